@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/runner"
+	"github.com/sensepost/gowitness/pkg/writers"
+	"github.com/spf13/cobra"
+)
+
+// doctorTarget is a self contained page used to test the browser setup
+const doctorTarget = "data:text/html,<html><head><title>gowitness doctor</title></head>" +
+	"<body style='background:%23fff'><h1>gowitness doctor</h1></body></html>"
+
+// doctorWriter keeps the results of a doctor scan
+type doctorWriter struct {
+	results []*models.Result
+}
+
+// Write records a result
+func (w *doctorWriter) Write(result *models.Result) error {
+	w.results = append(w.results, result)
+	return nil
+}
+
+var doctorOpts = runner.NewDefaultOptions()
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the browser setup works",
+	Long: ascii.LogoHelp(ascii.Markdown(`
+# doctor
+
+Check that the browser setup works.
+
+A local test page is screenshotted with the configured driver to confirm
+that the browser starts, and that screenshot capture and perception hashing
+succeed. The Chrome binary and version in use are reported as well.
+
+Run this before a large scan to catch a misconfigured --chrome-path or missing
+system dependencies up front. The command exits with a non-zero status if any
+check fails.`)),
+	Example: ascii.Markdown(`
+- gowitness doctor
+- gowitness doctor --driver gorod --chrome-path /usr/bin/chromium`),
+	// a failed check is not a usage error
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Printf("driver: %s\n", doctorOpts.Scan.Driver)
+
+		switch {
+		case doctorOpts.Scan.Driver == "webdriver":
+			fmt.Printf("webdriver url: %s\n", doctorOpts.Chrome.WebDriverURL)
+		case doctorOpts.Chrome.WSS != "":
+			fmt.Printf("chrome wss url: %s\n", doctorOpts.Chrome.WSS)
+		default:
+			path, err := chromeBinary(doctorOpts.Chrome.Path)
+			if err != nil {
+				fmt.Printf("chrome path: %s\n", err)
+				if doctorOpts.Scan.Driver != "gorod" {
+					return err
+				}
+				// go-rod downloads a browser if none is found
+			} else {
+				fmt.Printf("chrome path: %s\n", path)
+
+				version, err := chromeVersion(cmd.Context(), path)
+				if err != nil {
+					return fmt.Errorf("could not get the chrome version: %w", err)
+				}
+				fmt.Printf("chrome version: %s\n", version)
+			}
+		}
+
+		result, err := doctorScan()
+		if err != nil {
+			return err
+		}
+
+		if result.BrowserVersion != "" {
+			fmt.Printf("browser version: %s\n", result.BrowserVersion)
+		}
+		fmt.Printf("screenshot: ok (%s)\n", result.Filename)
+		fmt.Printf("perception hash: ok (%s)\n", result.PerceptionHash)
+		fmt.Println("all checks passed")
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().StringVar(&doctorOpts.Scan.Driver, "driver", doctorOpts.Scan.Driver, "The scan driver to check. Can be one of [gorod, chromedp, webdriver]")
+	doctorCmd.Flags().StringVar(&doctorOpts.Chrome.Path, "chrome-path", "", "The path to a Google Chrome binary to use (downloads a platform-appropriate binary by default)")
+	doctorCmd.Flags().StringVar(&doctorOpts.Chrome.WSS, "chrome-wss-url", "", "A websocket URL to connect to a remote, already running Chrome DevTools instance (i.e., Chrome started with --remote-debugging-port)")
+	doctorCmd.Flags().StringVar(&doctorOpts.Chrome.WebDriverURL, "webdriver-url", "", "A remote WebDriver endpoint used by the webdriver driver")
+	doctorCmd.Flags().IntVarP(&doctorOpts.Scan.Timeout, "timeout", "T", 30, "Number of seconds before considering the test page timed out")
+}
+
+// chromeBinary returns the Chrome binary that will be used to take
+// screenshots, either the configured path or one found on the system.
+func chromeBinary(path string) (string, error) {
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+
+	if path, found := launcher.LookPath(); found {
+		return path, nil
+	}
+
+	return "", errors.New("no chrome binary found, use --chrome-path to specify one")
+}
+
+// chromeVersion returns the version string reported by a Chrome binary
+func chromeVersion(ctx context.Context, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// doctorScan screenshots the doctor test page, returning the result if the
+// screenshot and perception hash were captured.
+func doctorScan() (*models.Result, error) {
+	screenshots, err := os.MkdirTemp("", "gowitness-doctor-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(screenshots)
+
+	options := *doctorOpts
+	options.Scan.ScreenshotPath = screenshots
+	options.Scan.UriFilter = []string{"data"}
+	options.Scan.Delay = 0
+	options.Scan.Threads = 1
+	options.Logging.LogScanErrors = true
+
+	logger := slog.New(log.Logger)
+	scanDriver, err := newDriver(logger, options)
+	if err != nil {
+		return nil, fmt.Errorf("could not start the %s driver: %w", options.Scan.Driver, err)
+	}
+
+	writer := &doctorWriter{}
+	scanRunner, err := runner.NewRunner(logger, scanDriver, options, []writers.Writer{writer})
+	if err != nil {
+		scanDriver.Close()
+		return nil, err
+	}
+
+	go func() {
+		scanRunner.Targets <- doctorTarget
+		close(scanRunner.Targets)
+	}()
+
+	scanRunner.Run()
+	scanRunner.Close()
+
+	if len(writer.results) == 0 {
+		return nil, errors.New("the test page could not be screenshotted, see the errors above")
+	}
+
+	result := writer.results[0]
+	if result.Failed {
+		return nil, fmt.Errorf("the test page failed to load: %s", result.FailedReason)
+	}
+
+	if _, err := os.Stat(filepath.Join(screenshots, result.Filename)); err != nil {
+		return nil, fmt.Errorf("the screenshot was not saved: %w", err)
+	}
+
+	if result.PerceptionHash == "" {
+		return nil, errors.New("the screenshot could not be perception hashed")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/sensepost/gowitness/pkg/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// applyProfile loads a scan profile into the global options, keeping the
+// values of any flags that were explicitly set on the command line. The
+// precedence is flag defaults, then the profile, then command line flags.
+func applyProfile(cmd *cobra.Command, name string) error {
+	type setFlag struct {
+		flag  *pflag.Flag
+		value string
+		slice []string
+	}
+
+	// remember what was set on the command line
+	var changed []setFlag
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		set := setFlag{flag: f, value: f.Value.String()}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			set.slice = slice.GetSlice()
+		}
+		changed = append(changed, set)
+	})
+
+	if err := runner.LoadProfile(name, opts); err != nil {
+		return err
+	}
+
+	// and apply it again over the profile values
+	for _, set := range changed {
+		if slice, ok := set.flag.Value.(pflag.SliceValue); ok {
+			if err := slice.Replace(set.slice); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := set.flag.Value.Set(set.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,8 +1,17 @@
 package cmd
 
 import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+
 	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/pkg/database"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/models"
 	"github.com/spf13/cobra"
+	"gorm.io/gorm/clause"
 )
 
 var reportCmd = &cobra.Command{
@@ -18,3 +27,57 @@ Work with gowitness reports.
 func init() {
 	rootCmd.AddCommand(reportCmd)
 }
+
+// readResults reads results from a JSON Lines file if one is given, otherwise
+// from the database at dbURI.
+func readResults(dbURI string, jsonFile string) ([]*models.Result, error) {
+	var results = []*models.Result{}
+
+	if jsonFile != "" {
+		file, err := os.Open(jsonFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		reader := bufio.NewReader(file)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				if err == io.EOF {
+					if len(line) == 0 {
+						break // End of file
+					}
+					// Handle the last line without '\n'
+				} else {
+					return nil, err
+				}
+			}
+
+			var result models.Result
+			if err := json.Unmarshal(line, &result); err != nil {
+				log.Error("could not unmarshal JSON line", "err", err)
+				continue
+			}
+			results = append(results, &result)
+
+			if err == io.EOF {
+				break
+			}
+		}
+
+		return results, nil
+	}
+
+	conn, err := database.Connection(dbURI, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Model(&models.Result{}).Preload(clause.Associations).
+		Preload("TLS.SanList").Preload("Network.Headers").Preload("WebSockets.Frames").Find(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,399 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// aquatoneVersion is the aquatone session format version that is written
+const aquatoneVersion = "1.7.0"
+
+// aquatoneSession is the aquatone_session.json file
+type aquatoneSession struct {
+	Version                string                   `json:"version"`
+	Stats                  aquatoneStats            `json:"stats"`
+	Pages                  map[string]*aquatonePage `json:"pages"`
+	PageSimilarityClusters map[string][]string      `json:"pageSimilarityClusters"`
+	Ports                  []int                    `json:"ports"`
+}
+
+// aquatoneStats are the session statistics
+type aquatoneStats struct {
+	StartedAt            time.Time `json:"startedAt"`
+	FinishedAt           time.Time `json:"finishedAt"`
+	PortOpen             uint32    `json:"portOpen"`
+	PortClosed           uint32    `json:"portClosed"`
+	RequestSuccessful    uint32    `json:"requestSuccessful"`
+	RequestFailed        uint32    `json:"requestFailed"`
+	ResponseCode2xx      uint32    `json:"responseCode2xx"`
+	ResponseCode3xx      uint32    `json:"responseCode3xx"`
+	ResponseCode4xx      uint32    `json:"responseCode4xx"`
+	ResponseCode5xx      uint32    `json:"responseCode5xx"`
+	ScreenshotSuccessful uint32    `json:"screenshotSuccessful"`
+	ScreenshotFailed     uint32    `json:"screenshotFailed"`
+}
+
+// aquatonePage is a page in an aquatone session
+type aquatonePage struct {
+	UUID           string           `json:"uuid"`
+	URL            string           `json:"url"`
+	Hostname       string           `json:"hostname"`
+	Addrs          []string         `json:"addrs"`
+	Status         string           `json:"status"`
+	PageTitle      string           `json:"pageTitle"`
+	PageStructure  []string         `json:"pageStructure"`
+	HeadersPath    string           `json:"headersPath"`
+	BodyPath       string           `json:"bodyPath"`
+	ScreenshotPath string           `json:"screenshotPath"`
+	HasScreenshot  bool             `json:"hasScreenshot"`
+	Headers        []aquatoneHeader `json:"headers"`
+	Tags           []aquatoneTag    `json:"tags"`
+	Notes          []string         `json:"notes"`
+}
+
+// aquatoneHeader is a response header of a page
+type aquatoneHeader struct {
+	Name              string `json:"name"`
+	Value             string `json:"value"`
+	DecreasesSecurity bool   `json:"decreasesSecurity"`
+	IncreasesSecurity bool   `json:"increasesSecurity"`
+}
+
+// aquatoneTag is a tag on a page, used for detected technologies
+type aquatoneTag struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+	Link string `json:"link"`
+	Hash string `json:"hash"`
+}
+
+var aquatoneCmdFlags = struct {
+	DbURI          string
+	JsonFile       string
+	ScreenshotPath string
+	OutputDir      string
+	Threshold      int
+}{}
+var aquatoneCmd = &cobra.Command{
+	Use:   "aquatone",
+	Short: "Export results in the aquatone report layout",
+	Long: ascii.LogoHelp(ascii.Markdown(`
+# report aquatone
+
+Export results in the aquatone report layout.
+
+The output directory contains the same files aquatone writes: an
+aquatone_session.json index, aquatone_urls.txt, and the screenshots, headers
+and html directories. Existing tooling that reads aquatone output can be used
+with gowitness results this way.
+
+Screenshots are copied from the --screenshot-path. Pages with similar
+screenshots are grouped into page similarity clusters using their perception
+hashes. To render the aquatone HTML report, run aquatone with
+_-session aquatone_session.json_ in the output directory.`)),
+	Example: ascii.Markdown(`
+- gowitness report aquatone --db-uri sqlite://gowitness.sqlite3 --output ./aquatone
+- gowitness report aquatone --json-file gowitness.jsonl --screenshot-path ./screenshots`),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if aquatoneCmdFlags.DbURI == "" && aquatoneCmdFlags.JsonFile == "" {
+			return errors.New("no data source defined")
+		}
+		if aquatoneCmdFlags.OutputDir == "" {
+			return errors.New("an output directory must be specified")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		results, err := readResults(aquatoneCmdFlags.DbURI, aquatoneCmdFlags.JsonFile)
+		if err != nil {
+			log.Error("could not read results", "err", err)
+			return
+		}
+
+		session, err := writeAquatoneReport(results, aquatoneCmdFlags.ScreenshotPath,
+			aquatoneCmdFlags.OutputDir, aquatoneCmdFlags.Threshold)
+		if err != nil {
+			log.Error("could not write aquatone report", "err", err)
+			return
+		}
+
+		log.Info("exported aquatone report", "pages", len(session.Pages),
+			"clusters", len(session.PageSimilarityClusters), "output", aquatoneCmdFlags.OutputDir)
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(aquatoneCmd)
+
+	aquatoneCmd.Flags().StringVar(&aquatoneCmdFlags.DbURI, "db-uri", "sqlite://gowitness.sqlite3", "The location of a gowitness database")
+	aquatoneCmd.Flags().StringVar(&aquatoneCmdFlags.JsonFile, "json-file", "", "The location of a JSON Lines results file (e.g., ./gowitness.jsonl). This flag takes precedence over --db-uri")
+	aquatoneCmd.Flags().StringVar(&aquatoneCmdFlags.ScreenshotPath, "screenshot-path", "./screenshots", "The path where screenshots are stored")
+	aquatoneCmd.Flags().StringVar(&aquatoneCmdFlags.OutputDir, "output", "aquatone", "The directory to write the aquatone report to")
+	aquatoneCmd.Flags().IntVar(&aquatoneCmdFlags.Threshold, "threshold", 10, "The maximum Hamming distance between perception hashes for pages to be in the same similarity cluster")
+}
+
+// aquatoneBaseFilename returns the file name aquatone uses for a url, e.g.
+// https__example_com__443__<path hash>. Unlike aquatone, the query is part
+// of the hash so urls that only differ by query get their own files.
+func aquatoneBaseFilename(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		sum := sha1.Sum([]byte(target))
+		return hex.EncodeToString(sum[:])[:16]
+	}
+
+	path := u.Path
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	sum := sha1.Sum([]byte(path + u.Fragment))
+	host := strings.Replace(u.Host, ":", "__", 1)
+	filename := fmt.Sprintf("%s__%s__%s", u.Scheme, strings.ReplaceAll(host, ".", "_"), hex.EncodeToString(sum[:])[:16])
+
+	return strings.ToLower(filename)
+}
+
+// aquatoneUUID returns a stable page uuid for a url
+func aquatoneUUID(target string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(target)).String()
+}
+
+// writeAquatoneReport writes results to an aquatone report directory
+func writeAquatoneReport(results []*models.Result, screenshotPath, outputDir string, threshold int) (*aquatoneSession, error) {
+	for _, dir := range []string{"screenshots", "headers", "html"} {
+		if err := os.MkdirAll(filepath.Join(outputDir, dir), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	session := &aquatoneSession{
+		Version:                aquatoneVersion,
+		Pages:                  make(map[string]*aquatonePage),
+		PageSimilarityClusters: make(map[string][]string),
+	}
+
+	ports := make(map[int]bool)
+	bases := make(map[string]int)
+	var urls []string
+
+	for _, result := range results {
+		page := aquatonePageFromResult(result)
+		if _, ok := session.Pages[page.UUID]; ok {
+			continue
+		}
+		base := aquatoneBaseFilename(result.URL)
+
+		// urls that only differ by case share a file name
+		if n := bases[base]; n > 0 {
+			bases[base]++
+			base = fmt.Sprintf("%s__%d", base, n)
+		} else {
+			bases[base] = 1
+		}
+
+		// stats
+		if session.Stats.StartedAt.IsZero() || result.ProbedAt.Before(session.Stats.StartedAt) {
+			session.Stats.StartedAt = result.ProbedAt
+		}
+		if result.ProbedAt.After(session.Stats.FinishedAt) {
+			session.Stats.FinishedAt = result.ProbedAt
+		}
+		if result.ResponseCode == 0 {
+			session.Stats.RequestFailed++
+		} else {
+			session.Stats.RequestSuccessful++
+		}
+		switch result.ResponseCode / 100 {
+		case 2:
+			session.Stats.ResponseCode2xx++
+		case 3:
+			session.Stats.ResponseCode3xx++
+		case 4:
+			session.Stats.ResponseCode4xx++
+		case 5:
+			session.Stats.ResponseCode5xx++
+		}
+
+		if u, err := url.Parse(result.URL); err == nil {
+			if port, err := strconv.Atoi(u.Port()); err == nil {
+				ports[port] = true
+			} else if u.Scheme == "https" {
+				ports[443] = true
+			} else if u.Scheme == "http" {
+				ports[80] = true
+			}
+		}
+
+		// headers and html
+		page.HeadersPath = filepath.ToSlash(filepath.Join("headers", base+".txt"))
+		if err := os.WriteFile(filepath.Join(outputDir, page.HeadersPath), []byte(aquatoneHeadersFile(result)), 0644); err != nil {
+			return nil, err
+		}
+		page.BodyPath = filepath.ToSlash(filepath.Join("html", base+".html"))
+		if err := os.WriteFile(filepath.Join(outputDir, page.BodyPath), []byte(result.HTML), 0644); err != nil {
+			return nil, err
+		}
+
+		// screenshot, decompressed as aquatone reports link to it directly
+		if result.Filename != "" && !result.Failed {
+			screenshot := filepath.ToSlash(filepath.Join("screenshots", base+filepath.Ext(strings.TrimSuffix(result.Filename, ".gz"))))
+			if err := copyScreenshot(filepath.Join(screenshotPath, result.Filename), filepath.Join(outputDir, screenshot)); err != nil {
+				log.Warn("could not copy screenshot", "file", result.Filename, "err", err)
+			} else {
+				page.ScreenshotPath = screenshot
+				page.HasScreenshot = true
+			}
+		}
+		if page.HasScreenshot {
+			session.Stats.ScreenshotSuccessful++
+		} else {
+			session.Stats.ScreenshotFailed++
+		}
+
+		session.Pages[page.UUID] = page
+		urls = append(urls, result.URL)
+	}
+	session.Stats.PortOpen = uint32(len(ports))
+
+	for port := range ports {
+		session.Ports = append(session.Ports, port)
+	}
+	sort.Ints(session.Ports)
+
+	// similar pages, by perception hash
+	for _, cluster := range clusterByPerceptionHash(results, threshold) {
+		var pages []string
+		seen := make(map[string]bool)
+		for _, u := range cluster.URLs {
+			id := aquatoneUUID(u)
+			if !seen[id] {
+				seen[id] = true
+				pages = append(pages, id)
+			}
+		}
+		session.PageSimilarityClusters[aquatoneUUID(fmt.Sprintf("cluster-%d", cluster.ID))] = pages
+	}
+
+	j, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "aquatone_session.json"), j, 0644); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(filepath.Join(outputDir, "aquatone_urls.txt"), []byte(strings.Join(urls, "\n")+"\n"), 0644); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// aquatonePageFromResult converts a result to an aquatone page
+func aquatonePageFromResult(result *models.Result) *aquatonePage {
+	page := &aquatonePage{
+		UUID:          aquatoneUUID(result.URL),
+		URL:           result.URL,
+		PageTitle:     result.Title,
+		PageStructure: []string{},
+		Addrs:         []string{},
+		Headers:       []aquatoneHeader{},
+		Tags:          []aquatoneTag{},
+		Notes:         []string{},
+	}
+
+	if u, err := url.Parse(result.URL); err == nil {
+		page.Hostname = u.Hostname()
+	}
+
+	if result.RemoteAddr != "" {
+		page.Addrs = append(page.Addrs, result.RemoteAddr)
+	}
+
+	if result.ResponseCode != 0 {
+		reason := result.ResponseReason
+		if reason == "" {
+			reason = http.StatusText(result.ResponseCode)
+		}
+		page.Status = strings.TrimSpace(fmt.Sprintf("%d %s", result.ResponseCode, reason))
+	}
+
+	for _, header := range result.Headers {
+		page.Headers = append(page.Headers, aquatoneHeader{
+			Name:  header.Key,
+			Value: header.Value,
+		})
+	}
+
+	for _, tech := range result.Technologies {
+		page.Tags = append(page.Tags, aquatoneTag{
+			Text: tech.Value,
+			Type: "info",
+			Hash: aquatoneUUID(tech.Value),
+		})
+	}
+
+	return page
+}
+
+// aquatoneHeadersFile returns the contents of a page's headers file
+func aquatoneHeadersFile(result *models.Result) string {
+	var b strings.Builder
+	for _, header := range result.Headers {
+		fmt.Fprintf(&b, "%s: %s\n", header.Key, header.Value)
+	}
+
+	return b.String()
+}
+
+// copyScreenshot copies a screenshot from source to destination,
+// decompressing gzip compressed (.gz) screenshots
+func copyScreenshot(source, destination string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var in io.Reader = file
+	if strings.HasSuffix(source, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		in = gz
+	}
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/x/term"
+	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// certificateGroup is a set of results that presented the same leaf certificate
+type certificateGroup struct {
+	Fingerprint string   `json:"fingerprint_sha256"`
+	SubjectName string   `json:"subject_name"`
+	Issuer      string   `json:"issuer"`
+	URLs        []string `json:"urls"`
+}
+
+var certificatesCmdFlags = struct {
+	DbURI    string
+	JsonFile string
+	MinSize  int
+	Json     bool
+}{}
+var certificatesCmd = &cobra.Command{
+	Use:   "certificates",
+	Short: "Group results that share the same TLS certificate",
+	Long: ascii.LogoHelp(ascii.Markdown(`
+# report certificates
+
+Group results that share the same TLS certificate.
+
+Results are grouped by the SHA-256 fingerprint of the leaf certificate that was
+presented. Hosts sharing a certificate are usually part of the same
+infrastructure, such as shared hosting or a CDN.
+
+By default only groups with two or more results are shown. Use --min-size 1 to
+list every certificate.`)),
+	Example: ascii.Markdown(`
+- gowitness report certificates
+- gowitness report certificates --json-file gowitness.jsonl --json
+- gowitness report certificates --db-uri sqlite://gowitness.sqlite3 --min-size 5`),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if certificatesCmdFlags.DbURI == "" && certificatesCmdFlags.JsonFile == "" {
+			return errors.New("no data source defined")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		results, err := readResults(certificatesCmdFlags.DbURI, certificatesCmdFlags.JsonFile)
+		if err != nil {
+			log.Error("could not read results", "err", err)
+			return
+		}
+
+		groups := groupByCertificate(results, certificatesCmdFlags.MinSize)
+
+		if certificatesCmdFlags.Json {
+			j, err := json.MarshalIndent(groups, "", "  ")
+			if err != nil {
+				log.Error("could not marshal certificate groups", "err", err)
+				return
+			}
+
+			fmt.Println(string(j))
+			return
+		}
+
+		renderCertificateGroups(groups)
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(certificatesCmd)
+
+	certificatesCmd.Flags().StringVar(&certificatesCmdFlags.DbURI, "db-uri", "sqlite://gowitness.sqlite3", "The location of a gowitness database")
+	certificatesCmd.Flags().StringVar(&certificatesCmdFlags.JsonFile, "json-file", "", "The location of a JSON Lines results file (e.g., ./gowitness.jsonl). This flag takes precedence over --db-uri")
+	certificatesCmd.Flags().IntVar(&certificatesCmdFlags.MinSize, "min-size", 2, "The minimum number of results a certificate group needs to be shown")
+	certificatesCmd.Flags().BoolVar(&certificatesCmdFlags.Json, "json", false, "Output the groups as JSON")
+}
+
+// groupByCertificate groups results by leaf certificate fingerprint, largest
+// groups first.
+func groupByCertificate(results []*models.Result, minSize int) []*certificateGroup {
+	groups := make(map[string]*certificateGroup)
+
+	for _, result := range results {
+		fingerprint := result.TLS.FingerprintSHA256
+		if fingerprint == "" {
+			continue
+		}
+
+		group, ok := groups[fingerprint]
+		if !ok {
+			group = &certificateGroup{
+				Fingerprint: fingerprint,
+				SubjectName: result.TLS.SubjectName,
+				Issuer:      result.TLS.Issuer,
+			}
+			groups[fingerprint] = group
+		}
+
+		group.URLs = append(group.URLs, result.URL)
+	}
+
+	var sorted []*certificateGroup
+	for _, group := range groups {
+		if len(group.URLs) >= minSize {
+			sorted = append(sorted, group)
+		}
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].URLs) == len(sorted[j].URLs) {
+			return sorted[i].Fingerprint < sorted[j].Fingerprint
+		}
+		return len(sorted[i].URLs) > len(sorted[j].URLs)
+	})
+
+	return sorted
+}
+
+func renderCertificateGroups(groups []*certificateGroup) {
+	PaddedStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+	HeaderStyle := PaddedStyle.Bold(true).Underline(true)
+	RowStyle := PaddedStyle
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99"))).
+		Headers("Fingerprint", "Subject", "Issuer", "Count", "URLs").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == 0:
+				return HeaderStyle
+			default:
+				return RowStyle
+			}
+		})
+
+	for _, group := range groups {
+		t.Row(
+			truncate(group.Fingerprint, 16),
+			titleStyle(group.SubjectName),
+			titleStyle(group.Issuer),
+			fmt.Sprintf("%d", len(group.URLs)),
+			strings.Join(group.URLs, "\n"),
+		)
+	}
+
+	w, _, _ := term.GetSize(os.Stdout.Fd())
+	fmt.Println(lipgloss.NewStyle().MaxWidth(w).Render(t.String()))
+}
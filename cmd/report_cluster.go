@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/x/term"
+	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/database"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// perceptionCluster is a set of results with similar screenshots
+type perceptionCluster struct {
+	ID        uint     `json:"id"`
+	Hash      string   `json:"perception_hash"`
+	ResultIDs []uint   `json:"result_ids"`
+	URLs      []string `json:"urls"`
+
+	hash []byte
+}
+
+var clusterCmdFlags = struct {
+	DbURI     string
+	Threshold int
+	MinSize   int
+	Write     bool
+	Json      bool
+}{}
+var clusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Cluster results by screenshot similarity",
+	Long: ascii.LogoHelp(ascii.Markdown(`
+# report cluster
+
+Cluster results by screenshot similarity.
+
+Results are grouped by the Hamming distance between their screenshot
+perception hashes, without rescanning. This is useful to find groups of
+identical pages, such as default installations or parking pages, across a
+database built over multiple scans.
+
+Use --write to store the cluster IDs as the perception hash group of each
+result, replacing the groups assigned while scanning.`)),
+	Example: ascii.Markdown(`
+- gowitness report cluster
+- gowitness report cluster --threshold 5 --min-size 3
+- gowitness report cluster --db-uri sqlite://gowitness.sqlite3 --write`),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if clusterCmdFlags.DbURI == "" {
+			return errors.New("a database uri must be specified")
+		}
+		if clusterCmdFlags.Threshold < 0 {
+			return errors.New("threshold cannot be negative")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, err := database.Connection(clusterCmdFlags.DbURI, true, false)
+		if err != nil {
+			log.Error("could not connect to database", "err", err)
+			return
+		}
+
+		var results []*models.Result
+		if err := conn.Model(&models.Result{}).
+			Select("id", "url", "perception_hash").
+			Where("perception_hash != ''").
+			Order("id").Find(&results).Error; err != nil {
+			log.Error("could not read results", "err", err)
+			return
+		}
+
+		clusters := clusterByPerceptionHash(results, clusterCmdFlags.Threshold)
+		log.Info("clustered results", "results", len(results), "clusters", len(clusters))
+
+		if clusterCmdFlags.Write {
+			if err := writeClusters(conn, clusters); err != nil {
+				log.Error("could not write cluster ids", "err", err)
+				return
+			}
+			log.Info("updated perception hash groups")
+		}
+
+		// only show clusters of the minimum size, largest first
+		var shown []*perceptionCluster
+		for _, cluster := range clusters {
+			if len(cluster.ResultIDs) >= clusterCmdFlags.MinSize {
+				shown = append(shown, cluster)
+			}
+		}
+		sort.SliceStable(shown, func(i, j int) bool {
+			return len(shown[i].ResultIDs) > len(shown[j].ResultIDs)
+		})
+
+		if clusterCmdFlags.Json {
+			j, err := json.MarshalIndent(shown, "", "  ")
+			if err != nil {
+				log.Error("could not marshal clusters", "err", err)
+				return
+			}
+
+			fmt.Println(string(j))
+			return
+		}
+
+		renderClusters(shown)
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(clusterCmd)
+
+	clusterCmd.Flags().StringVar(&clusterCmdFlags.DbURI, "db-uri", "sqlite://gowitness.sqlite3", "The location of a gowitness database")
+	clusterCmd.Flags().IntVar(&clusterCmdFlags.Threshold, "threshold", 10, "The maximum Hamming distance between perception hashes in the same cluster")
+	clusterCmd.Flags().IntVar(&clusterCmdFlags.MinSize, "min-size", 2, "The minimum number of results a cluster needs to be shown")
+	clusterCmd.Flags().BoolVar(&clusterCmdFlags.Write, "write", false, "Write the cluster IDs back to the database as perception hash groups")
+	clusterCmd.Flags().BoolVar(&clusterCmdFlags.Json, "json", false, "Output the clusters as JSON")
+}
+
+// clusterByPerceptionHash assigns each result to the first cluster whose
+// hash is within threshold, or starts a new cluster. This is the same
+// approach the database writer uses while scanning.
+func clusterByPerceptionHash(results []*models.Result, threshold int) []*perceptionCluster {
+	var clusters []*perceptionCluster
+
+	for _, result := range results {
+		hash, err := islazy.ParsePerceptionHash(result.PerceptionHash)
+		if err != nil {
+			continue
+		}
+
+		var cluster *perceptionCluster
+		for _, c := range clusters {
+			distance, err := islazy.HammingDistance(hash, c.hash)
+			if err == nil && distance <= threshold {
+				cluster = c
+				break
+			}
+		}
+
+		if cluster == nil {
+			cluster = &perceptionCluster{
+				ID:   uint(len(clusters) + 1),
+				Hash: result.PerceptionHash,
+				hash: hash,
+			}
+			clusters = append(clusters, cluster)
+		}
+
+		cluster.ResultIDs = append(cluster.ResultIDs, result.ID)
+		cluster.URLs = append(cluster.URLs, result.URL)
+	}
+
+	return clusters
+}
+
+// writeClusters stores cluster ids as result perception hash groups
+func writeClusters(conn *gorm.DB, clusters []*perceptionCluster) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		for _, cluster := range clusters {
+			if err := tx.Model(&models.Result{}).
+				Where("id IN ?", cluster.ResultIDs).
+				Update("perception_hash_group_id", cluster.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func renderClusters(clusters []*perceptionCluster) {
+	PaddedStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+	HeaderStyle := PaddedStyle.Bold(true).Underline(true)
+	RowStyle := PaddedStyle
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99"))).
+		Headers("Cluster", "Perception Hash", "Count", "URLs").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == 0:
+				return HeaderStyle
+			default:
+				return RowStyle
+			}
+		})
+
+	for _, cluster := range clusters {
+		t.Row(
+			fmt.Sprintf("%d", cluster.ID),
+			cluster.Hash,
+			fmt.Sprintf("%d", len(cluster.ResultIDs)),
+			strings.Join(cluster.URLs, "\n"),
+		)
+	}
+
+	w, _, _ := term.GetSize(os.Stdout.Fd())
+	fmt.Println(lipgloss.NewStyle().MaxWidth(w).Render(t.String()))
+}
@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/spf13/cobra"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// contactSheetPadding is the space around thumbnails, in pixels
+	contactSheetPadding = 12
+	// contactSheetLineHeight is the height of a label line, in pixels
+	contactSheetLineHeight = 15
+)
+
+var (
+	contactSheetBackground  = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	contactSheetBorder      = color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	contactSheetPlaceholder = color.RGBA{0xee, 0xee, 0xee, 0xff}
+	contactSheetText        = color.RGBA{0x22, 0x22, 0x22, 0xff}
+	contactSheetMutedText   = color.RGBA{0x77, 0x77, 0x77, 0xff}
+)
+
+var contactSheetCmdFlags = struct {
+	DbURI          string
+	JsonFile       string
+	ScreenshotPath string
+	Output         string
+	Columns        int
+	Width          int
+	NoLabels       bool
+	Failed         bool
+}{}
+var contactSheetCmd = &cobra.Command{
+	Use:   "contact-sheet",
+	Short: "Render screenshots as a single contact sheet image",
+	Long: ascii.LogoHelp(ascii.Markdown(`
+# report contact-sheet
+
+Render screenshots as a single contact sheet image.
+
+The contact sheet is a grid of screenshot thumbnails, each labelled with
+its URL, response code and title, for a quick printable overview of a scan
+(e.g., in an executive summary). Thumbnails show the top of each page,
+cropped to a 16:10 aspect ratio.
+
+Screenshots are read from the --screenshot-path, or from the results when
+they were stored inline. The image is written as a PNG, or as a JPEG if
+the output file has a .jpg or .jpeg extension.`)),
+	Example: ascii.Markdown(`
+- gowitness report contact-sheet --output contact-sheet.png
+- gowitness report contact-sheet --json-file gowitness.jsonl --columns 6 --width 240
+- gowitness report contact-sheet --output overview.jpeg --no-labels`),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if contactSheetCmdFlags.DbURI == "" && contactSheetCmdFlags.JsonFile == "" {
+			return errors.New("no data source defined")
+		}
+		if contactSheetCmdFlags.Output == "" {
+			return errors.New("an output file must be specified")
+		}
+		if contactSheetCmdFlags.Columns < 1 {
+			return errors.New("columns must be at least 1")
+		}
+		if contactSheetCmdFlags.Width < 64 {
+			return errors.New("thumbnail width must be at least 64 pixels")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		results, err := readResults(contactSheetCmdFlags.DbURI, contactSheetCmdFlags.JsonFile)
+		if err != nil {
+			log.Error("could not read results", "err", err)
+			return
+		}
+
+		var sheet []*models.Result
+		for _, result := range results {
+			if result.Failed && !contactSheetCmdFlags.Failed {
+				continue
+			}
+			if result.Filename == "" && result.Screenshot == "" {
+				continue
+			}
+			sheet = append(sheet, result)
+		}
+
+		if len(sheet) == 0 {
+			log.Warn("no results with screenshots to render")
+			return
+		}
+
+		img := renderContactSheet(sheet, contactSheetCmdFlags.ScreenshotPath,
+			contactSheetCmdFlags.Columns, contactSheetCmdFlags.Width, !contactSheetCmdFlags.NoLabels)
+
+		if err := writeContactSheet(img, contactSheetCmdFlags.Output); err != nil {
+			log.Error("could not write contact sheet", "err", err)
+			return
+		}
+
+		log.Info("rendered contact sheet", "screenshots", len(sheet), "output", contactSheetCmdFlags.Output,
+			"width", img.Bounds().Dx(), "height", img.Bounds().Dy())
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(contactSheetCmd)
+
+	contactSheetCmd.Flags().StringVar(&contactSheetCmdFlags.DbURI, "db-uri", "sqlite://gowitness.sqlite3", "The location of a gowitness database")
+	contactSheetCmd.Flags().StringVar(&contactSheetCmdFlags.JsonFile, "json-file", "", "The location of a JSON Lines results file (e.g., ./gowitness.jsonl). This flag takes precedence over --db-uri")
+	contactSheetCmd.Flags().StringVar(&contactSheetCmdFlags.ScreenshotPath, "screenshot-path", "./screenshots", "The path where screenshots are stored")
+	contactSheetCmd.Flags().StringVar(&contactSheetCmdFlags.Output, "output", "contact-sheet.png", "The image file to write the contact sheet to")
+	contactSheetCmd.Flags().IntVar(&contactSheetCmdFlags.Columns, "columns", 4, "The number of thumbnails per row")
+	contactSheetCmd.Flags().IntVar(&contactSheetCmdFlags.Width, "width", 320, "The width of a thumbnail in pixels")
+	contactSheetCmd.Flags().BoolVar(&contactSheetCmdFlags.NoLabels, "no-labels", false, "Do not label thumbnails with their URL, response code and title")
+	contactSheetCmd.Flags().BoolVar(&contactSheetCmdFlags.Failed, "failed", false, "Include results marked as failed")
+}
+
+// renderContactSheet draws the screenshots of results as a grid of labelled
+// thumbnails
+func renderContactSheet(results []*models.Result, screenshotPath string, columns int, width int, labels bool) *image.RGBA {
+	columns = min(columns, len(results))
+	rows := (len(results) + columns - 1) / columns
+
+	thumbHeight := width * 10 / 16
+	labelHeight := 0
+	if labels {
+		labelHeight = 2*contactSheetLineHeight + 4
+	}
+	cellHeight := thumbHeight + labelHeight
+
+	sheet := image.NewRGBA(image.Rect(0, 0,
+		columns*width+(columns+1)*contactSheetPadding,
+		rows*cellHeight+(rows+1)*contactSheetPadding))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(contactSheetBackground), image.Point{}, draw.Src)
+
+	for i, result := range results {
+		x := contactSheetPadding + (i%columns)*(width+contactSheetPadding)
+		y := contactSheetPadding + (i/columns)*(cellHeight+contactSheetPadding)
+		thumb := image.Rect(x, y, x+width, y+thumbHeight)
+
+		screenshot, err := loadScreenshot(result, screenshotPath)
+		if err != nil {
+			log.Warn("could not load screenshot", "url", result.URL, "file", result.Filename, "err", err)
+			draw.Draw(sheet, thumb, image.NewUniform(contactSheetPlaceholder), image.Point{}, draw.Src)
+		} else {
+			drawThumbnail(sheet, thumb, screenshot)
+		}
+		drawBorder(sheet, thumb)
+
+		if labels {
+			status := strconv.Itoa(result.ResponseCode)
+			if result.Title != "" {
+				status += " - " + result.Title
+			}
+
+			drawLabel(sheet, x, y+thumbHeight+contactSheetLineHeight, width, result.URL, contactSheetText)
+			drawLabel(sheet, x, y+thumbHeight+2*contactSheetLineHeight, width, status, contactSheetMutedText)
+		}
+	}
+
+	return sheet
+}
+
+// loadScreenshot decodes the screenshot of a result, from the inline base64
+// screenshot or the (possibly gzip compressed) screenshot file
+func loadScreenshot(result *models.Result, screenshotPath string) (image.Image, error) {
+	if result.Filename == "" {
+		data, err := base64.StdEncoding.DecodeString(result.Screenshot)
+		if err != nil {
+			return nil, err
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(data))
+		return img, err
+	}
+
+	file, err := os.Open(filepath.Join(screenshotPath, result.Filename))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(result.Filename, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	img, _, err := image.Decode(reader)
+	return img, err
+}
+
+// drawThumbnail scales the top of a screenshot into a thumbnail rectangle,
+// cropping tall (e.g. full page) screenshots to the aspect ratio of the
+// thumbnail
+func drawThumbnail(dst *image.RGBA, thumb image.Rectangle, src image.Image) {
+	bounds := src.Bounds()
+	crop := bounds
+	if height := bounds.Dx() * thumb.Dy() / thumb.Dx(); height < bounds.Dy() {
+		crop.Max.Y = bounds.Min.Y + height
+	}
+
+	// short screenshots keep their aspect ratio, with the rest of the
+	// thumbnail left as a placeholder
+	target := thumb
+	if height := crop.Dy() * thumb.Dx() / crop.Dx(); height < thumb.Dy() {
+		draw.Draw(dst, thumb, image.NewUniform(contactSheetPlaceholder), image.Point{}, draw.Src)
+		target.Max.Y = target.Min.Y + height
+	}
+
+	draw.CatmullRom.Scale(dst, target, src, crop, draw.Src, nil)
+}
+
+// drawBorder draws a one pixel border around a rectangle
+func drawBorder(dst *image.RGBA, r image.Rectangle) {
+	for x := r.Min.X - 1; x <= r.Max.X; x++ {
+		dst.Set(x, r.Min.Y-1, contactSheetBorder)
+		dst.Set(x, r.Max.Y, contactSheetBorder)
+	}
+	for y := r.Min.Y - 1; y <= r.Max.Y; y++ {
+		dst.Set(r.Min.X-1, y, contactSheetBorder)
+		dst.Set(r.Max.X, y, contactSheetBorder)
+	}
+}
+
+// drawLabel draws a line of text with its baseline at y, truncated to fit
+// in width
+func drawLabel(dst *image.RGBA, x int, y int, width int, text string, c color.Color) {
+	face := basicfont.Face7x13
+	maxChars := width / face.Advance
+
+	runes := []rune(text)
+	if len(runes) > maxChars {
+		runes = append(runes[:maxChars-3], []rune("...")...)
+	}
+
+	drawer := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(string(runes))
+}
+
+// writeContactSheet encodes the contact sheet as a PNG, or a JPEG for .jpg
+// and .jpeg file names
+func writeContactSheet(img image.Image, output string) error {
+	path, err := islazy.CreateFileWithDir(output)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		err = jpeg.Encode(file, img, &jpeg.Options{Quality: 90})
+	default:
+		err = png.Encode(file, img)
+	}
+	if err != nil {
+		return fmt.Errorf("could not encode contact sheet: %w", err)
+	}
+
+	return file.Close()
+}
@@ -76,14 +76,17 @@ target.`)),
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		var writer writers.Writer
-		var err error
 		if convertCmdFlags.toExt == ".sqlite3" {
-			writer, err = writers.NewDbWriter(fmt.Sprintf("sqlite://%s", convertCmdFlags.toFile), false)
+			dbWriter, err := writers.NewDbWriter(fmt.Sprintf("sqlite://%s", convertCmdFlags.toFile), false, "", 1)
 			if err != nil {
 				log.Error("could not get a database writer up", "err", err)
 				return
 			}
-			if err := convertFromJsonlTo(convertCmdFlags.fromFile, writer); err != nil {
+			if err := convertFromJsonlTo(convertCmdFlags.fromFile, dbWriter); err != nil {
+				log.Error("failed to convert to SQLite", "err", err)
+				return
+			}
+			if err := dbWriter.Close(); err != nil {
 				log.Error("failed to convert to SQLite", "err", err)
 				return
 			}
@@ -120,7 +123,8 @@ func convertFromDbTo(from string, writer writers.Writer) error {
 		return err
 	}
 
-	if err := conn.Model(&models.Result{}).Preload(clause.Associations).Find(&results).Error; err != nil {
+	if err := conn.Model(&models.Result{}).Preload(clause.Associations).
+		Preload("TLS.SanList").Preload("Network.Headers").Preload("WebSockets.Frames").Find(&results).Error; err != nil {
 		return err
 	}
 
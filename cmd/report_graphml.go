@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// graphmlNamespace is the XML namespace of GraphML documents
+const graphmlNamespace = "http://graphml.graphdrawing.org/xmlns"
+
+// structures for GraphML encoding
+type graphmlDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   graphmlGraph `xml:"graph"`
+}
+
+type graphmlKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphmlGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+type graphmlNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphmlKeys are the node and edge attributes of the graph. Gephi uses the
+// label as node label and the weight as edge weight.
+var graphmlKeys = []graphmlKey{
+	{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+	{ID: "type", For: "node", AttrName: "type", AttrType: "string"},
+	{ID: "relation", For: "edge", AttrName: "relation", AttrType: "string"},
+	{ID: "weight", For: "edge", AttrName: "weight", AttrType: "double"},
+}
+
+var graphmlCmdFlags = struct {
+	DbURI        string
+	JsonFile     string
+	OutputFile   string
+	Certificates bool
+	IPs          bool
+	Versions     bool
+}{}
+var graphmlCmd = &cobra.Command{
+	Use:   "graphml",
+	Short: "Export a GraphML graph of hosts and their technologies",
+	Long: ascii.LogoHelp(ascii.Markdown(`
+# report graphml
+
+Export a GraphML graph of hosts and their technologies.
+
+Nodes are the hosts of the results and the technologies detected on them,
+with an edge between a host and every technology it runs. Hosts running the
+same technologies cluster together, which makes shared infrastructure easy to
+spot. Use --certificates and --ips to also add the TLS certificates hosts
+presented and the IP addresses that served them, linking hosts that share a
+certificate or an address.
+
+Every node has a type (host, technology, certificate or ip) and a label, and
+every edge a relation and a weight, the number of results it was seen in.
+Open the file in Gephi (or any other tool that reads GraphML) to visualize
+the graph.`)),
+	Example: ascii.Markdown(`
+- gowitness report graphml --output gowitness.graphml
+- gowitness report graphml --json-file gowitness.jsonl --certificates --ips`),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if graphmlCmdFlags.DbURI == "" && graphmlCmdFlags.JsonFile == "" {
+			return errors.New("no data source defined")
+		}
+		if graphmlCmdFlags.OutputFile == "" {
+			return errors.New("an output file must be specified")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		results, err := readResults(graphmlCmdFlags.DbURI, graphmlCmdFlags.JsonFile)
+		if err != nil {
+			log.Error("could not read results", "err", err)
+			return
+		}
+
+		graph := technologyGraph(results, graphmlCmdFlags.Certificates, graphmlCmdFlags.IPs, graphmlCmdFlags.Versions)
+		if err := writeGraphml(graphmlCmdFlags.OutputFile, graph); err != nil {
+			log.Error("could not write graphml", "err", err)
+			return
+		}
+
+		log.Info("exported graphml graph", "results", len(results), "nodes", len(graph.Nodes),
+			"edges", len(graph.Edges), "output", graphmlCmdFlags.OutputFile)
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(graphmlCmd)
+
+	graphmlCmd.Flags().StringVar(&graphmlCmdFlags.DbURI, "db-uri", "sqlite://gowitness.sqlite3", "The location of a gowitness database")
+	graphmlCmd.Flags().StringVar(&graphmlCmdFlags.JsonFile, "json-file", "", "The location of a JSON Lines results file (e.g., ./gowitness.jsonl). This flag takes precedence over --db-uri")
+	graphmlCmd.Flags().StringVar(&graphmlCmdFlags.OutputFile, "output", "gowitness.graphml", "The file to write the GraphML graph to")
+	graphmlCmd.Flags().BoolVar(&graphmlCmdFlags.Certificates, "certificates", false, "Add the TLS certificates presented by hosts as nodes")
+	graphmlCmd.Flags().BoolVar(&graphmlCmdFlags.IPs, "ips", false, "Add the IP addresses that served hosts as nodes")
+	graphmlCmd.Flags().BoolVar(&graphmlCmdFlags.Versions, "versions", false, "Keep technology versions, making every version of a technology a separate node")
+}
+
+// technologyGraph builds a graph of the hosts in results, linked to their
+// technologies and optionally their certificates and IP addresses
+func technologyGraph(results []*models.Result, certificates bool, ips bool, versions bool) graphmlGraph {
+	graph := graphmlGraph{ID: "gowitness", EdgeDefault: "undirected"}
+
+	nodes := make(map[string]string)
+	node := func(nodeType, key, label string) string {
+		if id, ok := nodes[nodeType+"\x00"+key]; ok {
+			return id
+		}
+
+		id := fmt.Sprintf("n%d", len(graph.Nodes))
+		nodes[nodeType+"\x00"+key] = id
+		graph.Nodes = append(graph.Nodes, graphmlNode{
+			ID: id,
+			Data: []graphmlData{
+				{Key: "label", Value: label},
+				{Key: "type", Value: nodeType},
+			},
+		})
+
+		return id
+	}
+
+	// edges are counted once per result, as weight
+	edges := make(map[string]int)
+	var order []string
+	edge := func(source, target, relation string, linked map[string]bool) {
+		key := source + "\x00" + target + "\x00" + relation
+		if linked[key] {
+			return
+		}
+		linked[key] = true
+
+		if _, ok := edges[key]; !ok {
+			order = append(order, key)
+		}
+		edges[key]++
+	}
+
+	for _, result := range results {
+		target := result.URL
+		if result.FinalURL != "" {
+			target = result.FinalURL
+		}
+
+		u, err := url.Parse(target)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+
+		linked := make(map[string]bool)
+		host := node("host", strings.ToLower(u.Hostname()), strings.ToLower(u.Hostname()))
+
+		for _, tech := range result.Technologies {
+			name := tech.Value
+			if !versions {
+				// fingerprints may have a version, e.g. nginx:1.25.3
+				name, _, _ = strings.Cut(name, ":")
+			}
+			if name == "" {
+				continue
+			}
+
+			edge(host, node("technology", strings.ToLower(name), name), "runs", linked)
+		}
+
+		if certificates && result.TLS.SubjectName != "" {
+			key := result.TLS.FingerprintSHA256
+			if key == "" {
+				key = result.TLS.SubjectName + "\x00" + result.TLS.Issuer
+			}
+
+			edge(host, node("certificate", key, result.TLS.SubjectName), "presents", linked)
+		}
+
+		if ips {
+			// the ip address that served the final url
+			for _, entry := range result.Network {
+				if entry.URL == target && entry.RemoteIP != "" {
+					edge(host, node("ip", entry.RemoteIP, entry.RemoteIP), "resolves to", linked)
+					break
+				}
+			}
+		}
+	}
+
+	for i, key := range order {
+		parts := strings.SplitN(key, "\x00", 3)
+		graph.Edges = append(graph.Edges, graphmlEdge{
+			ID:     fmt.Sprintf("e%d", i),
+			Source: parts[0],
+			Target: parts[1],
+			Data: []graphmlData{
+				{Key: "relation", Value: parts[2]},
+				{Key: "weight", Value: fmt.Sprint(edges[key])},
+			},
+		})
+	}
+
+	return graph
+}
+
+// writeGraphml writes a graph to a GraphML file
+func writeGraphml(destination string, graph graphmlGraph) error {
+	p, err := islazy.CreateFileWithDir(destination)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(p, os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(graphmlDocument{
+		Xmlns: graphmlNamespace,
+		Keys:  graphmlKeys,
+		Graph: graph,
+	}); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
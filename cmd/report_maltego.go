@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+
+	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// maltegoHeaders are the columns written to a Maltego link table
+var maltegoHeaders = []string{"SourceType", "SourceValue", "Link", "TargetType", "TargetValue"}
+
+var maltegoCmdFlags = struct {
+	DbURI      string
+	JsonFile   string
+	OutputFile string
+}{}
+var maltegoCmd = &cobra.Command{
+	Use:   "maltego",
+	Short: "Export results as a Maltego link table",
+	Long: ascii.LogoHelp(ascii.Markdown(`
+# report maltego
+
+Export results as a Maltego link table.
+
+Each row in the resulting CSV file describes a single relationship between two
+Maltego entities. URLs are linked to their host, hosts are linked to the IP
+address that served them, and URLs are linked to detected technologies and the
+TLS certificate subject that was presented.
+
+Import the file in Maltego using _Import Graph from Table_, mapping the
+SourceValue and TargetValue columns to the entity types in the SourceType and
+TargetType columns.`)),
+	Example: ascii.Markdown(`
+- gowitness report maltego --db-uri sqlite://gowitness.sqlite3 --output maltego.csv
+- gowitness report maltego --json-file gowitness.jsonl`),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if maltegoCmdFlags.DbURI == "" && maltegoCmdFlags.JsonFile == "" {
+			return errors.New("no data source defined")
+		}
+		if maltegoCmdFlags.OutputFile == "" {
+			return errors.New("an output file must be specified")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		results, err := readResults(maltegoCmdFlags.DbURI, maltegoCmdFlags.JsonFile)
+		if err != nil {
+			log.Error("could not read results", "err", err)
+			return
+		}
+
+		rows := maltegoRows(results)
+		if err := writeMaltegoTable(maltegoCmdFlags.OutputFile, rows); err != nil {
+			log.Error("could not write maltego table", "err", err)
+			return
+		}
+
+		log.Info("exported maltego link table", "results", len(results), "links", len(rows),
+			"output", maltegoCmdFlags.OutputFile)
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(maltegoCmd)
+
+	maltegoCmd.Flags().StringVar(&maltegoCmdFlags.DbURI, "db-uri", "sqlite://gowitness.sqlite3", "The location of a gowitness database")
+	maltegoCmd.Flags().StringVar(&maltegoCmdFlags.JsonFile, "json-file", "", "The location of a JSON Lines results file (e.g., ./gowitness.jsonl). This flag takes precedence over --db-uri")
+	maltegoCmd.Flags().StringVar(&maltegoCmdFlags.OutputFile, "output", "gowitness-maltego.csv", "The file to write the Maltego link table to")
+}
+
+// maltegoRows builds unique entity relationships from results
+func maltegoRows(results []*models.Result) [][]string {
+	var rows [][]string
+	seen := make(map[string]bool)
+
+	add := func(sourceType, sourceValue, link, targetType, targetValue string) {
+		if sourceValue == "" || targetValue == "" {
+			return
+		}
+
+		row := []string{sourceType, sourceValue, link, targetType, targetValue}
+		key := sourceValue + "\x00" + link + "\x00" + targetValue
+		if seen[key] {
+			return
+		}
+
+		seen[key] = true
+		rows = append(rows, row)
+	}
+
+	for _, result := range results {
+		target := result.URL
+		if result.FinalURL != "" {
+			target = result.FinalURL
+		}
+
+		u, err := url.Parse(target)
+		if err != nil {
+			continue
+		}
+		host := u.Hostname()
+
+		add("maltego.URL", target, "hosted on", hostEntityType(host), host)
+
+		// the ip address that served the final url
+		for _, entry := range result.Network {
+			if entry.URL == target && entry.RemoteIP != "" {
+				ip := entry.RemoteIP
+				add(hostEntityType(host), host, "resolves to", hostEntityType(ip), ip)
+				break
+			}
+		}
+
+		for _, tech := range result.Technologies {
+			add("maltego.URL", target, "runs", "maltego.Phrase", tech.Value)
+		}
+
+		if result.TLS.SubjectName != "" {
+			add("maltego.URL", target, "presents", "maltego.X509Certificate", result.TLS.SubjectName)
+		}
+	}
+
+	return rows
+}
+
+// hostEntityType returns the Maltego entity type for a host value
+func hostEntityType(host string) string {
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return "maltego.DNSName"
+	case ip.To4() != nil:
+		return "maltego.IPv4Address"
+	default:
+		return "maltego.IPv6Address"
+	}
+}
+
+// writeMaltegoTable writes rows to a CSV file
+func writeMaltegoTable(destination string, rows [][]string) error {
+	p, err := islazy.CreateFileWithDir(destination)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(p, os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(maltegoHeaders); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
@@ -213,8 +213,18 @@ func createOutputDatabase(path string) (*gorm.DB, error) {
 		&models.Technology{},
 		&models.Header{},
 		&models.NetworkLog{},
+		&models.NetworkHeader{},
 		&models.ConsoleLog{},
 		&models.Cookie{},
+		&models.ScrollCapture{},
+		&models.BurstCapture{},
+		&models.AuthChallenge{},
+		&models.Redirect{},
+		&models.MixedContentURL{},
+		&models.ExternalResource{},
+		&models.WebSocket{},
+		&models.WebSocketFrame{},
+		&models.ResourceTiming{},
 	); err != nil {
 		return nil, err
 	}
@@ -225,7 +235,7 @@ func createOutputDatabase(path string) (*gorm.DB, error) {
 func copyData(source *gorm.DB, dest *gorm.DB) error {
 	batchSize := 10
 	var results []models.Result
-	if err := source.Model(&models.Result{}).Preload(clause.Associations).Preload("TLS.SanList").
+	if err := source.Model(&models.Result{}).Preload(clause.Associations).Preload("TLS.SanList").Preload("Network.Headers").Preload("WebSockets.Frames").
 		FindInBatches(&results, batchSize, func(tx *gorm.DB, batch int) error {
 			// Begin a transaction in the destination database
 			return dest.Transaction(func(destTx *gorm.DB) error {
@@ -245,6 +255,22 @@ func copyData(source *gorm.DB, dest *gorm.DB) error {
 					result.Technologies = nil
 					tlsData := result.TLS
 					result.TLS = models.TLS{}
+					scrollCaptures := result.ScrollCaptures
+					result.ScrollCaptures = nil
+					burstCaptures := result.BurstCaptures
+					result.BurstCaptures = nil
+					authChallenges := result.AuthChallenges
+					result.AuthChallenges = nil
+					redirects := result.Redirects
+					result.Redirects = nil
+					mixedContentURLs := result.MixedContentURLs
+					result.MixedContentURLs = nil
+					externalResources := result.ExternalResources
+					result.ExternalResources = nil
+					webSockets := result.WebSockets
+					result.WebSockets = nil
+					resourceTimings := result.ResourceTimings
+					result.ResourceTimings = nil
 
 					// Insert Result
 					if err := destTx.Create(&result).Error; err != nil {
@@ -291,6 +317,10 @@ func copyData(source *gorm.DB, dest *gorm.DB) error {
 					for i := range networkLogs {
 						networkLogs[i].ID = 0
 						networkLogs[i].ResultID = newResultID
+						for j := range networkLogs[i].Headers {
+							networkLogs[i].Headers[j].ID = 0
+							networkLogs[i].Headers[j].NetworkLogID = 0
+						}
 					}
 					if len(networkLogs) > 0 {
 						if err := destTx.Create(&networkLogs).Error; err != nil {
@@ -330,6 +360,98 @@ func copyData(source *gorm.DB, dest *gorm.DB) error {
 							return fmt.Errorf("failed to insert Technologies: %w", err)
 						}
 					}
+
+					// Insert Scroll Captures
+					for i := range scrollCaptures {
+						scrollCaptures[i].ID = 0
+						scrollCaptures[i].ResultID = newResultID
+					}
+					if len(scrollCaptures) > 0 {
+						if err := destTx.Create(&scrollCaptures).Error; err != nil {
+							return fmt.Errorf("failed to insert Scroll Captures: %w", err)
+						}
+					}
+
+					// Insert Burst Captures
+					for i := range burstCaptures {
+						burstCaptures[i].ID = 0
+						burstCaptures[i].ResultID = newResultID
+					}
+					if len(burstCaptures) > 0 {
+						if err := destTx.Create(&burstCaptures).Error; err != nil {
+							return fmt.Errorf("failed to insert Burst Captures: %w", err)
+						}
+					}
+
+					// Insert Auth Challenges
+					for i := range authChallenges {
+						authChallenges[i].ID = 0
+						authChallenges[i].ResultID = newResultID
+					}
+					if len(authChallenges) > 0 {
+						if err := destTx.Create(&authChallenges).Error; err != nil {
+							return fmt.Errorf("failed to insert Auth Challenges: %w", err)
+						}
+					}
+
+					// Insert Redirects
+					for i := range redirects {
+						redirects[i].ID = 0
+						redirects[i].ResultID = newResultID
+					}
+					if len(redirects) > 0 {
+						if err := destTx.Create(&redirects).Error; err != nil {
+							return fmt.Errorf("failed to insert Redirects: %w", err)
+						}
+					}
+
+					// Insert Mixed Content URLs
+					for i := range mixedContentURLs {
+						mixedContentURLs[i].ID = 0
+						mixedContentURLs[i].ResultID = newResultID
+					}
+					if len(mixedContentURLs) > 0 {
+						if err := destTx.Create(&mixedContentURLs).Error; err != nil {
+							return fmt.Errorf("failed to insert Mixed Content URLs: %w", err)
+						}
+					}
+
+					// Insert External Resources
+					for i := range externalResources {
+						externalResources[i].ID = 0
+						externalResources[i].ResultID = newResultID
+					}
+					if len(externalResources) > 0 {
+						if err := destTx.Create(&externalResources).Error; err != nil {
+							return fmt.Errorf("failed to insert External Resources: %w", err)
+						}
+					}
+
+					// Insert WebSockets
+					for i := range webSockets {
+						webSockets[i].ID = 0
+						webSockets[i].ResultID = newResultID
+						for j := range webSockets[i].Frames {
+							webSockets[i].Frames[j].ID = 0
+							webSockets[i].Frames[j].WebSocketID = 0
+						}
+					}
+					if len(webSockets) > 0 {
+						if err := destTx.Create(&webSockets).Error; err != nil {
+							return fmt.Errorf("failed to insert WebSockets: %w", err)
+						}
+					}
+
+					// Insert Resource Timings
+					for i := range resourceTimings {
+						resourceTimings[i].ID = 0
+						resourceTimings[i].ResultID = newResultID
+					}
+					if len(resourceTimings) > 0 {
+						if err := destTx.Create(&resourceTimings).Error; err != nil {
+							return fmt.Errorf("failed to insert Resource Timings: %w", err)
+						}
+					}
 				}
 				return nil
 			})
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+func TestCopyData(t *testing.T) {
+	dir := t.TempDir()
+
+	dest, err := createOutputDatabase(filepath.Join(dir, "merged.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// both sources number their rows from 1, so every child id collides
+	urls := []string{"https://one.example", "https://two.example"}
+	for i, url := range urls {
+		source, err := createOutputDatabase(filepath.Join(dir, filepath.Base(url)+".sqlite3"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result := &models.Result{
+			URL:               url,
+			AuthChallenges:    []models.AuthChallenge{{Scheme: "Basic"}},
+			Redirects:         []models.Redirect{{URL: url, Location: url + "/login", ResponseCode: 302}},
+			MixedContentURLs:  []models.MixedContentURL{{URL: "http://insecure.example/app.js"}},
+			ExternalResources: []models.ExternalResource{{Type: "script", URL: "https://cdn.example/app.js", Host: "cdn.example"}},
+			WebSockets: []models.WebSocket{{
+				URL:    "wss://one.example/socket",
+				Frames: []models.WebSocketFrame{{Direction: "sent"}, {Direction: "received"}},
+			}},
+			ResourceTimings: []models.ResourceTiming{{Name: url + "/app.js"}},
+		}
+		if err := source.Create(result).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		if err := copyData(source, dest); err != nil {
+			t.Fatalf("copyData() source %d => %v", i, err)
+		}
+	}
+
+	var results []models.Result
+	if err := dest.Preload("AuthChallenges").Preload("Redirects").Preload("MixedContentURLs").
+		Preload("ExternalResources").Preload("WebSockets.Frames").Preload("ResourceTimings").
+		Order("id").Find(&results).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != len(urls) {
+		t.Fatalf("copyData() =>\n\nhave: %d results\nwant %d", len(results), len(urls))
+	}
+
+	tests := []struct {
+		name  string
+		count func(models.Result) int
+		want  int
+	}{
+		{name: "Test auth challenges", count: func(r models.Result) int { return len(r.AuthChallenges) }, want: 1},
+		{name: "Test redirects", count: func(r models.Result) int { return len(r.Redirects) }, want: 1},
+		{name: "Test mixed content urls", count: func(r models.Result) int { return len(r.MixedContentURLs) }, want: 1},
+		{name: "Test external resources", count: func(r models.Result) int { return len(r.ExternalResources) }, want: 1},
+		{name: "Test websockets", count: func(r models.Result) int { return len(r.WebSockets) }, want: 1},
+		{name: "Test websocket frames", count: func(r models.Result) int {
+			if len(r.WebSockets) == 0 {
+				return 0
+			}
+			return len(r.WebSockets[0].Frames)
+		}, want: 2},
+		{name: "Test resource timings", count: func(r models.Result) int { return len(r.ResourceTimings) }, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, result := range results {
+				if got := tt.count(result); got != tt.want {
+					t.Errorf("copyData() %s =>\n\nhave: %d\nwant %d", result.URL, got, tt.want)
+				}
+			}
+		})
+	}
+
+	if results[1].Redirects[0].Location != urls[1]+"/login" {
+		t.Errorf("copyData() redirect =>\n\nhave: %s\nwant %s", results[1].Redirects[0].Location, urls[1]+"/login")
+	}
+}
@@ -59,7 +59,7 @@ and as a result will remain empty.`)),
 			strings.TrimSuffix(migrateCmdFlags.Source, filepath.Ext(migrateCmdFlags.Source)))
 		log.Info("writing to new SQLite database file", "target", targetFile)
 
-		writer, err := writers.NewDbWriter(fmt.Sprintf("sqlite://%s", targetFile), false)
+		writer, err := writers.NewDbWriter(fmt.Sprintf("sqlite://%s", targetFile), false, "", 1)
 		if err != nil {
 			log.Error("could not open new database", "err", err)
 			return
@@ -82,6 +82,10 @@ and as a result will remain empty.`)),
 				log.Error("could not write v2 URL result to v3 database", "err", err)
 			}
 		}
+		if err := writer.Close(); err != nil {
+			log.Error("could not write v2 URL results to v3 database", "err", err)
+			return
+		}
 
 		log.Info("database migrated")
 	},
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/x/term"
+	wappalyzer "github.com/projectdiscovery/wappalyzergo"
+	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/database"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// technologyInventory is the technologies seen across all results
+type technologyInventory struct {
+	Technologies []*technologyEntry `json:"technologies"`
+	Categories   []*categoryEntry   `json:"categories"`
+}
+
+// technologyEntry is a detected technology (and version, if known) with the
+// hosts it was seen on
+type technologyEntry struct {
+	Technology string   `json:"technology"`
+	Name       string   `json:"name"`
+	Version    string   `json:"version,omitempty"`
+	Categories []string `json:"categories"`
+	Count      int      `json:"count"`
+	Hosts      []string `json:"hosts"`
+}
+
+// categoryEntry is a technology category with the technologies and hosts
+// seen for it
+type categoryEntry struct {
+	Category     string   `json:"category"`
+	Count        int      `json:"count"`
+	Technologies []string `json:"technologies"`
+	Hosts        []string `json:"hosts"`
+}
+
+// technologyRow is a technology joined with the url of its result
+type technologyRow struct {
+	Value string
+	URL   string
+}
+
+var technologiesCmdFlags = struct {
+	DbURI      string
+	Technology string
+	Category   string
+	Json       bool
+}{}
+var technologiesCmd = &cobra.Command{
+	Use:   "technologies",
+	Short: "Build an inventory of detected technologies",
+	Long: ascii.LogoHelp(ascii.Markdown(`
+# report technologies
+
+Build an inventory of detected technologies.
+
+Technologies detected while scanning are aggregated across all results in a
+database, with a count and the list of hosts for every technology (and
+version), as well as for every technology category.
+
+Use --technology or --category to only include matching entries, for example
+to find every host running a specific CMS version.`)),
+	Example: ascii.Markdown(`
+- gowitness report technologies
+- gowitness report technologies --technology wordpress:6.4
+- gowitness report technologies --category CMS --json`),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if technologiesCmdFlags.DbURI == "" {
+			return errors.New("a database uri must be specified")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, err := database.Connection(technologiesCmdFlags.DbURI, true, false)
+		if err != nil {
+			log.Error("could not connect to database", "err", err)
+			return
+		}
+
+		var rows []*technologyRow
+		if err := conn.Table("technologies").
+			Select("technologies.value, results.url").
+			Joins("JOIN results ON results.id = technologies.result_id").
+			Order("technologies.value").Scan(&rows).Error; err != nil {
+			log.Error("could not read technologies", "err", err)
+			return
+		}
+
+		wap, err := wappalyzer.New()
+		if err != nil {
+			log.Error("could not load technology fingerprints", "err", err)
+			return
+		}
+
+		inventory := buildTechnologyInventory(rows, technologyCategories(wap),
+			technologiesCmdFlags.Technology, technologiesCmdFlags.Category)
+
+		if technologiesCmdFlags.Json {
+			j, err := json.MarshalIndent(inventory, "", "  ")
+			if err != nil {
+				log.Error("could not marshal technology inventory", "err", err)
+				return
+			}
+
+			fmt.Println(string(j))
+			return
+		}
+
+		renderTechnologyInventory(inventory)
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(technologiesCmd)
+
+	technologiesCmd.Flags().StringVar(&technologiesCmdFlags.DbURI, "db-uri", "sqlite://gowitness.sqlite3", "The location of a gowitness database")
+	technologiesCmd.Flags().StringVar(&technologiesCmdFlags.Technology, "technology", "", "Only include technologies containing this value (case-insensitive, e.g., wordpress or wordpress:6.4)")
+	technologiesCmd.Flags().StringVar(&technologiesCmdFlags.Category, "category", "", "Only include technologies in this category (case-insensitive, e.g., CMS)")
+	technologiesCmd.Flags().BoolVar(&technologiesCmdFlags.Json, "json", false, "Output the inventory as JSON")
+}
+
+// technologyCategories returns a lookup of technology name to category names
+func technologyCategories(wap *wappalyzer.Wappalyze) func(name string) []string {
+	apps := wap.GetCompiledFingerprints().Apps
+
+	return func(name string) []string {
+		fingerprint, ok := apps[name]
+		if !ok {
+			return nil
+		}
+
+		return wappalyzer.AppInfoFromFingerprint(fingerprint).Categories
+	}
+}
+
+// buildTechnologyInventory aggregates technology rows by technology and by
+// category. Entries with the most hosts are sorted first.
+func buildTechnologyInventory(rows []*technologyRow, categories func(string) []string, technologyFilter, categoryFilter string) *technologyInventory {
+	technologies := make(map[string]*technologyEntry)
+	categoryMap := make(map[string]*categoryEntry)
+
+	for _, row := range rows {
+		if technologyFilter != "" && !strings.Contains(strings.ToLower(row.Value), strings.ToLower(technologyFilter)) {
+			continue
+		}
+
+		name, version, _ := strings.Cut(row.Value, ":")
+		cats := categories(name)
+		if len(cats) == 0 {
+			cats = []string{"Uncategorized"}
+		}
+
+		if categoryFilter != "" && !islazy.SliceHasStr(lowerStrings(cats), strings.ToLower(categoryFilter)) {
+			continue
+		}
+
+		host := row.URL
+		if u, err := url.Parse(row.URL); err == nil && u.Host != "" {
+			host = u.Host
+		}
+
+		technology, ok := technologies[row.Value]
+		if !ok {
+			technology = &technologyEntry{
+				Technology: row.Value,
+				Name:       name,
+				Version:    version,
+				Categories: cats,
+			}
+			technologies[row.Value] = technology
+		}
+		technology.Hosts = append(technology.Hosts, host)
+
+		for _, cat := range cats {
+			if categoryFilter != "" && !strings.EqualFold(cat, categoryFilter) {
+				continue
+			}
+
+			category, ok := categoryMap[cat]
+			if !ok {
+				category = &categoryEntry{Category: cat}
+				categoryMap[cat] = category
+			}
+			category.Technologies = append(category.Technologies, name)
+			category.Hosts = append(category.Hosts, host)
+		}
+	}
+
+	inventory := &technologyInventory{}
+	for _, technology := range technologies {
+		technology.Hosts = islazy.UniqueStringSlice(technology.Hosts)
+		sort.Strings(technology.Hosts)
+		technology.Count = len(technology.Hosts)
+		inventory.Technologies = append(inventory.Technologies, technology)
+	}
+	for _, category := range categoryMap {
+		category.Technologies = islazy.UniqueStringSlice(category.Technologies)
+		sort.Strings(category.Technologies)
+		category.Hosts = islazy.UniqueStringSlice(category.Hosts)
+		sort.Strings(category.Hosts)
+		category.Count = len(category.Hosts)
+		inventory.Categories = append(inventory.Categories, category)
+	}
+
+	sort.Slice(inventory.Technologies, func(i, j int) bool {
+		a, b := inventory.Technologies[i], inventory.Technologies[j]
+		if a.Count == b.Count {
+			return a.Technology < b.Technology
+		}
+		return a.Count > b.Count
+	})
+	sort.Slice(inventory.Categories, func(i, j int) bool {
+		a, b := inventory.Categories[i], inventory.Categories[j]
+		if a.Count == b.Count {
+			return a.Category < b.Category
+		}
+		return a.Count > b.Count
+	})
+
+	return inventory
+}
+
+// lowerStrings returns a lower cased copy of a string slice
+func lowerStrings(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+
+	return lowered
+}
+
+func renderTechnologyInventory(inventory *technologyInventory) {
+	PaddedStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+	HeaderStyle := PaddedStyle.Bold(true).Underline(true)
+	RowStyle := PaddedStyle
+
+	styleFunc := func(row, col int) lipgloss.Style {
+		switch {
+		case row == 0:
+			return HeaderStyle
+		default:
+			return RowStyle
+		}
+	}
+
+	categories := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99"))).
+		Headers("Category", "Hosts", "Technologies").
+		StyleFunc(styleFunc)
+
+	for _, category := range inventory.Categories {
+		categories.Row(
+			category.Category,
+			fmt.Sprintf("%d", category.Count),
+			strings.Join(category.Technologies, ", "),
+		)
+	}
+
+	technologies := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99"))).
+		Headers("Technology", "Categories", "Count", "Hosts").
+		StyleFunc(styleFunc)
+
+	for _, technology := range inventory.Technologies {
+		technologies.Row(
+			technology.Technology,
+			strings.Join(technology.Categories, ", "),
+			fmt.Sprintf("%d", technology.Count),
+			strings.Join(technology.Hosts, "\n"),
+		)
+	}
+
+	w, _, _ := term.GetSize(os.Stdout.Fd())
+	fmt.Println(lipgloss.NewStyle().MaxWidth(w).Render(categories.String()))
+	fmt.Println(lipgloss.NewStyle().MaxWidth(w).Render(technologies.String()))
+}
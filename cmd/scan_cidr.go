@@ -78,4 +78,6 @@ func init() {
 	cidrCmd.Flags().BoolVar(&cidrCmdOptions.PortsMedium, "ports-medium", false, "Include a medium ports list when scanning targets")
 	cidrCmd.Flags().BoolVar(&cidrCmdOptions.PortsLarge, "ports-large", false, "Include a large ports list when scanning targets")
 	cidrCmd.Flags().BoolVar(&cidrCmdOptions.Random, "random", false, "Randomize scan targets")
+	cidrCmd.Flags().BoolVar(&cidrCmdOptions.InferScheme, "infer-scheme", false, "Probe well known ports only with their usual scheme (e.g., 80 and 8080 with http, 443 and 8443 with https). Other ports are probed with both")
+	cidrCmd.Flags().IntVar(&cidrCmdOptions.MaxHosts, "max-cidr-hosts", readers.DefaultMaxCidrHosts, "The maximum number of hosts the CIDR ranges may expand to, to guard against accidentally scanning huge ranges. Use 0 for no limit")
 }
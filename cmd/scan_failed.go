@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/readers"
+	"github.com/spf13/cobra"
+)
+
+var failedCmdOptions = &readers.FailedReaderOptions{}
+var failedCmd = &cobra.Command{
+	Use:   "failed",
+	Short: "Rescan the failed targets of a previous scan",
+	Long: ascii.LogoHelp(ascii.Markdown(`
+# scan failed
+
+Rescan the failed targets of a previous scan.
+
+Targets are read from the results of a previous scan, either from a database
+or a JSON Lines file. Results that were marked as failed or have no response
+code (e.g., timeouts or connection errors) are scanned again, which catches
+transient failures without rescanning everything. Targets that already have a
+successful result, for example from an earlier rescan, are skipped.
+
+Request bodies are not stored with results, so targets that were scanned with
+a method other than GET are replayed without their body.
+
+**Note**: To update the previous results, write the rescan to the same
+database or file using the _--write-*_ set of flags. Combine with
+_--write-db-upsert-key url_ to replace the failed results instead of adding
+new ones.`)),
+	Example: ascii.Markdown(`
+- gowitness scan failed --db-uri sqlite://gowitness.sqlite3 --write-db
+- gowitness scan failed --json-file gowitness.jsonl --write-jsonl --timeout 120
+- gowitness scan failed --write-db --write-db-upsert-key url --threads 2`),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if failedCmdOptions.DbURI == "" && failedCmdOptions.JsonFile == "" {
+			return errors.New("no data source defined")
+		}
+
+		if failedCmdOptions.JsonFile != "" && !islazy.FileExists(failedCmdOptions.JsonFile) {
+			return errors.New("json file is not readable")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Debug("starting failed target rescanning", "db-uri", failedCmdOptions.DbURI, "json-file", failedCmdOptions.JsonFile)
+
+		reader := readers.NewFailedReader(failedCmdOptions)
+		go func() {
+			if err := reader.Read(scanRunner.Targets); err != nil {
+				log.Error("error in reader.Read", "err", err)
+				return
+			}
+		}()
+
+		scanRunner.Run()
+		scanRunner.Close()
+	},
+}
+
+func init() {
+	scanCmd.AddCommand(failedCmd)
+
+	failedCmd.Flags().StringVar(&failedCmdOptions.DbURI, "db-uri", "sqlite://gowitness.sqlite3", "The location of a gowitness database with the results of a previous scan")
+	failedCmd.Flags().StringVar(&failedCmdOptions.JsonFile, "json-file", "", "The location of a JSON Lines results file of a previous scan (e.g., ./gowitness.jsonl). This flag takes precedence over --db-uri")
+}
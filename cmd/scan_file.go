@@ -31,6 +31,20 @@ ignored.
 If any ports are added (via --port or one of the ports collections), then URL
 candidates will also be generated with the port section specified.
 
+Lines may also be IPv4 CIDR ranges (e.g. _10.0.0.0/24_), which are expanded to
+every host in the range. To guard against accidentally scanning huge ranges,
+expansion stops with an error beyond --max-cidr-hosts hosts.
+
+Lines may be prefixed with an HTTP method and followed by a request body to
+probe APIs with something other than a GET navigation. For example:
+_POST https://example.com/api {"key":"value"}_. The method used is recorded on
+the result.
+
+Lines may also be prefixed with a proxy that overrides --chrome-proxy for that
+target, e.g. _proxy=socks5://10.0.0.1:1080 https://example.com_. Only the
+chromedp driver, which starts a new browser for every target, supports this.
+Other drivers log a warning and use the default proxy.
+
 **Note**: By default, no metadata is saved except for screenshots that are
 stored in the configured --screenshot-path. For later parsing (i.e., using the
 gowitness reporting feature), you need to specify where to write results (db,
@@ -79,4 +93,6 @@ func init() {
 	fileCmd.Flags().BoolVar(&fileCmdOptions.PortsSmall, "ports-small", false, "Include a small ports list when scanning targets")
 	fileCmd.Flags().BoolVar(&fileCmdOptions.PortsMedium, "ports-medium", false, "Include a medium ports list when scanning targets")
 	fileCmd.Flags().BoolVar(&fileCmdOptions.PortsLarge, "ports-large", false, "Include a large ports list when scanning targets")
+	fileCmd.Flags().BoolVar(&fileCmdOptions.InferScheme, "infer-scheme", false, "Probe well known ports on targets without a scheme only with their usual scheme (e.g., 80 and 8080 with http, 443 and 8443 with https). Other ports are probed with both")
+	fileCmd.Flags().IntVar(&fileCmdOptions.MaxCidrHosts, "max-cidr-hosts", readers.DefaultMaxCidrHosts, "The maximum number of hosts CIDR ranges in the target file may expand to. Use 0 for no limit")
 }
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/sensepost/gowitness/internal/ascii"
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/readers"
+	"github.com/spf13/cobra"
+)
+
+var harCmdOptions = &readers.HarReaderOptions{}
+var harCmd = &cobra.Command{
+	Use:   "har",
+	Short: "Scan targets from a HAR file",
+	Long: ascii.LogoHelp(ascii.Markdown(`
+# scan har
+
+Scan targets from an HTTP Archive (HAR) file.
+
+HAR files can be exported from browser developer tools and many proxies. By
+default only top-level document requests are used as targets. Use --all-entries
+to scan every request in the archive instead.
+
+With --keep-method, non-GET requests are replayed using their original method
+and request body.
+
+**Note**: By default, no metadata is saved except for screenshots that are
+stored in the configured --screenshot-path. For later parsing (i.e., using the
+gowitness reporting feature), you need to specify where to write results (db,
+csv, jsonl) using the _--write-*_ set of flags. See _--help_ for available
+flags.`)),
+	Example: ascii.Markdown(`
+- gowitness scan har -f ~/Downloads/example.com.har
+- gowitness scan har -f proxy-export.har --all-entries --write-db`),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if harCmdOptions.Source == "" {
+			return errors.New("a source must be specified")
+		}
+
+		if !islazy.FileExists(harCmdOptions.Source) {
+			return errors.New("source is not readable")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Debug("starting HAR file scanning", "file", harCmdOptions.Source)
+
+		reader := readers.NewHarReader(harCmdOptions)
+		go func() {
+			if err := reader.Read(scanRunner.Targets); err != nil {
+				log.Error("error in reader.Read", "err", err)
+				return
+			}
+		}()
+
+		scanRunner.Run()
+		scanRunner.Close()
+	},
+}
+
+func init() {
+	scanCmd.AddCommand(harCmd)
+
+	harCmd.Flags().StringVarP(&harCmdOptions.Source, "file", "f", "", "A HAR file with targets to scan")
+	harCmd.Flags().BoolVar(&harCmdOptions.AllEntries, "all-entries", false, "Scan every request in the HAR file, not just top-level documents")
+	harCmd.Flags().BoolVar(&harCmdOptions.KeepMethod, "keep-method", false, "Replay requests with their original HTTP method and request body")
+}
@@ -2,6 +2,7 @@ package islazy
 
 import (
 	"encoding/binary"
+	"math"
 	"net"
 )
 
@@ -31,3 +32,18 @@ func IpsInCIDR(cidr string) ([]string, error) {
 
 	return ips, nil
 }
+
+// CIDRSize returns the number of addresses in a given CIDR block
+func CIDRSize(cidr string) (uint64, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0, err
+	}
+
+	ones, bits := ipnet.Mask.Size()
+	if bits-ones >= 64 {
+		return math.MaxUint64, nil
+	}
+
+	return 1 << uint(bits-ones), nil
+}
@@ -43,9 +43,30 @@ func UniqueIntSlice(slice []int) []int {
 	return result
 }
 
+// UniqueStringSlice returns a slice of unique strings
+func UniqueStringSlice(slice []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+
+	for _, str := range slice {
+		if !seen[str] {
+			seen[str] = true
+			result = append(result, str)
+		}
+	}
+
+	return result
+}
+
 // ShuffleStr shuffles a slice of strings
 func ShuffleStr(slice []string) {
-	source := rand.NewSource(time.Now().UnixNano())
+	ShuffleStrSeed(slice, time.Now().UnixNano())
+}
+
+// ShuffleStrSeed shuffles a slice of strings using a seed, so that the
+// same seed always produces the same order
+func ShuffleStrSeed(slice []string, seed int64) {
+	source := rand.NewSource(seed)
 	rng := rand.New(source)
 
 	// Fisher-Yates shuffle algorithm
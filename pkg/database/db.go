@@ -6,8 +6,11 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/glebarez/sqlite"
+	"github.com/sensepost/gowitness/internal/islazy"
 	"github.com/sensepost/gowitness/pkg/models"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -17,6 +20,49 @@ import (
 
 // Connection returns a Database connection based on a URI
 func Connection(uri string, shouldExist, debug bool) (*gorm.DB, error) {
+	c, err := open(uri, shouldExist, debug, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// run database migrations on the connection
+	if err := c.AutoMigrate(
+		&models.Result{},
+		&models.TLS{},
+		&models.TLSSanList{},
+		&models.Technology{},
+		&models.Header{},
+		&models.NetworkLog{},
+		&models.NetworkHeader{},
+		&models.ConsoleLog{},
+		&models.Cookie{},
+		&models.ScrollCapture{},
+		&models.BurstCapture{},
+		&models.AuthChallenge{},
+		&models.Redirect{},
+		&models.MixedContentURL{},
+		&models.ExternalResource{},
+		&models.WebSocket{},
+		&models.WebSocketFrame{},
+		&models.ResourceTiming{},
+		&models.UpsertKey{},
+	); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// ReadOnlyConnection returns a connection to a database that gowitness does
+// not manage, e.g. the asset inventory of another tool. No migrations are
+// run, and sqlite databases must exist and are opened read-only without
+// changing their journal mode.
+func ReadOnlyConnection(uri string, debug bool) (*gorm.DB, error) {
+	return open(uri, true, debug, true)
+}
+
+// open opens a database connection based on a URI
+func open(uri string, shouldExist, debug, readOnly bool) (*gorm.DB, error) {
 	var err error
 	var c *gorm.DB
 
@@ -34,6 +80,22 @@ func Connection(uri string, shouldExist, debug bool) (*gorm.DB, error) {
 
 	switch db.Scheme {
 	case "sqlite":
+		// pragmas are set in the dsn, so that they apply to every
+		// connection in the pool and not just the first one
+		pragmas, err := sqlitePragmas(db.Query())
+		if err != nil {
+			return nil, err
+		}
+
+		// in-memory databases, e.g. sqlite://name?mode=memory
+		if db.Query().Get("mode") == "memory" {
+			c, err = gorm.Open(sqlite.Open("file:"+db.Host+"?mode=memory&cache=shared&_pragma=foreign_keys(1)"), config)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+
 		if shouldExist {
 			dbpath := filepath.Join(db.Host, db.Path)
 			dbpath = filepath.Clean(dbpath)
@@ -45,11 +107,20 @@ func Connection(uri string, shouldExist, debug bool) (*gorm.DB, error) {
 			}
 		}
 
-		c, err = gorm.Open(sqlite.Open(db.Host+db.Path+"?cache=shared"), config)
+		// the journal mode persists in the database file, so read-only
+		// connections only wait for locks
+		if readOnly {
+			c, err = gorm.Open(sqlite.Open("file:"+db.Host+db.Path+"?mode=ro&"+sqliteBusyTimeout(pragmas).Encode()), config)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		c, err = gorm.Open(sqlite.Open(db.Host+db.Path+"?cache=shared&"+pragmas.Encode()), config)
 		if err != nil {
 			return nil, err
 		}
-		c.Exec("PRAGMA foreign_keys = ON")
 	case "postgres":
 		c, err = gorm.Open(postgres.Open(uri), config)
 		if err != nil {
@@ -64,19 +135,69 @@ func Connection(uri string, shouldExist, debug bool) (*gorm.DB, error) {
 		return nil, errors.New("invalid db uri scheme")
 	}
 
-	// run database migrations on the connection
-	if err := c.AutoMigrate(
-		&models.Result{},
-		&models.TLS{},
-		&models.TLSSanList{},
-		&models.Technology{},
-		&models.Header{},
-		&models.NetworkLog{},
-		&models.ConsoleLog{},
-		&models.Cookie{},
-	); err != nil {
-		return nil, err
+	return c, nil
+}
+
+// sqliteBusyTimeout returns only the busy_timeout pragma of sqlite pragmas
+func sqliteBusyTimeout(pragmas url.Values) url.Values {
+	for _, pragma := range pragmas["_pragma"] {
+		if strings.HasPrefix(pragma, "busy_timeout(") {
+			return url.Values{"_pragma": []string{pragma}}
+		}
 	}
 
-	return c, nil
+	return url.Values{}
+}
+
+// sqliteJournalModes and sqliteSynchronousModes are the values allowed for
+// the journal_mode and synchronous sqlite uri parameters
+var (
+	sqliteJournalModes     = []string{"delete", "truncate", "persist", "memory", "wal", "off"}
+	sqliteSynchronousModes = []string{"off", "normal", "full", "extra"}
+)
+
+// sqlitePragmas returns the dsn parameters setting the pragmas of a sqlite
+// database. The journal_mode, synchronous and busy_timeout (in
+// milliseconds) pragmas can be set with uri parameters, e.g.
+// sqlite://gowitness.sqlite3?journal_mode=delete&synchronous=full.
+//
+// The defaults are tuned for many concurrent writers: the WAL journal lets
+// readers (like the report server) work while results are written, normal
+// synchronous only syncs the WAL on checkpoints instead of every commit,
+// which is still safe against corruption in WAL mode, and the busy timeout
+// makes writers wait for a lock instead of failing with "database is
+// locked". WAL does not work on network file systems, use
+// journal_mode=delete there.
+func sqlitePragmas(query url.Values) (url.Values, error) {
+	journalMode := strings.ToLower(query.Get("journal_mode"))
+	if journalMode == "" {
+		journalMode = "wal"
+	}
+	if !islazy.SliceHasStr(sqliteJournalModes, journalMode) {
+		return nil, fmt.Errorf("invalid sqlite journal_mode %q, must be one of %v", journalMode, sqliteJournalModes)
+	}
+
+	synchronous := strings.ToLower(query.Get("synchronous"))
+	if synchronous == "" {
+		synchronous = "normal"
+	}
+	if !islazy.SliceHasStr(sqliteSynchronousModes, synchronous) {
+		return nil, fmt.Errorf("invalid sqlite synchronous %q, must be one of %v", synchronous, sqliteSynchronousModes)
+	}
+
+	busyTimeout := 5000
+	if value := query.Get("busy_timeout"); value != "" {
+		var err error
+		busyTimeout, err = strconv.Atoi(value)
+		if err != nil || busyTimeout < 0 {
+			return nil, fmt.Errorf("invalid sqlite busy_timeout %q, must be a number of milliseconds", value)
+		}
+	}
+
+	return url.Values{"_pragma": []string{
+		"foreign_keys(1)",
+		fmt.Sprintf("busy_timeout(%d)", busyTimeout),
+		"journal_mode(" + journalMode + ")",
+		"synchronous(" + synchronous + ")",
+	}}, nil
 }
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSqlitePragmas(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "Test with defaults",
+			query: "",
+			want:  []string{"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(wal)", "synchronous(normal)"},
+		},
+		{
+			name:  "Test with overrides",
+			query: "journal_mode=DELETE&synchronous=full&busy_timeout=0",
+			want:  []string{"foreign_keys(1)", "busy_timeout(0)", "journal_mode(delete)", "synchronous(full)"},
+		},
+		{
+			name:    "Test with invalid journal mode",
+			query:   "journal_mode=fast",
+			wantErr: true,
+		},
+		{
+			name:    "Test with invalid synchronous",
+			query:   "synchronous=sometimes",
+			wantErr: true,
+		},
+		{
+			name:    "Test with invalid busy timeout",
+			query:   "busy_timeout=-1",
+			wantErr: true,
+		},
+		{
+			name:    "Test with non-numeric busy timeout",
+			query:   "busy_timeout=5s",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := sqlitePragmas(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sqlitePragmas() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got["_pragma"], tt.want) {
+				t.Errorf("sqlitePragmas() =>\n\nhave: %v\nwant %v", got["_pragma"], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// commandTimeout is how long a command may run for a result before it
+// is killed
+const commandTimeout = 60 * time.Second
+
+// CommandHook runs a shell command for every result.
+//
+// The command is a text/template with the fields returned by resultVars
+// (e.g. {{.URL}} or {{.ResponseCode}}). Values are shell quoted, so they
+// can be used as arguments as is. The same fields are set as GOWITNESS_*
+// environment variables (e.g. GOWITNESS_RESPONSE_CODE), and the result is
+// written as JSON to the command's stdin.
+//
+// On Windows the command runs with cmd.exe delayed expansion enabled, and
+// the template fields expand to their environment variables (e.g.
+// "!GOWITNESS_URL!") rather than the values, see shellArgument. A literal
+// ! in the command must be escaped as ^!.
+type CommandHook struct {
+	command        *template.Template
+	screenshotPath string
+}
+
+// NewCommandHook returns a new command hook
+func NewCommandHook(command string, screenshotPath string) (*CommandHook, error) {
+	tmpl, err := template.New("command").Option("missingkey=error").Parse(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hook command template: %w", err)
+	}
+
+	return &CommandHook{
+		command:        tmpl,
+		screenshotPath: screenshotPath,
+	}, nil
+}
+
+// Process runs the command for a result
+func (h *CommandHook) Process(result *models.Result) error {
+	vars := resultVars(result, h.screenshotPath)
+
+	quoted := make(map[string]string, len(vars))
+	for name, value := range vars {
+		quoted[name] = shellArgument(name, value)
+	}
+
+	var command strings.Builder
+	if err := h.command.Execute(&command, quoted); err != nil {
+		return fmt.Errorf("could not render hook command: %w", err)
+	}
+
+	input, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, command.String())
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = os.Environ()
+	for name, value := range vars {
+		cmd.Env = append(cmd.Env, envName(name)+"="+value)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("hook command failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// resultVars returns the result fields available to hook commands
+func resultVars(result *models.Result, screenshotPath string) map[string]string {
+	screenshot := ""
+	if result.Filename != "" {
+		screenshot = filepath.Join(screenshotPath, result.Filename)
+	}
+
+	return map[string]string{
+		"URL":          result.URL,
+		"FinalURL":     result.FinalURL,
+		"ResponseCode": strconv.Itoa(result.ResponseCode),
+		"Title":        result.Title,
+		"Filename":     result.Filename,
+		"Screenshot":   screenshot,
+		"Failed":       strconv.FormatBool(result.Failed),
+		"ProbedAt":     result.ProbedAt.Format(time.RFC3339),
+	}
+}
+
+// envName returns the environment variable name of a result field, e.g.
+// GOWITNESS_FINAL_URL for FinalURL
+func envName(name string) string {
+	var b strings.Builder
+	b.WriteString("GOWITNESS_")
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(rune(name[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package hooks
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// KeywordAlertHook logs an alert when a result's title or HTML contains
+// one of the configured keywords.
+type KeywordAlertHook struct {
+	log      *slog.Logger
+	keywords []string
+}
+
+// NewKeywordAlertHook returns a new keyword alert hook. Keywords are
+// matched case-insensitively.
+func NewKeywordAlertHook(logger *slog.Logger, keywords []string) *KeywordAlertHook {
+	var lowered []string
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			lowered = append(lowered, strings.ToLower(keyword))
+		}
+	}
+
+	return &KeywordAlertHook{
+		log:      logger,
+		keywords: lowered,
+	}
+}
+
+// Process checks a result for keywords
+func (h *KeywordAlertHook) Process(result *models.Result) error {
+	title := strings.ToLower(result.Title)
+	html := strings.ToLower(result.HTML)
+
+	for _, keyword := range h.keywords {
+		if strings.Contains(title, keyword) || strings.Contains(html, keyword) {
+			h.log.Warn("keyword alert 🚨", "target", result.URL, "keyword", keyword, "title", result.Title)
+		}
+	}
+
+	return nil
+}
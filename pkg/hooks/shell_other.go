@@ -0,0 +1,20 @@
+//go:build !windows
+
+package hooks
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// shellCommand returns a command running command with sh
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// shellArgument returns the value of a result field quoted as a single
+// argument for sh
+func shellArgument(name string, value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package hooks
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand returns a command running command with cmd.exe, with
+// delayed expansion enabled for the arguments returned by shellArgument.
+// The command line is set as is, as cmd.exe does not follow the quoting
+// rules exec uses for other programs.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd.exe")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `cmd.exe /V:ON /S /C "` + command + `"`}
+
+	return cmd
+}
+
+// shellArgument returns the argument for a result field. cmd.exe expands
+// %variables% and acts on characters like & and " before it runs a
+// command, so no quoting makes a value safe to substitute. Instead the
+// argument refers to the field's environment variable with delayed
+// expansion, which happens after the command line was parsed.
+func shellArgument(name string, value string) string {
+	return `"!` + envName(name) + `!"`
+}
@@ -0,0 +1,50 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// webhookTimeout is how long to wait for a webhook to respond
+const webhookTimeout = 30 * time.Second
+
+// WebhookHook posts every result as JSON to a webhook URL
+type WebhookHook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookHook returns a new webhook hook
+func NewWebhookHook(url string) *WebhookHook {
+	return &WebhookHook{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Process posts a result to the webhook
+func (h *WebhookHook) Process(result *models.Result) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not post result to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+
+	return nil
+}
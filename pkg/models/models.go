@@ -12,30 +12,159 @@ const (
 	WS
 )
 
+// NetError is a category of browser network error, for the net::ERR_*
+// error text Chrome reports when the primary request fails
+type NetError string
+
+const (
+	NetErrorDNS               NetError = "dns"
+	NetErrorConnectionRefused NetError = "connection_refused"
+	NetErrorConnectionReset   NetError = "connection_reset"
+	NetErrorTimeout           NetError = "timeout"
+	NetErrorUnreachable       NetError = "unreachable"
+	NetErrorTLS               NetError = "tls"
+	NetErrorCertificate       NetError = "certificate"
+	NetErrorProxy             NetError = "proxy"
+	NetErrorBlocked           NetError = "blocked"
+	NetErrorAborted           NetError = "aborted"
+	NetErrorHTTP              NetError = "http"
+	NetErrorOther             NetError = "other"
+)
+
 // Result is a Gowitness result
 type Result struct {
 	ID uint `json:"id" gorm:"primarykey"`
 
 	URL                   string    `json:"url"`
+	Method                string    `json:"method"`
 	ProbedAt              time.Time `json:"probed_at"`
 	FinalURL              string    `json:"final_url"`
 	ResponseCode          int       `json:"response_code"`
 	ResponseReason        string    `json:"response_reason"`
 	Protocol              string    `json:"protocol"`
 	ContentLength         int64     `json:"content_length"`
+	RemoteAddr            string    `json:"remote_addr"`
 	HTML                  string    `json:"html" gorm:"index"`
 	Title                 string    `json:"title" gorm:"index"`
 	PerceptionHash        string    `json:"perception_hash" gorm:"index"`
 	PerceptionHashGroupId uint      `json:"perception_hash_group_id" gorm:"index"`
 	Screenshot            string    `json:"screenshot"`
 
+	// Number of times the URL was scanned and when it was first scanned,
+	// kept by the database writer when rescans update the existing result.
+	// ProbedAt is the last time it was scanned.
+	ScanCount     int       `json:"scan_count"`
+	FirstProbedAt time.Time `json:"first_probed_at"`
+
+	// SHA-256 hashes of the raw HTML and screenshot bytes, for exact
+	// duplicate detection and integrity verification
+	HTMLSHA256       string `json:"html_sha256" gorm:"index"`
+	ScreenshotSHA256 string `json:"screenshot_sha256" gorm:"index"`
+
 	// Name of the screenshot file
 	Filename string `json:"file_name"`
 	IsPDF    bool   `json:"is_pdf"`
+	// Name of the HAR file, if one was saved
+	HarFile string `json:"har_file"`
+
+	// Version of the browser that produced the capture
+	BrowserVersion string `json:"browser_version"`
+
+	// Referer sent with the main navigation request, if one was set
+	Referer string `json:"referer"`
+
+	// JSON encoded return value of the scan JavaScript function, if its
+	// return value was captured
+	JavaScriptReturn string `json:"javascript_return"`
+
+	// Primary language of the page (e.g. "en"), as declared by the page
+	// or detected from its text, if language capture is enabled
+	Language string `json:"language" gorm:"index"`
+
+	// Readable main text of the page, without navigation, ads and other
+	// boilerplate, if main text extraction is enabled
+	MainText string `json:"main_text"`
+
+	// Page complexity metrics, if enabled: the number of elements in the
+	// DOM and the bytes transferred over the network to load the page
+	DOMNodes         int64 `json:"dom_nodes"`
+	TransferredBytes int64 `json:"transferred_bytes"`
+
+	// Service worker registered by the page and its web app manifest, if
+	// PWA capture was enabled. Manifest is the raw manifest JSON.
+	ServiceWorkerRegistered bool   `json:"service_worker_registered" gorm:"index"`
+	ServiceWorkerScope      string `json:"service_worker_scope"`
+	ServiceWorkerScriptURL  string `json:"service_worker_script_url"`
+	ServiceWorkerStatus     string `json:"service_worker_status"`
+	ManifestURL             string `json:"manifest_url"`
+	Manifest                string `json:"manifest"`
+
+	// Set if a client certificate was requested by, and presented to, the target
+	ClientCertPresented bool `json:"client_cert_presented"`
+
+	// Challenge flags set if a JavaScript challenge page (e.g. Cloudflare)
+	// was detected, and if it was cleared before the screenshot
+	ChallengeDetected bool `json:"challenge_detected"`
+	ChallengePassed   bool `json:"challenge_passed"`
+
+	// IsDefaultPage flag set if the page matched a default or placeholder
+	// page signature (e.g. a web server test page or a parked domain)
+	IsDefaultPage bool   `json:"is_default_page" gorm:"index"`
+	DefaultPage   string `json:"default_page"`
+
+	// IsSPA flag set if the page is a single-page app: a client side
+	// framework (SPAFramework) rendered a DOM much larger than the HTML
+	// the server sent. These pages may need --wait-until networkidle or a
+	// selector wait to be captured fully.
+	IsSPA        bool   `json:"is_spa" gorm:"index"`
+	SPAFramework string `json:"spa_framework"`
+
+	// ConsentHandled flag set if a cookie consent banner was found and
+	// dismissed before the screenshot, by ConsentFramework
+	ConsentHandled   bool   `json:"consent_handled"`
+	ConsentFramework string `json:"consent_framework"`
+
+	// SchemeSwitched flag set if the target failed to connect and this
+	// result is from a retry with the other scheme (http or https)
+	SchemeSwitched bool `json:"scheme_switched"`
 
 	// Failed flag set if the result should be considered failed
 	Failed       bool   `json:"failed"`
 	FailedReason string `json:"failed_reason"`
+	// NetError is the category of FailedReason if the primary request
+	// failed with a browser network error
+	NetError NetError `json:"net_error" gorm:"index"`
+
+	// AuthRequired flag set if the primary response asked for HTTP
+	// authentication with a WWW-Authenticate header, with the challenges
+	// listed in AuthChallenges
+	AuthRequired   bool            `json:"auth_required" gorm:"index"`
+	AuthChallenges []AuthChallenge `json:"auth_challenges" gorm:"constraint:OnDelete:CASCADE"`
+
+	// Redirects are the HTTP redirects followed by the main navigation
+	// request, in order
+	Redirects []Redirect `json:"redirects" gorm:"constraint:OnDelete:CASCADE"`
+	// RedirectCount is the number of Redirects. CrossOriginRedirect flag set
+	// if any of them redirected to another origin, e.g. to an SSO provider
+	RedirectCount       int  `json:"redirect_count"`
+	CrossOriginRedirect bool `json:"cross_origin_redirect" gorm:"index"`
+
+	// MixedContent flag set if an HTTPS page loaded insecure (http:// or
+	// ws://) subresources, listed in MixedContentURLs
+	MixedContent     bool              `json:"mixed_content" gorm:"index"`
+	MixedContentURLs []MixedContentURL `json:"mixed_content_urls" gorm:"constraint:OnDelete:CASCADE"`
+
+	// ExternalResources are the script and link sources referenced by the
+	// page, with third party hosts flagged
+	ExternalResources []ExternalResource `json:"external_resources" gorm:"constraint:OnDelete:CASCADE"`
+
+	// WebSockets are the WebSocket connections opened by the page, with
+	// their text frames if frame capture is enabled
+	WebSockets []WebSocket `json:"websockets" gorm:"constraint:OnDelete:CASCADE"`
+
+	// ResourceTimings are the page's window.performance resource timing
+	// entries, if resource timing capture is enabled
+	ResourceTimings []ResourceTiming `json:"resource_timings" gorm:"constraint:OnDelete:CASCADE"`
 
 	TLS          TLS          `json:"tls" gorm:"constraint:OnDelete:CASCADE"`
 	Technologies []Technology `json:"technologies" gorm:"constraint:OnDelete:CASCADE"`
@@ -44,6 +173,9 @@ type Result struct {
 	Network []NetworkLog `json:"network" gorm:"constraint:OnDelete:CASCADE"`
 	Console []ConsoleLog `json:"console" gorm:"constraint:OnDelete:CASCADE"`
 	Cookies []Cookie     `json:"cookies" gorm:"constraint:OnDelete:CASCADE"`
+
+	ScrollCaptures []ScrollCapture `json:"scroll_captures" gorm:"constraint:OnDelete:CASCADE"`
+	BurstCaptures  []BurstCapture  `json:"burst_captures" gorm:"constraint:OnDelete:CASCADE"`
 }
 
 func (r *Result) HeaderMap() map[string][]string {
@@ -70,6 +202,10 @@ type TLS struct {
 	ValidTo                  time.Time    `json:"valid_to"`
 	ServerSignatureAlgorithm int64        `json:"server_signature_algorithm"`
 	EncryptedClientHello     bool         `json:"encrypted_client_hello"`
+	// SHA-256 fingerprint of the leaf certificate
+	FingerprintSHA256 string `json:"fingerprint_sha256" gorm:"index"`
+	// PEM encoded certificate chain, leaf first
+	PEM string `json:"pem"`
 }
 
 type TLSSanList struct {
@@ -103,9 +239,45 @@ type NetworkLog struct {
 	URL         string      `json:"url"`
 	RemoteIP    string      `json:"remote_ip"`
 	MIMEType    string      `json:"mime_type"`
+	Size        int64       `json:"size"`
 	Time        time.Time   `json:"time"`
 	Content     []byte      `json:"content"`
 	Error       string      `json:"error"`
+
+	// ContentFile is the name of the file in the content path the body was
+	// written to, instead of Content, if content is stored on disk
+	ContentFile string `json:"content_file"`
+
+	// Protocol is the protocol used to fetch the resource (e.g., h2)
+	Protocol string `json:"protocol"`
+	// Pushed is true when the resource was pushed by the server (HTTP/2 push)
+	Pushed bool `json:"pushed"`
+	// Trailers are the trailer field names announced by the response's
+	// Trailer header. Chrome does not expose trailer values.
+	Trailers string `json:"trailers"`
+
+	// Durations of the request phases in milliseconds, from the browser's
+	// network timing. They are 0 if the phase did not happen (e.g. DNS and
+	// connect for a reused connection) or the timing is unknown.
+	DNSTime     float64 `json:"dns_time"`
+	ConnectTime float64 `json:"connect_time"`
+	TLSTime     float64 `json:"tls_time"`
+	SendTime    float64 `json:"send_time"`
+	WaitTime    float64 `json:"wait_time"`
+	ReceiveTime float64 `json:"receive_time"`
+
+	Headers []NetworkHeader `json:"headers" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// NetworkHeader is a request or response header of a network log entry
+type NetworkHeader struct {
+	ID           uint `json:"id" gorm:"primarykey"`
+	NetworkLogID uint `json:"network_log_id"`
+
+	// Type is either request or response
+	Type  string `json:"type"`
+	Key   string `json:"key"`
+	Value string `json:"value" gorm:"index"`
 }
 
 type ConsoleLog struct {
@@ -130,6 +302,145 @@ type Cookie struct {
 	Secure       bool      `json:"secure"`
 	Session      bool      `json:"session"`
 	Priority     string    `json:"priority"`
+	SameSite     string    `json:"same_site"`
 	SourceScheme string    `json:"source_scheme"`
 	SourcePort   int64     `json:"source_port"`
 }
+
+// ScrollCapture is a viewport screenshot taken at a scroll position
+type ScrollCapture struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	ResultID uint `json:"result_id"`
+
+	Position   string `json:"position"`
+	Offset     int64  `json:"offset"`
+	Filename   string `json:"file_name"`
+	Screenshot string `json:"screenshot"`
+}
+
+// BurstCapture is a viewport screenshot taken some time after the
+// initial screenshot
+type BurstCapture struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	ResultID uint `json:"result_id"`
+
+	Sequence int `json:"sequence"`
+	// Milliseconds since the first burst capture started
+	Elapsed    int64  `json:"elapsed"`
+	Filename   string `json:"file_name"`
+	Screenshot string `json:"screenshot"`
+}
+
+// MixedContentURL is an insecure subresource loaded by an HTTPS page
+type MixedContentURL struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	ResultID uint `json:"result_id"`
+
+	URL string `json:"url"`
+}
+
+// AuthChallenge is an HTTP authentication challenge of a WWW-Authenticate
+// response header
+type AuthChallenge struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	ResultID uint `json:"result_id"`
+
+	// Scheme is the authentication scheme, e.g. Basic, Bearer or Negotiate
+	Scheme string `json:"scheme" gorm:"index"`
+	Realm  string `json:"realm"`
+}
+
+// Redirect is an HTTP redirect followed by the main navigation request
+type Redirect struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	ResultID uint `json:"result_id"`
+
+	// URL is the URL that responded with the redirect, and Location the
+	// URL it redirected to
+	URL          string `json:"url"`
+	Location     string `json:"location"`
+	ResponseCode int    `json:"response_code"`
+}
+
+// ExternalResource is the source of a <script src> or <link href> element
+type ExternalResource struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	ResultID uint `json:"result_id"`
+
+	// Type is either script or link
+	Type string `json:"type"`
+	// Rel is the rel attribute of link elements (e.g., stylesheet)
+	Rel  string `json:"rel"`
+	URL  string `json:"url"`
+	Host string `json:"host" gorm:"index"`
+	// ThirdParty is set if the host is not part of the page's site
+	ThirdParty bool `json:"third_party" gorm:"index"`
+	// Integrity is the subresource integrity attribute, if any
+	Integrity string `json:"integrity"`
+}
+
+// WebSocket is a WebSocket connection opened by a page
+type WebSocket struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	ResultID uint `json:"result_id"`
+
+	URL string `json:"url"`
+	// Truncated is set if frames were dropped after the connection
+	// reached its capture byte limit
+	Truncated bool `json:"truncated"`
+
+	Frames []WebSocketFrame `json:"frames" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// WebSocketFrame is a text message sent or received on a WebSocket
+type WebSocketFrame struct {
+	ID          uint `json:"id" gorm:"primarykey"`
+	WebSocketID uint `json:"websocket_id"`
+
+	// Direction is either sent or received
+	Direction string    `json:"direction"`
+	Time      time.Time `json:"time"`
+	// Size is the size of the payload in bytes, before truncation
+	Size      int    `json:"size"`
+	Payload   string `json:"payload"`
+	Truncated bool   `json:"truncated"`
+}
+
+// ResourceTiming is a resource timing entry of a page, as reported by
+// performance.getEntriesByType("resource"). Times are in milliseconds, and
+// StartTime is relative to the start of the navigation.
+type ResourceTiming struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	ResultID uint `json:"result_id"`
+
+	Name            string  `json:"name"`
+	InitiatorType   string  `json:"initiator_type"`
+	NextHopProtocol string  `json:"next_hop_protocol"`
+	StartTime       float64 `json:"start_time"`
+	Duration        float64 `json:"duration"`
+
+	// Phases of the request. They are 0 if the phase did not happen (e.g.
+	// a reused connection) or the resource is cross-origin without a
+	// Timing-Allow-Origin header.
+	RedirectTime float64 `json:"redirect_time"`
+	DNSTime      float64 `json:"dns_time"`
+	ConnectTime  float64 `json:"connect_time"`
+	TLSTime      float64 `json:"tls_time"`
+	RequestTime  float64 `json:"request_time"`
+	ResponseTime float64 `json:"response_time"`
+
+	TransferSize    int64 `json:"transfer_size"`
+	EncodedBodySize int64 `json:"encoded_body_size"`
+	DecodedBodySize int64 `json:"decoded_body_size"`
+	ResponseStatus  int   `json:"response_status"`
+}
+
+// UpsertKey is a row per upserted key value that database writers lock
+// while they replace the result with that value. Its primary key makes
+// concurrent writers of a new value insert a single row, as there is no
+// existing result row to lock yet.
+type UpsertKey struct {
+	// Hash is the SHA-256 of the upsert column and value, as values such
+	// as urls are too long for a unique index on MySQL
+	Hash string `json:"hash" gorm:"primarykey;size:64"`
+}
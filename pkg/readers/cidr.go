@@ -3,6 +3,7 @@ package readers
 import (
 	"bufio"
 	"fmt"
+	"net"
 	"os"
 	"strings"
 
@@ -10,6 +11,10 @@ import (
 	"github.com/sensepost/gowitness/pkg/log"
 )
 
+// DefaultMaxCidrHosts is the default limit on the number of hosts CIDR ranges
+// may expand to. This guards against accidentally scanning huge ranges.
+const DefaultMaxCidrHosts = 65536
+
 type CidrReader struct {
 	Options *CidrReaderOptions
 }
@@ -24,6 +29,12 @@ type CidrReaderOptions struct {
 	PortsMedium bool
 	PortsLarge  bool
 	Random      bool
+	// InferScheme probes well known ports only with their usual scheme
+	// (e.g. 8443 with https).
+	InferScheme bool
+	// MaxHosts is the maximum number of hosts the CIDR ranges may expand
+	// to. 0 means no limit.
+	MaxHosts int
 }
 
 func NewCidrReader(opts *CidrReaderOptions) *CidrReader {
@@ -63,11 +74,11 @@ func (cr *CidrReader) candidates() ([]string, error) {
 		for _, port := range ports {
 			partial := fmt.Sprintf("%s:%d", ip, port)
 
-			if !cr.Options.NoHTTP {
+			if !cr.Options.NoHTTP && (!cr.Options.InferScheme || portAllowsScheme(port, "http")) {
 				candidates = append(candidates, fmt.Sprintf("http://%s", partial))
 			}
 
-			if !cr.Options.NoHTTPS {
+			if !cr.Options.NoHTTPS && (!cr.Options.InferScheme || portAllowsScheme(port, "https")) {
 				candidates = append(candidates, fmt.Sprintf("https://%s", partial))
 			}
 		}
@@ -101,7 +112,7 @@ func (cr *CidrReader) ports() []int {
 
 // ips gets ips from a file and cidr agruments
 func (cr *CidrReader) ips() ([]string, error) {
-	var cidrs = cr.Options.Cidrs
+	var cidrs = append([]string{}, cr.Options.Cidrs...)
 	var ips []string
 
 	// Slurp a file if we have one
@@ -120,16 +131,33 @@ func (cr *CidrReader) ips() ([]string, error) {
 
 		scanner := bufio.NewScanner(file)
 		for scanner.Scan() {
-			cidrs = append(cidrs, strings.TrimSpace(scanner.Text()))
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				cidrs = append(cidrs, line)
+			}
 		}
 	}
 
-	// populate ips from the collected cidrs to return
-	for _, cidr := range cidrs {
+	for i, cidr := range cidrs {
 		if !strings.Contains(cidr, "/") {
-			cidr += "/32"
+			cidrs[i] = cidr + "/32"
 		}
+	}
+
+	// refuse to expand huge ranges unless asked to
+	var hosts uint64
+	for _, cidr := range cidrs {
+		size, err := cidrSize(cidr)
+		if err != nil {
+			return nil, err
+		}
+		hosts += size
+	}
+	if err := checkCidrHosts(hosts, cr.Options.MaxHosts); err != nil {
+		return nil, err
+	}
 
+	// populate ips from the collected cidrs to return
+	for _, cidr := range cidrs {
 		ip, err := islazy.IpsInCIDR(cidr)
 		if err != nil {
 			return nil, err
@@ -140,3 +168,29 @@ func (cr *CidrReader) ips() ([]string, error) {
 
 	return ips, nil
 }
+
+// isCidr returns true if a candidate is an IPv4 CIDR range
+func isCidr(candidate string) bool {
+	ip, _, err := net.ParseCIDR(candidate)
+	return err == nil && ip.To4() != nil
+}
+
+// cidrSize returns the number of addresses in an IPv4 CIDR range
+func cidrSize(cidr string) (uint64, error) {
+	if !isCidr(cidr) {
+		return 0, fmt.Errorf("%s is not a valid IPv4 CIDR range", cidr)
+	}
+
+	return islazy.CIDRSize(cidr)
+}
+
+// checkCidrHosts returns an error if the number of hosts CIDR ranges expand to
+// is more than max. A max of 0 means no limit.
+func checkCidrHosts(hosts uint64, max int) error {
+	if max <= 0 || hosts <= uint64(max) {
+		return nil
+	}
+
+	return fmt.Errorf("cidr ranges expand to %d hosts, which is more than the maximum of %d. "+
+		"increase --max-cidr-hosts (or set it to 0) to scan them anyway", hosts, max)
+}
@@ -0,0 +1,149 @@
+package readers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/database"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/targetline"
+)
+
+// FailedReader is a reader for the failed targets of a previous scan
+type FailedReader struct {
+	Options *FailedReaderOptions
+}
+
+// FailedReaderOptions are options for the failed targets reader
+type FailedReaderOptions struct {
+	// DbURI is the database with results of a previous scan
+	DbURI string
+	// JsonFile is a JSON Lines results file of a previous scan. It takes
+	// precedence over DbURI
+	JsonFile string
+}
+
+// failedResult are the fields of a result needed to decide if it failed
+type failedResult struct {
+	URL          string `json:"url"`
+	Method       string `json:"method"`
+	Failed       bool   `json:"failed"`
+	ResponseCode int    `json:"response_code"`
+}
+
+// NewFailedReader returns a new failed targets reader
+func NewFailedReader(opts *FailedReaderOptions) *FailedReader {
+	return &FailedReader{
+		Options: opts,
+	}
+}
+
+// Read sends the targets of results that failed or have no response code.
+// Targets that also have a successful result (e.g. from a previous rescan)
+// are skipped. All results are read before the first target is sent, so
+// that the rescan can write to the same database or file.
+func (fr *FailedReader) Read(ch chan<- string) error {
+	defer close(ch)
+
+	var results []failedResult
+	var err error
+	if fr.Options.JsonFile != "" {
+		results, err = fr.readJsonl()
+	} else {
+		results, err = fr.readDb()
+	}
+	if err != nil {
+		return err
+	}
+
+	succeeded := make(map[string]bool)
+	for _, result := range results {
+		if !result.failed() {
+			succeeded[result.target()] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, result := range results {
+		target := result.target()
+		if !result.failed() || succeeded[target] || seen[target] {
+			continue
+		}
+
+		seen[target] = true
+		ch <- target
+	}
+
+	return nil
+}
+
+// readJsonl reads results from a JSON Lines file
+func (fr *FailedReader) readJsonl() ([]failedResult, error) {
+	file, err := os.Open(fr.Options.JsonFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var results []failedResult
+	decoder := json.NewDecoder(file)
+	for {
+		var result failedResult
+		if err := decoder.Decode(&result); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// readDb reads results from a database
+func (fr *FailedReader) readDb() ([]failedResult, error) {
+	conn, err := database.Connection(fr.Options.DbURI, true, false)
+	if err != nil {
+		return nil, err
+	}
+	if db, err := conn.DB(); err == nil {
+		defer db.Close()
+	}
+
+	var results []failedResult
+	if err := conn.Model(&models.Result{}).
+		Select("url", "method", "failed", "response_code").
+		Order("id").Find(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// failed returns true if a result failed or did not get a response
+func (r failedResult) failed() bool {
+	return r.Failed || r.ResponseCode == 0
+}
+
+// target returns the target line for a result. Request bodies are not
+// stored with results, so other methods are replayed without a body.
+func (r failedResult) target() string {
+	method := strings.ToUpper(r.Method)
+	if method == "" || method == http.MethodGet {
+		return r.URL
+	}
+
+	target := targetline.Parse(method + " " + r.URL)
+	if target.URL != r.URL {
+		// not a method we can replay
+		return r.URL
+	}
+
+	return target.String()
+}
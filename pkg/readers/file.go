@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/targetline"
 )
 
 // FileReader is a reader that expects a file with targets that
@@ -27,6 +28,12 @@ type FileReaderOptions struct {
 	PortsMedium bool
 	PortsLarge  bool
 	Random      bool
+	// InferScheme probes well known ports only with their usual scheme
+	// (e.g. 8443 with https) for targets without a scheme.
+	InferScheme bool
+	// MaxCidrHosts is the maximum number of hosts CIDR ranges in the
+	// source may expand to. 0 means no limit.
+	MaxCidrHosts int
 }
 
 // NewFileReader prepares a new file reader
@@ -57,6 +64,9 @@ func (fr *FileReader) Read(ch chan<- string) error {
 	// determine any ports
 	ports := fr.ports()
 
+	// hosts that CIDR ranges expanded to so far
+	var cidrHosts uint64
+
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		candidate := scanner.Text()
@@ -64,6 +74,17 @@ func (fr *FileReader) Read(ch chan<- string) error {
 			continue
 		}
 
+		if trimmed := strings.TrimSpace(candidate); isCidr(trimmed) {
+			size, err := cidrSize(trimmed)
+			if err != nil {
+				return err
+			}
+			cidrHosts += size
+			if err := checkCidrHosts(cidrHosts, fr.Options.MaxCidrHosts); err != nil {
+				return err
+			}
+		}
+
 		for _, url := range fr.urlsFor(candidate, ports) {
 			ch <- url
 		}
@@ -77,12 +98,42 @@ func (fr *FileReader) Read(ch chan<- string) error {
 // For candidates with no protocol, (and none of http/https is ignored), the
 // method will return two urls.
 // If any ports configuration exists, those will also be added as candidates.
+//
+// IPv4 CIDR candidates (e.g. 10.0.0.0/24) are expanded to every host in the
+// range first.
+//
+// Candidates may be prefixed with an HTTP method and suffixed with a request
+// body (e.g. POST https://host/api {"key":"value"}), or prefixed with a proxy
+// (e.g. proxy=socks5://127.0.0.1:1080 host), in which case the method, body
+// and proxy are kept on every generated URL.
 func (fr *FileReader) urlsFor(candidate string, ports []int) []string {
 	var urls []string
 
 	// trim any spaces
 	candidate = strings.TrimSpace(candidate)
 
+	if isCidr(candidate) {
+		ips, err := islazy.IpsInCIDR(candidate)
+		if err != nil {
+			return urls
+		}
+
+		for _, ip := range ips {
+			urls = append(urls, fr.urlsFor(ip, ports)...)
+		}
+
+		return urls
+	}
+
+	if request := targetline.Parse(candidate); !request.IsPlain() || request.Proxy != "" {
+		for _, u := range fr.urlsFor(request.URL, ports) {
+			request.URL = u
+			urls = append(urls, request.String())
+		}
+
+		return urls
+	}
+
 	// check if we got a scheme, add
 	hasScheme := strings.Contains(candidate, "://")
 	if !hasScheme {
@@ -152,6 +203,10 @@ func (fr *FileReader) urlsFor(candidate string, ports []int) []string {
 	// generate the urls
 	for _, scheme := range schemes {
 		for _, port := range targetPorts {
+			if !hasScheme && fr.Options.InferScheme && !portAllowsScheme(port, scheme) {
+				continue
+			}
+
 			host := hostname
 
 			if port != 0 {
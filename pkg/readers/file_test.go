@@ -29,6 +29,17 @@ func TestUrlsFor(t *testing.T) {
 				"https://192.168.1.1:8443",
 			},
 		},
+		{
+			name:      "Test with CIDR",
+			candidate: "192.168.1.0/31",
+			ports:     []int{80},
+			want: []string{
+				"http://192.168.1.0:80",
+				"https://192.168.1.0:80",
+				"http://192.168.1.1:80",
+				"https://192.168.1.1:80",
+			},
+		},
 		{
 			name:      "Test with IP and port",
 			candidate: "192.168.1.1:8080",
@@ -95,6 +106,32 @@ func TestUrlsFor(t *testing.T) {
 				"https://192.168.1.1:8080/path",
 			},
 		},
+		{
+			name:      "Test with method, scheme, IP, port, path and body",
+			candidate: `POST https://192.168.1.1:8443/api {"key": "value"}`,
+			ports:     []int{80, 443, 8443},
+			want: []string{
+				`POST https://192.168.1.1:8443/api {"key": "value"}`,
+			},
+		},
+		{
+			name:      "Test with method and IP",
+			candidate: "DELETE 192.168.1.1:8080/item",
+			ports:     []int{80, 443, 8443},
+			want: []string{
+				"DELETE http://192.168.1.1:8080/item",
+				"DELETE https://192.168.1.1:8080/item",
+			},
+		},
+		{
+			name:      "Test with proxy and IP",
+			candidate: "proxy=socks5://127.0.0.1:1080 192.168.1.1:8080",
+			ports:     []int{80, 443, 8443},
+			want: []string{
+				"proxy=socks5://127.0.0.1:1080 http://192.168.1.1:8080",
+				"proxy=socks5://127.0.0.1:1080 https://192.168.1.1:8080",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -106,3 +143,22 @@ func TestUrlsFor(t *testing.T) {
 		})
 	}
 }
+
+func TestUrlsForInferScheme(t *testing.T) {
+	fr := FileReader{
+		Options: &FileReaderOptions{InferScheme: true},
+	}
+
+	got := fr.urlsFor("example.com", []int{80, 443, 8443, 9000})
+	want := []string{
+		"http://example.com:80",
+		"http://example.com:9000",
+		"https://example.com:443",
+		"https://example.com:8443",
+		"https://example.com:9000",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("urlsFor() =>\n\nhave: %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package readers
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/targetline"
+)
+
+// HarReader is a reader for HTTP Archive (HAR) files
+type HarReader struct {
+	Options *HarReaderOptions
+}
+
+// HarReaderOptions are options for a HAR file reader
+type HarReaderOptions struct {
+	Source string
+	// AllEntries includes every request, not just top-level documents
+	AllEntries bool
+	// KeepMethod keeps non-GET methods and request bodies
+	KeepMethod bool
+}
+
+// structures for HAR parsing
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	ResourceType string `json:"_resourceType"`
+	Request      struct {
+		Method   string `json:"method"`
+		URL      string `json:"url"`
+		PostData *struct {
+			Text string `json:"text"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Content struct {
+			MimeType string `json:"mimeType"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// NewHarReader returns a new HAR file reader
+func NewHarReader(opts *HarReaderOptions) *HarReader {
+	return &HarReader{
+		Options: opts,
+	}
+}
+
+// Read extracts targets from a HAR file
+func (hr *HarReader) Read(ch chan<- string) error {
+	defer close(ch)
+
+	file, err := os.Open(hr.Options.Source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var har harFile
+	if err := json.NewDecoder(file).Decode(&har); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range har.Log.Entries {
+		if !hr.Options.AllEntries && !entry.isDocument() {
+			continue
+		}
+
+		target := hr.targetFor(entry)
+		if target == "" || seen[target] {
+			continue
+		}
+
+		seen[target] = true
+		ch <- target
+	}
+
+	return nil
+}
+
+// isDocument returns true if an entry is a top-level document request.
+// Chrome exports a resource type, other tools only have the MIME type.
+func (e harEntry) isDocument() bool {
+	if e.ResourceType != "" {
+		return e.ResourceType == "document"
+	}
+
+	return strings.HasPrefix(e.Response.Content.MimeType, "text/html")
+}
+
+// targetFor returns the target line for a HAR entry
+func (hr *HarReader) targetFor(entry harEntry) string {
+	u := entry.Request.URL
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		return ""
+	}
+
+	if !hr.Options.KeepMethod {
+		return u
+	}
+
+	target := targetline.Parse(strings.ToUpper(entry.Request.Method) + " " + u)
+	if target.URL != u {
+		// not a method we can replay
+		return u
+	}
+	if entry.Request.PostData != nil {
+		target.Body = entry.Request.PostData.Text
+	}
+
+	return target.String()
+}
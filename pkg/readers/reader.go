@@ -14,3 +14,28 @@ var (
 	medium = append(small, []int{81, 90, 591, 3000, 3128, 8000, 8008, 8081, 8082, 8834, 8888, 7015, 8800, 8990, 10000}...)
 	large  = append(medium, []int{300, 2082, 2087, 2095, 4243, 4993, 5000, 7000, 7171, 7396, 7474, 8090, 8280, 8880, 9443}...)
 )
+
+// well known ports that are usually served over plain http or over tls,
+// used to pick a scheme when schemes are inferred
+var (
+	httpPorts  = []int{80, 81, 90, 591, 2082, 2095, 3000, 3128, 5000, 7000, 8000, 8008, 8080, 8081, 8082, 8088, 8090, 8280, 8800, 8880, 8888}
+	httpsPorts = []int{443, 2083, 2087, 2096, 4443, 5001, 7443, 8443, 8834, 9443, 10000, 10443}
+)
+
+// portAllowsScheme returns true if a port should be probed with a scheme when
+// schemes are inferred. Ports that are not well known are probed with both.
+func portAllowsScheme(port int, scheme string) bool {
+	for _, p := range httpPorts {
+		if p == port {
+			return scheme == "http"
+		}
+	}
+
+	for _, p := range httpsPorts {
+		if p == port {
+			return scheme == "https"
+		}
+	}
+
+	return true
+}
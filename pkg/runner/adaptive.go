@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+const (
+	// adaptiveWindow 是每次评估之间需要完成的目标数量
+	adaptiveWindow = 10
+	// adaptiveBackoffRate 是触发退避的失败率
+	adaptiveBackoffRate = 0.5
+	// adaptiveRampUpRate 是允许增加工作线程的最大失败率
+	adaptiveRampUpRate = 0.1
+	// adaptiveFdPressureHigh 是触发退避的文件描述符使用率
+	adaptiveFdPressureHigh = 0.8
+	// adaptiveFdPressureLow 是允许增加工作线程的最大文件描述符使用率
+	adaptiveFdPressureLow = 0.6
+)
+
+// adaptiveController 根据失败率和系统资源压力动态调整活动工作线程的数量。
+//
+// 使用加性增、乘性减的策略：当失败激增（通常意味着资源耗尽）或
+// 文件描述符接近上限时，活动工作线程数量减半；当一切正常时，每个
+// 评估窗口增加一个。
+type adaptiveController struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+
+	min    int
+	max    int
+	limit  int
+	active int
+
+	// 当前评估窗口内的统计
+	successes int
+	failures  int
+
+	log *slog.Logger
+}
+
+// newAdaptiveController 返回一个从 minThreads 个工作线程开始的控制器
+func newAdaptiveController(logger *slog.Logger, minThreads, maxThreads int) *adaptiveController {
+	c := &adaptiveController{
+		min:   minThreads,
+		max:   maxThreads,
+		limit: minThreads,
+		log:   logger,
+	}
+	c.cond = sync.NewCond(&c.mutex)
+
+	return c
+}
+
+// acquire 阻塞直到有可用的工作槽位。如果上下文已取消，返回 false。
+func (c *adaptiveController) acquire(ctx context.Context) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for c.active >= c.limit {
+		if ctx.Err() != nil {
+			return false
+		}
+		c.cond.Wait()
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	c.active++
+	return true
+}
+
+// release 释放一个工作槽位并记录该目标的结果
+func (c *adaptiveController) release(failed bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.active--
+	if failed {
+		c.failures++
+	} else {
+		c.successes++
+	}
+
+	if c.successes+c.failures >= adaptiveWindow {
+		c.adjust()
+	}
+
+	c.cond.Broadcast()
+}
+
+// wake 唤醒所有等待槽位的工作线程，通常在上下文取消时调用
+func (c *adaptiveController) wake() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.cond.Broadcast()
+}
+
+// adjust 根据当前窗口的统计重新计算限制。调用者必须持有锁。
+func (c *adaptiveController) adjust() {
+	failureRate := float64(c.failures) / float64(c.successes+c.failures)
+	pressure := fdPressure()
+	previous := c.limit
+
+	switch {
+	case failureRate >= adaptiveBackoffRate || pressure >= adaptiveFdPressureHigh:
+		c.limit = max(c.min, c.limit/2)
+	case failureRate <= adaptiveRampUpRate && pressure < adaptiveFdPressureLow:
+		c.limit = min(c.max, c.limit+1)
+	}
+
+	if previous != c.limit {
+		c.log.Debug("adaptive thread limit changed", "from", previous, "to", c.limit,
+			"failure-rate", failureRate, "fd-pressure", pressure)
+	}
+
+	c.successes = 0
+	c.failures = 0
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// detectAuthChallenges 解析主响应的 WWW-Authenticate 头部，记录每个认证
+// 质询的方案和 realm，用于识别需要认证的端点，而无需截取浏览器认证对话框。
+func detectAuthChallenges(result *models.Result) {
+	for _, header := range result.Headers {
+		if !strings.EqualFold(header.Key, "WWW-Authenticate") {
+			continue
+		}
+
+		// Chrome 将多个同名头部用换行符合并为一个值
+		for _, value := range strings.Split(header.Value, "\n") {
+			result.AuthChallenges = append(result.AuthChallenges, parseAuthChallenges(value)...)
+		}
+	}
+
+	result.AuthRequired = len(result.AuthChallenges) > 0
+}
+
+// parseAuthChallenges 解析一个 WWW-Authenticate 头部值。一个值中可以有多个
+// 以逗号分隔的质询，例如：
+//
+//	Basic realm="admin", Bearer realm="api", error="invalid_token"
+//	Negotiate, NTLM
+//
+// 新的质询以后面没有 = 的方案名称开始，其余的是前一个质询的参数。
+func parseAuthChallenges(value string) []models.AuthChallenge {
+	var challenges []models.AuthChallenge
+
+	rest := strings.TrimSpace(value)
+	for rest != "" {
+		rest = strings.TrimLeft(rest, ", \t")
+		if rest == "" {
+			break
+		}
+
+		// 下一个词，是方案名称或参数名称
+		end := strings.IndexAny(rest, " \t,=")
+		if end < 0 {
+			end = len(rest)
+		}
+		token := rest[:end]
+		rest = strings.TrimLeft(rest[end:], " \t")
+
+		if !strings.HasPrefix(rest, "=") || len(challenges) == 0 {
+			// 方案名称，后面可能直接跟着参数或 token68
+			if token == "" {
+				break
+			}
+			challenges = append(challenges, models.AuthChallenge{Scheme: token})
+			continue
+		}
+
+		// 参数。以 = 填充结尾的 token68（例如 base64）也会被当作参数读取，
+		// 它们不是 realm，所以被忽略。
+		var param string
+		param, rest = authParamValue(strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t"))
+		if strings.EqualFold(token, "realm") {
+			challenges[len(challenges)-1].Realm = param
+		}
+	}
+
+	return challenges
+}
+
+// authParamValue 读取一个认证参数的值（可以是带引号的字符串），
+// 并返回值和剩余的字符串
+func authParamValue(s string) (string, string) {
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexAny(s, ", \t")
+		if end < 0 {
+			return s, ""
+		}
+		return s[:end], s[end:]
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:]
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), ""
+}
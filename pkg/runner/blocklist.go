@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// loadBlocklistHashes 读取感知哈希黑名单文件。
+// 每行一个哈希（"p:<hex>" 或 "<hex>"），空行和 # 注释会被忽略。
+func loadBlocklistHashes(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var hashes [][]byte
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		candidate := strings.TrimSpace(scanner.Text())
+		if candidate == "" || strings.HasPrefix(candidate, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(candidate, "p:") {
+			candidate = "p:" + candidate
+		}
+
+		hash, err := islazy.ParsePerceptionHash(candidate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid perception hash on line %d of %s: %w", line, path, err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, scanner.Err()
+}
+
+// isBlocklisted 返回 true 表示结果的感知哈希在黑名单哈希的阈值范围内
+func (run *Runner) isBlocklisted(result *models.Result) bool {
+	if len(run.blocklist) == 0 || result.PerceptionHash == "" {
+		return false
+	}
+
+	hash, err := islazy.ParsePerceptionHash(result.PerceptionHash)
+	if err != nil {
+		return false
+	}
+
+	for _, blocked := range run.blocklist {
+		distance, err := islazy.HammingDistance(hash, blocked)
+		if err != nil {
+			continue
+		}
+
+		if distance <= run.options.Scan.BlocklistHashThreshold {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeScreenshots 删除结果已写入磁盘的截图
+func (run *Runner) removeScreenshots(result *models.Result) {
+	if run.options.Scan.ScreenshotSkipSave {
+		return
+	}
+
+	filenames := []string{result.Filename}
+	for _, capture := range result.ScrollCaptures {
+		filenames = append(filenames, capture.Filename)
+	}
+	for _, capture := range result.BurstCaptures {
+		filenames = append(filenames, capture.Filename)
+	}
+
+	// 同时删除保留的原始格式截图
+	if run.options.Scan.ScreenshotKeepOriginal {
+		for _, filename := range filenames {
+			if filename != "" {
+				filenames = append(filenames, OriginalScreenshotFilename(run.options, filename))
+			}
+		}
+	}
+
+	for _, filename := range filenames {
+		if filename == "" {
+			continue
+		}
+
+		path := filepath.Join(run.options.Scan.ScreenshotPath, filename)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			run.log.Error("could not remove screenshot", "file", path, "err", err)
+		}
+	}
+}
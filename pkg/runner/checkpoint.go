@@ -0,0 +1,208 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sensepost/gowitness/internal/islazy"
+)
+
+// checkpointEntry 是检查点文件中的一行，记录一个已完成的目标
+type checkpointEntry struct {
+	Target      string    `json:"target"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// checkpoint 以 JSON Lines 记录已完成的目标，使中断的扫描可以在不使用
+// 数据库的情况下恢复。nil 的 checkpoint 是禁用的。
+type checkpoint struct {
+	mutex     sync.Mutex
+	file      *os.File
+	completed map[string]bool
+	// inProgress 是正在探测或者结果正在等待写入的目标
+	inProgress map[string]*checkpointTarget
+}
+
+// checkpointTarget 跟踪一个尚未记录到检查点的目标
+type checkpointTarget struct {
+	// holds 是还未释放的 hold 数量
+	holds int
+	// failed 为 true 表示目标没有完成，不会被记录
+	failed bool
+}
+
+// openCheckpoint 打开检查点文件。resume 为 true 时读取已完成的目标并
+// 在文件末尾追加，否则清空文件。path 为空时返回 nil。
+func openCheckpoint(path string, resume bool) (*checkpoint, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	// 不使用 CreateFileWithDir，它会清空已有的检查点
+	dir, err := islazy.CreateDir(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.Join(dir, filepath.Base(path))
+
+	flags := os.O_CREATE | os.O_RDWR | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_RDWR | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checkpoint{
+		file:       file,
+		completed:  make(map[string]bool),
+		inProgress: make(map[string]*checkpointTarget),
+	}
+	if resume {
+		if err := c.load(); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("could not read checkpoint file: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// load 读取检查点文件中已完成的目标。崩溃时可能只写了一半的最后一行会被
+// 忽略，并补上换行符，使之后追加的记录从新的一行开始。
+func (c *checkpoint) load() error {
+	reader := bufio.NewReader(c.file)
+
+	var last string
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			last = line
+
+			var entry checkpointEntry
+			if json.Unmarshal([]byte(line), &entry) == nil && entry.Target != "" {
+				c.completed[entry.Target] = true
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if last != "" && last[len(last)-1] != '\n' {
+		if _, err := c.file.WriteString("\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// done 检查目标是否已在之前的运行中完成
+func (c *checkpoint) done(target string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.completed[target]
+}
+
+// size 返回之前的运行中完成的目标数量
+func (c *checkpoint) size() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.completed)
+}
+
+// hold 标记目标正在处理。目标在它的所有 hold 都被释放后才会被记录，
+// 工作线程在探测目标时持有一个 hold，写入器写入目标的结果时也持有一个，
+// 因此批量写入器排队的结果在写入之前不会被记录。
+func (c *checkpoint) hold(target string) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t, ok := c.inProgress[target]
+	if !ok {
+		t = &checkpointTarget{}
+		c.inProgress[target] = t
+	}
+	t.holds++
+}
+
+// release 释放目标的一个 hold。ok 为 false 表示目标没有完成（例如运行被
+// 取消，或写入器没能写入结果），目标不会被记录，恢复时会被重新探测。
+// 最后一个 hold 被释放时，目标被记录为已完成。
+func (c *checkpoint) release(target string, ok bool) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t, found := c.inProgress[target]
+	if !found {
+		return nil
+	}
+	t.failed = t.failed || !ok
+
+	t.holds--
+	if t.holds > 0 {
+		return nil
+	}
+	delete(c.inProgress, target)
+
+	if t.failed {
+		return nil
+	}
+
+	return c.record(target)
+}
+
+// record 将目标写入检查点文件。每条记录在返回前同步到磁盘，因此崩溃后
+// 检查点中的目标都已经完成，它们的结果也已经被写入。调用者必须持有锁。
+func (c *checkpoint) record(target string) error {
+	line, err := json.Marshal(checkpointEntry{Target: target, CompletedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return c.file.Sync()
+}
+
+// close 关闭检查点文件
+func (c *checkpoint) close() error {
+	if c == nil {
+		return nil
+	}
+
+	return c.file.Close()
+}
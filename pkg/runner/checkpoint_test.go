@@ -0,0 +1,163 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckpointLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     map[string]bool
+	}{
+		{
+			name:     "Test with empty file",
+			contents: "",
+			want:     map[string]bool{},
+		},
+		{
+			name: "Test with completed targets",
+			contents: `{"target":"https://one.example.com","completed_at":"2024-06-01T12:00:00Z"}` + "\n" +
+				`{"target":"POST https://two.example.com a=b","completed_at":"2024-06-01T12:00:01Z"}` + "\n",
+			want: map[string]bool{"https://one.example.com": true, "POST https://two.example.com a=b": true},
+		},
+		{
+			name: "Test with truncated last line",
+			contents: `{"target":"https://one.example.com","completed_at":"2024-06-01T12:00:00Z"}` + "\n" +
+				`{"target":"https://two.exa`,
+			want: map[string]bool{"https://one.example.com": true},
+		},
+		{
+			name: "Test with invalid lines",
+			contents: "not json\n" + `{"completed_at":"2024-06-01T12:00:00Z"}` + "\n" +
+				`{"target":"https://one.example.com","completed_at":"2024-06-01T12:00:00Z"}` + "\n",
+			want: map[string]bool{"https://one.example.com": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+			if err := os.WriteFile(path, []byte(tt.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := openCheckpoint(path, true)
+			if err != nil {
+				t.Fatalf("openCheckpoint() error = %v", err)
+			}
+			if !reflect.DeepEqual(c.completed, tt.want) {
+				t.Errorf("load() =>\n\nhave: %v\nwant %v", c.completed, tt.want)
+			}
+
+			// new records start on a line of their own
+			c.hold("https://new.example.com")
+			if err := c.release("https://new.example.com", true); err != nil {
+				t.Fatalf("release() error = %v", err)
+			}
+			if err := c.close(); err != nil {
+				t.Fatal(err)
+			}
+
+			reopened, err := openCheckpoint(path, true)
+			if err != nil {
+				t.Fatalf("openCheckpoint() error = %v", err)
+			}
+			defer reopened.close()
+			if !reopened.done("https://new.example.com") || reopened.size() != len(tt.want)+1 {
+				t.Errorf("reopened checkpoint =>\n\nhave: %v\nwant %v and the new target", reopened.completed, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckpointHoldRelease(t *testing.T) {
+	tests := []struct {
+		name     string
+		releases []bool
+		want     bool
+	}{
+		{name: "Test with completed target", releases: []bool{true}, want: true},
+		{name: "Test with written result", releases: []bool{true, true}, want: true},
+		{name: "Test with canceled target", releases: []bool{false}, want: false},
+		{name: "Test with failed write", releases: []bool{true, false}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+			c, err := openCheckpoint(path, false)
+			if err != nil {
+				t.Fatalf("openCheckpoint() error = %v", err)
+			}
+			defer c.close()
+
+			for range tt.releases {
+				c.hold("https://example.com")
+			}
+			for i, ok := range tt.releases {
+				if err := c.release("https://example.com", ok); err != nil {
+					t.Fatalf("release() error = %v", err)
+				}
+
+				// nothing is recorded while a hold is left
+				contents, _ := os.ReadFile(path)
+				if i < len(tt.releases)-1 && len(contents) > 0 {
+					t.Fatalf("target was recorded with %d holds left", len(tt.releases)-1-i)
+				}
+			}
+
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(string(contents), `"https://example.com"`); got != tt.want {
+				t.Errorf("recorded =>\n\nhave: %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipCompletedTargets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	contents := `{"target":"https://one.example.com","completed_at":"2024-06-01T12:00:00Z"}` + "\n" +
+		`{"target":"https://three.example.com","completed_at":"2024-06-01T12:00:00Z"}` + "\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := openCheckpoint(path, true)
+	if err != nil {
+		t.Fatalf("openCheckpoint() error = %v", err)
+	}
+	defer c.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	run := &Runner{checkpoint: c, log: slog.New(slog.NewTextHandler(io.Discard, nil)), ctx: ctx, cancel: cancel}
+
+	targets := make(chan string)
+	go func() {
+		for _, target := range []string{"https://one.example.com", "https://two.example.com", "https://three.example.com", "https://four.example.com"} {
+			targets <- target
+		}
+		close(targets)
+	}()
+
+	var remaining []string
+	for target := range run.skipCompletedTargets(targets) {
+		remaining = append(remaining, target)
+	}
+
+	want := []string{"https://two.example.com", "https://four.example.com"}
+	if !reflect.DeepEqual(remaining, want) {
+		t.Errorf("skipCompletedTargets() =>\n\nhave: %v\nwant %v", remaining, want)
+	}
+}
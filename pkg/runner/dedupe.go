@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// finalURLSet 是一个线程安全的已见最终 URL 集合
+type finalURLSet struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+	// stripQuery 比较时同时忽略查询字符串
+	stripQuery bool
+}
+
+// newFinalURLSet 返回一个新的最终 URL 集合
+func newFinalURLSet(stripQuery bool) *finalURLSet {
+	return &finalURLSet{seen: make(map[string]struct{}), stripQuery: stripQuery}
+}
+
+// add 将 URL 添加到集合中。如果该 URL 之前已经见过，返回 false。
+func (s *finalURLSet) add(raw string) bool {
+	if s.stripQuery {
+		raw = StripQuery(raw)
+	}
+	key := dedupeKey(raw)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[key]; ok {
+		return false
+	}
+	s.seen[key] = struct{}{}
+
+	return true
+}
+
+// dedupeKey 规范化 URL 以便比较：忽略片段、主机名大小写和末尾的斜杠
+func dedupeKey(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	return u.String()
+}
+
+// StripQuery 移除 URL 中的查询字符串和片段。
+// 仅用于生成文件名和去重键，结果中始终保留完整的 URL。
+func StripQuery(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u.String()
+}
@@ -0,0 +1,155 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPageSignature 描述一类默认页面或占位页面。
+// 页面标题包含任一 Titles，或 HTML 包含任一 HTML 片段时匹配（不区分大小写）。
+type DefaultPageSignature struct {
+	Name   string   `yaml:"name"`
+	Titles []string `yaml:"titles"`
+	HTML   []string `yaml:"html"`
+}
+
+// defaultPageSignatures 是内置的默认页面特征
+var defaultPageSignatures = []DefaultPageSignature{
+	{
+		Name: "apache",
+		Titles: []string{"apache2 ubuntu default page", "apache2 debian default page",
+			"test page for the apache http server", "apache http server test page"},
+		HTML: []string{"<h1>it works!</h1>"},
+	},
+	{
+		Name:   "nginx",
+		Titles: []string{"welcome to nginx!", "test page for the nginx http server"},
+	},
+	{
+		Name:   "openresty",
+		Titles: []string{"welcome to openresty!"},
+	},
+	{
+		Name:   "iis",
+		Titles: []string{"iis windows", "internet information services", "iis7", "iis8"},
+		HTML:   []string{"iisstart.png"},
+	},
+	{
+		Name:   "tomcat",
+		Titles: []string{"apache tomcat/"},
+		HTML:   []string{"if you're seeing this, you've successfully installed tomcat"},
+	},
+	{
+		Name:   "caddy",
+		Titles: []string{"caddy works!"},
+	},
+	{
+		Name:   "os-test-page",
+		Titles: []string{"welcome to centos", "test page for the http server on fedora", "test page for the http server on red hat"},
+	},
+	{
+		Name:   "hosting-panel",
+		Titles: []string{"default web site page", "default parallels plesk page", "domain default page"},
+		HTML:   []string{"future home of something quite cool"},
+	},
+	{
+		Name: "cloud",
+		Titles: []string{"firebase hosting setup complete", "heroku | welcome to your new app!",
+			"microsoft azure app service - welcome"},
+		HTML: []string{"your web app is running and waiting for your content", "hey, app service developers!"},
+	},
+	{
+		Name:   "parked",
+		Titles: []string{"parked domain"},
+		HTML: []string{"this domain is parked", "this domain may be for sale", "buy this domain",
+			"sedoparking.com", "parkingcrew.net", "bodis.com/", "hugedomains.com"},
+	},
+}
+
+// loadDefaultPageSignatures 读取 YAML 默认页面特征文件，文件中的特征
+// 会追加到内置特征之后。文件是一个特征列表，例如：
+//
+//   - name: my-appliance
+//     titles: ["welcome to my appliance"]
+//     html: ["/static/appliance-logo.png"]
+func loadDefaultPageSignatures(path string) ([]DefaultPageSignature, error) {
+	signatures := append([]DefaultPageSignature{}, defaultPageSignatures...)
+	if path == "" {
+		return signatures, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var extra []DefaultPageSignature
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&extra); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("could not parse default page signatures %s: %w", path, err)
+	}
+
+	for i, signature := range extra {
+		if signature.Name == "" || (len(signature.Titles) == 0 && len(signature.HTML) == 0) {
+			return nil, fmt.Errorf("default page signature %d in %s needs a name and titles or html", i+1, path)
+		}
+
+		signatures = append(signatures, DefaultPageSignature{
+			Name:   signature.Name,
+			Titles: lowerStrings(signature.Titles),
+			HTML:   lowerStrings(signature.HTML),
+		})
+	}
+
+	return signatures, nil
+}
+
+// detectDefaultPage 在结果的标题或 HTML 匹配默认页面特征时设置
+// IsDefaultPage 和匹配的特征名称
+func (run *Runner) detectDefaultPage(result *models.Result) {
+	title := strings.ToLower(result.Title)
+	html := strings.ToLower(result.HTML)
+
+	for _, signature := range run.defaultPages {
+		if signature.matches(title, html) {
+			result.IsDefaultPage = true
+			result.DefaultPage = signature.Name
+			return
+		}
+	}
+}
+
+// matches 检查小写的标题或 HTML 是否匹配特征
+func (s DefaultPageSignature) matches(title, html string) bool {
+	for _, t := range s.Titles {
+		if title != "" && strings.Contains(title, t) {
+			return true
+		}
+	}
+
+	for _, h := range s.HTML {
+		if html != "" && strings.Contains(html, h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lowerStrings 返回字符串的小写副本
+func lowerStrings(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+
+	return lowered
+}
@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sensepost/gowitness/pkg/models"
@@ -16,7 +17,8 @@ func (e ChromeNotFoundError) Error() string {
 }
 
 // Driver is the interface browser drivers will implement.
+// The context carries the trace span of the witness call, if any.
 type Driver interface {
-	Witness(target string, runner *Runner) (*models.Result, error)
+	Witness(ctx context.Context, target string, runner *Runner) (*models.Result, error)
 	Close()
 }
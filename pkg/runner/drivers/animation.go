@@ -0,0 +1,63 @@
+package driver
+
+import "fmt"
+
+// animationFrameStableFrames is the number of consecutive frames without
+// layout changes or running animations after which a page is considered
+// visually stable
+const animationFrameStableFrames = 10
+
+// animationFrameWaitLimit is the maximum time to wait for a page to become
+// visually stable, in milliseconds
+const animationFrameWaitLimit = 5000
+
+// animationFramesJs waits for at least the given number of animation frames,
+// and then until the page layout did not change and no finite animations
+// ran for stableFrames consecutive frames, at most limit milliseconds.
+// Infinite animations (e.g. spinners) never finish and are ignored. Frames
+// fall back to a timer, as hidden pages don't get animation frames. It
+// returns the number of frames waited for.
+const animationFramesJs = `async (frames, stableFrames, limit) => {
+	const start = Date.now();
+	const frame = () => new Promise((r) => {
+		const timer = setTimeout(r, 100);
+		requestAnimationFrame(() => { clearTimeout(timer); r(); });
+	});
+	const layout = () => {
+		const root = document.documentElement;
+		const body = document.body;
+		return [root.scrollWidth, root.scrollHeight, body ? body.scrollWidth : 0,
+			body ? body.scrollHeight : 0, document.getElementsByTagName("*").length].join(",");
+	};
+	const animating = () => {
+		if (!document.getAnimations) return false;
+		return document.getAnimations().some((a) => a.playState === "running" &&
+			!(a.effect && a.effect.getTiming && a.effect.getTiming().iterations === Infinity));
+	};
+
+	let count = 0;
+	while (count < frames && Date.now() - start < limit) {
+		await frame();
+		count++;
+	}
+
+	let previous = layout();
+	let stable = 0;
+	while (stable < stableFrames && Date.now() - start < limit) {
+		await frame();
+		count++;
+
+		const current = layout();
+		stable = current === previous && !animating() ? stable + 1 : 0;
+		previous = current;
+	}
+
+	return count;
+}`
+
+// animationFramesScript returns animationFramesJs as an expression called
+// with its arguments
+func animationFramesScript(frames int) string {
+	return fmt.Sprintf("(%s)(%d, %d, %d)", animationFramesJs,
+		frames, animationFrameStableFrames, animationFrameWaitLimit)
+}
@@ -0,0 +1,59 @@
+package driver
+
+import (
+	"strings"
+	"time"
+)
+
+// challengePollInterval is how often a challenge page is checked for
+// being cleared
+const challengePollInterval = time.Second
+
+// challengeJs returns the title and (truncated) HTML of a page
+const challengeJs = `() => [
+	document.title,
+	document.documentElement ? document.documentElement.outerHTML.slice(0, 100000) : ""
+]`
+
+// challengeTitles are page titles used by common JavaScript challenge
+// interstitials
+var challengeTitles = []string{
+	"just a moment",
+	"checking your browser",
+	"attention required! | cloudflare",
+	"ddos-guard",
+	"please wait",
+	"one more step",
+	"verifying you are human",
+}
+
+// challengeMarkers are HTML fragments used by common JavaScript challenge
+// interstitials
+var challengeMarkers = []string{
+	"/cdn-cgi/challenge-platform/",
+	"cf-browser-verification",
+	"cf_chl_opt",
+	"ddos-guard.net/",
+	"_incapsula_resource",
+	"sucuri_cloudproxy_js",
+}
+
+// isChallengePage returns true if a page looks like a JavaScript challenge
+// interstitial rather than real content
+func isChallengePage(title, html string) bool {
+	title = strings.ToLower(strings.TrimSpace(title))
+	for _, t := range challengeTitles {
+		if strings.HasPrefix(title, t) {
+			return true
+		}
+	}
+
+	html = strings.ToLower(html)
+	for _, marker := range challengeMarkers {
+		if strings.Contains(html, marker) {
+			return true
+		}
+	}
+
+	return false
+}
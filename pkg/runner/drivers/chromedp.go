@@ -10,24 +10,31 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/chromedp/cdproto/browser"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/serviceworker"
 	"github.com/chromedp/cdproto/storage"
 	"github.com/chromedp/chromedp"
 	"github.com/corona10/goimagehash"
 	"github.com/sensepost/gowitness/internal/islazy"
 	"github.com/sensepost/gowitness/pkg/models"
 	"github.com/sensepost/gowitness/pkg/runner"
+	"github.com/sensepost/gowitness/pkg/targetline"
 )
 
+// partialCaptureTimeout 是导航后获取标题和 HTML 的最长时间
+const partialCaptureTimeout = 10 * time.Second
+
 // Chromedp 是使用 chromedp 探测 Web 目标的驱动程序
 // 实现参考：https://github.com/chromedp/examples/blob/master/multi/main.go
 type Chromedp struct {
@@ -35,6 +42,10 @@ type Chromedp struct {
 	options runner.Options
 	// 日志记录器
 	log *slog.Logger
+	// 出示客户端证书的本地代理（如果配置了）
+	clientCert *clientCertProxy
+	// browserVersion 是浏览器报告的版本字符串
+	browserVersion string
 }
 
 // browserInstance 是 Witness 一次运行使用的实例
@@ -67,7 +78,7 @@ func getChromedpAllocator(opts runner.Options) (*browserInstance, error) {
 	)
 
 	if opts.Chrome.WSS == "" {
-		userData, err = os.MkdirTemp("", "gowitness-v3-chromedp-*")
+		userData, err = newUserDataDir("gowitness-v3-chromedp-*", opts.Chrome.ProfilePath)
 		if err != nil {
 			return nil, err
 		}
@@ -82,12 +93,15 @@ func getChromedpAllocator(opts runner.Options) (*browserInstance, error) {
 			chromedp.Flag("disable-dev-shm-usage", true),
 			chromedp.Flag("disable-features", "MediaRouter"),
 			chromedp.Flag("mute-audio", true),
+			// 与 go-rod 一致地隐藏滚动条，使截图在两个驱动之间相同
+			chromedp.Flag("hide-scrollbars", !opts.Chrome.ShowScrollbars),
 			chromedp.Flag("disable-background-timer-throttling", true),
 			chromedp.Flag("disable-backgrounding-occluded-windows", true),
 			chromedp.Flag("disable-renderer-backgrounding", true),
 			chromedp.Flag("deny-permission-prompts", true),
 			chromedp.Flag("explicitly-allowed-ports", restrictedPorts()),
 			chromedp.WindowSize(opts.Chrome.WindowX, opts.Chrome.WindowY),
+			chromedp.Flag("window-position", fmt.Sprintf("%d,%d", opts.Chrome.WindowPositionX, opts.Chrome.WindowPositionY)),
 			chromedp.UserDataDir(userData),
 		)
 
@@ -96,6 +110,11 @@ func getChromedpAllocator(opts runner.Options) (*browserInstance, error) {
 			allocOpts = append(allocOpts, chromedp.ProxyServer(opts.Chrome.Proxy))
 		}
 
+		// 不加载图像
+		if opts.Scan.DisableJavaScript {
+			allocOpts = append(allocOpts, chromedp.Flag("blink-settings", "imagesEnabled=false"))
+		}
+
 		// 如果提供了特定的 Chrome 二进制文件，则使用它
 		if opts.Chrome.Path != "" {
 			allocOpts = append(allocOpts, chromedp.ExecPath(opts.Chrome.Path))
@@ -116,24 +135,97 @@ func getChromedpAllocator(opts runner.Options) (*browserInstance, error) {
 
 // NewChromedp 返回一个新的 Chromedp 实例
 func NewChromedp(logger *slog.Logger, opts runner.Options) (*Chromedp, error) {
+	// 通过本地代理出示客户端证书
+	var clientCert *clientCertProxy
+	if opts.Chrome.ClientCert != "" {
+		var err error
+		clientCert, err = newClientCertProxy(logger, opts)
+		if err != nil {
+			return nil, err
+		}
+		opts.Chrome.Proxy = clientCert.URL()
+	}
+
+	// 记录浏览器版本，以便结果可以复现。这里启动的浏览器
+	// 只用于获取版本，失败时 Witness 会报告更具体的错误。
+	version, err := chromedpBrowserVersion(opts)
+	if err != nil {
+		logger.Warn("could not get browser version", "err", err)
+	} else {
+		logger.Debug("browser version", "version", version)
+	}
+
 	return &Chromedp{
-		options: opts,
-		log:     logger,
+		options:        opts,
+		log:            logger,
+		clientCert:     clientCert,
+		browserVersion: version,
 	}, nil
 }
 
+// chromedpBrowserVersion 启动一个浏览器并返回其报告的产品版本
+func chromedpBrowserVersion(opts runner.Options) (string, error) {
+	allocator, err := getChromedpAllocator(opts)
+	if err != nil {
+		return "", err
+	}
+	defer allocator.Close()
+
+	browserCtx, cancel := chromedp.NewContext(allocator.allocCtx)
+	defer cancel()
+
+	ctx, timeoutCancel := context.WithTimeout(browserCtx, time.Duration(opts.Scan.Timeout)*time.Second)
+	defer timeoutCancel()
+
+	var product string
+	if err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, product, _, _, _, err = browser.GetVersion().Do(ctx)
+		return err
+	})); err != nil {
+		return "", err
+	}
+
+	return product, nil
+}
+
 // witness 执行探测 URL 的工作。
 // 就 runner 而言，这是所有工作汇聚的地方。
-func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.Result, error) {
+func (run *Chromedp) Witness(ctx context.Context, target string, thisRunner *runner.Runner) (*models.Result, error) {
 	logger := run.log.With("target", target)
 	logger.Debug("witnessing 👀")
 
+	// 目标可能带有请求方法和请求体
+	request := targetline.Parse(target)
+	target = request.URL
+
+	// 只向目标的主机出示客户端证书
+	if run.clientCert != nil {
+		host := urlHostname(target)
+		run.clientCert.Allow(host)
+		defer run.clientCert.Release(host)
+	}
+
 	// 这可能看起来很奇怪，但在对大量列表进行截图时，使用
 	// 标签页意味着截图失败的几率非常高。可能是
 	// 父浏览器进程的资源问题？所以，现在使用这个
 	// 驱动程序意味着资源使用量将更高，但你的准确性
 	// 也会非常惊人。
-	allocator, err := getChromedpAllocator(run.options)
+	//
+	// 因为每个目标都有自己的浏览器，目标行中指定的代理可以
+	// 覆盖 Chrome.Proxy。
+	allocatorOpts := run.options
+	if request.Proxy != "" {
+		switch {
+		case run.options.Chrome.WSS != "":
+			logger.Warn("per-target proxies are not supported with a remote chrome instance, ignoring the target proxy")
+		case run.clientCert != nil:
+			logger.Warn("per-target proxies cannot be combined with client certificates, ignoring the target proxy")
+		default:
+			allocatorOpts.Chrome.Proxy = request.Proxy
+		}
+	}
+
+	allocator, err := getChromedpAllocator(allocatorOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -163,17 +255,46 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 		return nil, fmt.Errorf("error enabling network tracking: %w", err)
 	}
 
+	// 显式设置视口、屏幕大小和设备像素比。无头模式下窗口大小和截图视口
+	// 可能不一致，导致不同运行之间的截图尺寸不同。
+	if run.options.Chrome.WindowX > 0 && run.options.Chrome.WindowY > 0 {
+		if err := chromedp.Run(navigationCtx, emulation.SetDeviceMetricsOverride(
+			int64(run.options.Chrome.WindowX),
+			int64(run.options.Chrome.WindowY),
+			run.options.Chrome.DeviceScaleFactor,
+			false,
+		).WithScreenWidth(int64(run.options.Chrome.WindowX)).
+			WithScreenHeight(int64(run.options.Chrome.WindowY))); err != nil {
+			return nil, fmt.Errorf("could not set device metrics: %w", err)
+		}
+	}
+
+	// 模拟慢速网络
+	if run.options.Scan.NetworkThrottle != "" {
+		throttle, err := runner.ParseNetworkThrottle(run.options.Scan.NetworkThrottle)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := chromedp.Run(navigationCtx, network.EmulateNetworkConditions(
+			throttle.Offline,
+			throttle.Latency,
+			throttle.DownloadThroughput,
+			throttle.UploadThroughput,
+		)); err != nil {
+			return nil, fmt.Errorf("could not emulate network conditions: %w", err)
+		}
+	}
+
 	// 设置额外的头部（如果有）
-	if len(run.options.Chrome.Headers) > 0 {
+	extra, invalid := extraHeaders(run.options)
+	for _, header := range invalid {
+		logger.Warn("custom header did not parse correctly", "header", header)
+	}
+	if len(extra) > 0 {
 		headers := make(network.Headers)
-		for _, header := range run.options.Chrome.Headers {
-			kv := strings.SplitN(header, ":", 2)
-			if len(kv) != 2 {
-				logger.Warn("custom header did not parse correctly", "header", header)
-				continue
-			}
-
-			headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		for k, v := range extra {
+			headers[k] = v
 		}
 
 		if err := chromedp.Run(navigationCtx, network.SetExtraHTTPHeaders((headers))); err != nil {
@@ -181,17 +302,89 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 		}
 	}
 
+	// 授予指定的权限
+	if granted := grantedPermissions(run.options); len(granted) > 0 {
+		var permissions []browser.PermissionType
+		for _, permission := range granted {
+			permissions = append(permissions, browser.PermissionType(permission))
+		}
+
+		if err := chromedp.Run(navigationCtx, browser.GrantPermissions(permissions)); err != nil {
+			return nil, fmt.Errorf("could not grant permissions: %w", err)
+		}
+	}
+
+	// 模拟地理位置
+	if run.options.Chrome.Geolocation != "" {
+		geolocation, err := runner.ParseGeolocation(run.options.Chrome.Geolocation)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := chromedp.Run(navigationCtx, emulation.SetGeolocationOverride().
+			WithLatitude(geolocation.Latitude).
+			WithLongitude(geolocation.Longitude).
+			WithAccuracy(geolocation.Accuracy)); err != nil {
+			return nil, fmt.Errorf("could not set geolocation override: %w", err)
+		}
+	}
+
+	// 禁用 JavaScript 执行
+	if run.options.Scan.DisableJavaScript {
+		if err := chromedp.Run(navigationCtx, emulation.SetScriptExecutionDisabled(true)); err != nil {
+			return nil, fmt.Errorf("could not disable javascript: %w", err)
+		}
+	}
+
+	// 在任何页面脚本之前执行的 JavaScript
+	if run.options.Scan.JavaScriptOnNewDocument != "" {
+		if err := chromedp.Run(navigationCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(run.options.Scan.JavaScriptOnNewDocument).Do(ctx)
+			return err
+		})); err != nil {
+			return nil, fmt.Errorf("could not add javascript to evaluate on new documents: %w", err)
+		}
+	}
+
+	// 扩大资源计时缓冲区，以记录页面的所有资源
+	if run.options.Scan.CaptureResourceTimings {
+		if err := chromedp.Run(navigationCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(resourceTimingBufferScript()).Do(ctx)
+			return err
+		})); err != nil {
+			return nil, fmt.Errorf("could not raise the resource timing buffer size: %w", err)
+		}
+	}
+
+	// 如果不是普通的 GET 导航，拦截主文档请求以改写方法和请求体
+	if !request.IsPlain() {
+		if err := chromedp.Run(navigationCtx, fetch.Enable().WithPatterns([]*fetch.RequestPattern{
+			{URLPattern: "*", ResourceType: network.ResourceTypeDocument},
+		})); err != nil {
+			return nil, fmt.Errorf("could not enable request interception: %w", err)
+		}
+	}
+
 	// 使用页面事件来获取有关目标的信息。这是我们
 	// 了解第一个请求结果的方式，以便将其保存为
 	// 输出写入器的整体 URL 结果。
 	var (
 		result = &models.Result{
-			URL:      target,
-			ProbedAt: time.Now(),
+			URL:            target,
+			Method:         request.Method,
+			ProbedAt:       time.Now(),
+			BrowserVersion: run.browserVersion,
+			Referer:        run.options.Scan.Referer,
 		}
 		resultMutex sync.Mutex
 		first       *network.EventRequestWillBeSent
 		netlog      = make(map[string]models.NetworkLog)
+		receipts    = make(map[string]responseReceipt)
+		rewritten   atomic.Bool
+		transferred atomic.Int64
+		idle        = newIdleWatchdog(run.options.Scan.IdleTimeout)
+		websockets  = newWebsocketRecorder(run.options)
+		workers     = newServiceWorkerRecorder(run.options)
 	)
 
 	go chromedp.ListenTarget(navigationCtx, func(ev interface{}) {
@@ -201,6 +394,32 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 			if err := chromedp.Run(navigationCtx, page.HandleJavaScriptDialog(true)); err != nil {
 				logger.Error("failed to handle a javascript dialog", "err", err)
 			}
+		// 改写第一个被拦截的文档请求，其余请求原样放行
+		case *fetch.EventRequestPaused:
+			go func() {
+				c := chromedp.FromContext(navigationCtx)
+				p := fetch.ContinueRequest(e.RequestID)
+
+				if rewritten.CompareAndSwap(false, true) {
+					p = p.WithMethod(request.Method)
+					if request.Body != "" {
+						headers := []*fetch.HeaderEntry{{Name: "Content-Type", Value: request.ContentType()}}
+						for k, v := range e.Request.Headers {
+							if strings.EqualFold(k, "content-type") {
+								continue
+							}
+							headers = append(headers, &fetch.HeaderEntry{Name: k, Value: fmt.Sprint(v)})
+						}
+
+						p = p.WithHeaders(headers).
+							WithPostData(base64.StdEncoding.EncodeToString([]byte(request.Body)))
+					}
+				}
+
+				if err := p.Do(cdp.WithExecutor(navigationCtx, c.Target)); err != nil {
+					logger.Error("could not continue intercepted request", "err", err)
+				}
+			}()
 		// 记录 console.* 调用
 		case *runtime.EventConsoleAPICalled:
 			v := ""
@@ -222,15 +441,33 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 		// 网络相关事件
 		// 将请求写入网络请求映射
 		case *network.EventRequestWillBeSent:
+			idle.touch()
 			if first == nil {
 				first = e
 			}
-			netlog[string(e.RequestID)] = models.NetworkLog{
+			// 重定向时主请求以相同的请求 ID 再次发送
+			if e.RedirectResponse != nil && first.RequestID == e.RequestID {
+				resultMutex.Lock()
+				result.Redirects = append(result.Redirects, models.Redirect{
+					URL:          e.RedirectResponse.URL,
+					Location:     e.Request.URL,
+					ResponseCode: int(e.RedirectResponse.Status),
+				})
+				resultMutex.Unlock()
+			}
+			entry := models.NetworkLog{
 				Time:        e.WallTime.Time(),
 				RequestType: models.HTTP,
 				URL:         e.Request.URL,
 			}
+			if run.options.Scan.SaveNetworkHeaders {
+				entry.Headers = networkHeaders("request", e.Request.Headers, func(v interface{}) string {
+					return fmt.Sprint(v)
+				})
+			}
+			netlog[string(e.RequestID)] = entry
 		case *network.EventResponseReceived:
+			idle.touch()
 			if entry, ok := netlog[string(e.RequestID)]; ok {
 				if first != nil && first.RequestID == e.RequestID {
 					resultMutex.Lock()
@@ -239,6 +476,7 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 					result.ResponseReason = e.Response.StatusText
 					result.Protocol = e.Response.Protocol
 					result.ContentLength = int64(e.Response.EncodedDataLength)
+					result.RemoteAddr = remoteAddr(e.Response.RemoteIPAddress, int(e.Response.RemotePort))
 
 					// 写入头部
 					for k, v := range e.Response.Headers {
@@ -286,19 +524,41 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 				entry.URL = e.Response.URL
 				entry.RemoteIP = e.Response.RemoteIPAddress
 				entry.MIMEType = e.Response.MimeType
+				entry.Protocol = e.Response.Protocol
+				entry.Pushed = e.Response.Timing != nil && e.Response.Timing.PushStart > 0
+				entry.Trailers = announcedTrailers(e.Response.Headers, func(v interface{}) string {
+					return fmt.Sprint(v)
+				})
+				var timing networkTiming
+				if t := e.Response.Timing; t != nil {
+					timing = networkTiming{
+						RequestTime: t.RequestTime, DNSStart: t.DNSStart, DNSEnd: t.DNSEnd,
+						ConnectStart: t.ConnectStart, ConnectEnd: t.ConnectEnd, SSLStart: t.SslStart, SSLEnd: t.SslEnd,
+						SendStart: t.SendStart, SendEnd: t.SendEnd, ReceiveHeadersEnd: t.ReceiveHeadersEnd,
+					}
+					timing.apply(&entry)
+				}
 				if e.Response.ResponseTime != nil {
 					entry.Time = e.Response.ResponseTime.Time()
 				}
+				if run.options.Scan.SaveNetworkHeaders {
+					entry.Headers = append(entry.Headers, networkHeaders("response", e.Response.Headers, func(v interface{}) string {
+						return fmt.Sprint(v)
+					})...)
+				}
 
 				// 写入网络日志
 				resultMutex.Lock()
 				entryIndex := len(result.Network)
 				result.Network = append(result.Network, entry)
 				resultMutex.Unlock()
+				if e.Response.Timing != nil {
+					receipts[string(e.RequestID)] = responseReceipt{index: entryIndex, headersReceived: timing.headersReceived()}
+				}
 
 				// 如果我们需要写入响应体，就这样做
 				// https://github.com/chromedp/chromedp/issues/543
-				if run.options.Scan.SaveContent {
+				if shouldSaveContent(run.options, e.Response.MimeType) {
 					go func(index int) {
 						c := chromedp.FromContext(navigationCtx)
 						p := network.GetResponseBody(e.RequestID)
@@ -310,13 +570,34 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 							}
 						}
 
+						content, file, err := storeContent(run.options, body)
+						if err != nil {
+							run.log.Error("could not store network request response body", "url", e.Response.URL, "err", err)
+							return
+						}
+
 						resultMutex.Lock()
-						result.Network[index].Content = body
+						result.Network[index].Content = content
+						result.Network[index].ContentFile = file
 						resultMutex.Unlock()
 
 					}(entryIndex)
 				}
 			}
+		// 收到数据表示连接没有空闲
+		case *network.EventDataReceived:
+			idle.touch()
+		// 统计传输的字节数
+		case *network.EventLoadingFinished:
+			transferred.Add(int64(e.EncodedDataLength))
+
+			// 记录响应体的下载时间
+			if receipt, ok := receipts[string(e.RequestID)]; ok && e.Timestamp != nil {
+				finished := float64(e.Timestamp.Time().Sub(*cdp.MonotonicTimeEpoch)) / float64(time.Second)
+				resultMutex.Lock()
+				result.Network[receipt.index].ReceiveTime = receipt.receiveTime(finished)
+				resultMutex.Unlock()
+			}
 		// 将请求标记为失败
 		case *network.EventLoadingFailed:
 			// 获取现有的 requestid 并添加失败信息
@@ -327,6 +608,7 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 				if first != nil && first.RequestID == e.RequestID {
 					result.Failed = true
 					result.FailedReason = e.ErrorText
+					result.NetError = netError(e.ErrorText)
 				} else {
 					entry.Error = e.ErrorText
 
@@ -336,28 +618,129 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 
 				resultMutex.Unlock()
 			}
+		// 记录 WebSocket 连接和文本帧
+		case *network.EventWebSocketCreated:
+			websockets.created(string(e.RequestID), e.URL)
+		case *network.EventWebSocketFrameSent:
+			idle.touch()
+			if e.Response != nil {
+				websockets.frame(string(e.RequestID), "sent", int(e.Response.Opcode), e.Response.PayloadData)
+			}
+		case *network.EventWebSocketFrameReceived:
+			idle.touch()
+			if e.Response != nil {
+				websockets.frame(string(e.RequestID), "received", int(e.Response.Opcode), e.Response.PayloadData)
+			}
+		// 记录 service worker 注册和版本
+		case *serviceworker.EventWorkerRegistrationUpdated:
+			for _, registration := range e.Registrations {
+				workers.registration(string(registration.RegistrationID), registration.ScopeURL, registration.IsDeleted)
+			}
+		case *serviceworker.EventWorkerVersionUpdated:
+			for _, version := range e.Versions {
+				workers.version(version.VersionID, string(version.RegistrationID), version.ScriptURL, string(version.Status))
+			}
 		}
-
-		// TODO: wss
 	})
 
+	// 跟踪 service worker
+	if run.options.Scan.CapturePWA {
+		if err := chromedp.Run(navigationCtx, serviceworker.Enable()); err != nil {
+			return nil, fmt.Errorf("could not enable service worker tracking: %w", err)
+		}
+	}
+
 	// 导航到目标
-	if err := chromedp.Run(
-		navigationCtx, chromedp.Navigate(target),
-	); err != nil && err != context.DeadlineExceeded {
+	// 空闲超时只在导航期间生效
+	_, navigateSpan := thisRunner.Tracer.Start(ctx, "navigate")
+	loadCtx, loadCancel := idle.watch(navigationCtx)
+	err = run.navigate(loadCtx, target)
+	loadCancel()
+	if idleErr := idle.err(); idleErr != nil {
+		navigateSpan.SetError(idleErr)
+		navigateSpan.End()
+		return nil, fmt.Errorf("could not navigate to target: %w", idleErr)
+	}
+	if err != nil && err != context.DeadlineExceeded {
+		navigateSpan.SetError(err)
+		navigateSpan.End()
 		return nil, fmt.Errorf("could not navigate to target: %w", err)
 	}
+	navigateSpan.End()
 
 	// 如果有延迟，就等待
 	if run.options.Scan.Delay > 0 {
 		time.Sleep(time.Duration(run.options.Scan.Delay) * time.Second)
 	}
 
+	// 等待动画帧，直到页面在视觉上稳定
+	if run.options.Scan.WaitAnimationFrames > 0 && !run.options.Scan.DisableJavaScript {
+		var frames int
+		if err := chromedp.Run(navigationCtx, chromedp.Evaluate(animationFramesScript(run.options.Scan.WaitAnimationFrames), &frames,
+			func(p *runtime.EvaluateParams) *runtime.EvaluateParams { return p.WithAwaitPromise(true) })); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not wait for animation frames", "err", err)
+			}
+		} else {
+			logger.Debug("waited for animation frames", "frames", frames)
+		}
+	}
+
 	// 运行我们有的任何 JavaScript
 	if run.options.Scan.JavaScript != "" {
-		if err := chromedp.Run(navigationCtx, chromedp.Evaluate(run.options.Scan.JavaScript, nil)); err != nil {
+		_, javascriptSpan := thisRunner.Tracer.Start(ctx, "javascript")
+		var err error
+		if run.options.Scan.JavaScriptReturn {
+			// 调用函数并将其返回值以 JSON 记录
+			var value []byte
+			err = chromedp.Run(navigationCtx, chromedp.Evaluate("("+run.options.Scan.JavaScript+")()", &value,
+				func(p *runtime.EvaluateParams) *runtime.EvaluateParams { return p.WithAwaitPromise(true) }))
+			result.JavaScriptReturn = string(value)
+		} else {
+			err = chromedp.Run(navigationCtx, chromedp.Evaluate(run.options.Scan.JavaScript, nil))
+		}
+		if err != nil {
+			javascriptSpan.SetError(err)
+			javascriptSpan.End()
 			return nil, fmt.Errorf("failed to evaluate user-provided javascript: %w", err)
 		}
+		javascriptSpan.End()
+	}
+
+	// 等待 JavaScript 挑战页面通过
+	if run.options.Scan.ChallengeWait > 0 {
+		result.ChallengeDetected, result.ChallengePassed = run.waitForChallenge(navigationCtx, target)
+		if result.ChallengeDetected {
+			logger.Debug("javascript challenge detected", "passed", result.ChallengePassed)
+		}
+	}
+
+	// 记录是否出示了客户端证书。Chrome 只连接到本地代理，它报告的
+	// TLS 详情和远程地址都属于代理，所以改用代理到目标的上游连接
+	if run.clientCert != nil {
+		host := urlHostname(result.FinalURL)
+		result.ClientCertPresented = run.clientCert.Presented(host)
+		result.TLS, result.RemoteAddr, _ = run.clientCert.Connection(host)
+	} else if result.TLS.Protocol != "" {
+		// 获取叶证书指纹，用于关联共享证书的结果，以及证书链
+		if err := chromedp.Run(navigationCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+			tableNames, err := network.GetCertificate(urlOrigin(result.FinalURL)).Do(ctx)
+			if err != nil {
+				return err
+			}
+
+			result.TLS.FingerprintSHA256, err = certificateFingerprint(tableNames)
+			if err != nil {
+				return err
+			}
+
+			result.TLS.PEM, err = certificateChainPEM(tableNames)
+			return err
+		})); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not get certificate fingerprint", "err", err)
+			}
+		}
 	}
 
 	// 获取 cookies
@@ -383,14 +766,23 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 				Secure:       cookie.Secure,
 				Session:      cookie.Session,
 				Priority:     cookie.Priority.String(),
+				SameSite:     cookie.SameSite.String(),
 				SourceScheme: cookie.SourceScheme.String(),
 				SourcePort:   cookie.SourcePort,
 			})
 		}
 	}
 
+	// 导航超时后 navigationCtx 已经失效。标题和 HTML 使用一个从标签页派生的
+	// 短超时上下文获取，这样部分加载的页面仍然可以尽力获取已有的内容。
+	if navigationCtx.Err() != nil {
+		logger.Debug("navigation timed out, capturing the partially loaded page")
+	}
+	captureCtx, captureCancel := context.WithTimeout(tabCtx, partialCaptureTimeout)
+	defer captureCancel()
+
 	// 获取标题
-	if err := chromedp.Run(navigationCtx, chromedp.Title(&result.Title)); err != nil {
+	if err := chromedp.Run(captureCtx, chromedp.Title(&result.Title)); err != nil {
 		if run.options.Logging.LogScanErrors {
 			logger.Error("could not get page title", "err", err)
 		}
@@ -398,13 +790,94 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 
 	// 获取 HTML
 	if !run.options.Scan.SkipHTML {
-		if err := chromedp.Run(navigationCtx, chromedp.OuterHTML(":root", &result.HTML, chromedp.ByQueryAll)); err != nil {
+		if err := chromedp.Run(captureCtx, chromedp.OuterHTML(":root", &result.HTML, chromedp.ByQueryAll)); err != nil {
 			if run.options.Logging.LogScanErrors {
 				logger.Error("could not get page html", "err", err)
 			}
 		}
 	}
 
+	// 记录 data: 和 blob: 资源
+	if run.options.Scan.CaptureInlineResources {
+		var resources []inlineResource
+		includeData := run.options.Scan.SaveContent || len(run.options.Scan.SaveContentTypes) > 0
+		if err := chromedp.Run(navigationCtx, chromedp.Evaluate(fmt.Sprintf("(%s)(%t)", inlineResourcesScript(), includeData), &resources,
+			func(p *runtime.EvaluateParams) *runtime.EvaluateParams { return p.WithAwaitPromise(true) })); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not capture inline resources", "err", err)
+			}
+		} else {
+			resultMutex.Lock()
+			result.Network = append(result.Network, inlineNetworkLogs(run.options, resources)...)
+			resultMutex.Unlock()
+		}
+	}
+
+	// 记录 WebSocket 帧
+	result.WebSockets = websockets.websockets()
+
+	// 记录脚本和链接的来源
+	var externals []externalResource
+	if err := chromedp.Run(navigationCtx, chromedp.Evaluate("("+externalResourcesScript()+")()", &externals)); err != nil {
+		if run.options.Logging.LogScanErrors {
+			logger.Error("could not list external resources", "err", err)
+		}
+	} else {
+		result.ExternalResources = externalResourceModels(externals)
+	}
+
+	// 记录资源计时
+	if run.options.Scan.CaptureResourceTimings {
+		if err := chromedp.Run(navigationCtx, chromedp.Evaluate("("+resourceTimingsScript()+")()", &result.ResourceTimings)); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not get resource timings", "err", err)
+			}
+		}
+	}
+
+	// 记录页面语言
+	if run.options.Scan.CaptureLanguage {
+		var language []string
+		if err := chromedp.Run(navigationCtx, chromedp.Evaluate(languageScript(run.options.Scan.DetectLanguage), &language)); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not get page language", "err", err)
+			}
+		} else {
+			result.Language = pageLanguage(language)
+		}
+	}
+
+	// 记录页面复杂度指标
+	if run.options.Scan.PageMetrics {
+		if err := chromedp.Run(navigationCtx, chromedp.Evaluate(domNodesJs, &result.DOMNodes)); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not count dom nodes", "err", err)
+			}
+		}
+		result.TransferredBytes = transferred.Load()
+	}
+
+	// 记录 service worker 和 web 应用清单
+	if run.options.Scan.CapturePWA {
+		workers.waitActivated()
+		workers.apply(result)
+
+		if err := chromedp.Run(navigationCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			result.ManifestURL, _, result.Manifest, _, err = page.GetAppManifest().Do(ctx)
+			return err
+		})); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not get the web app manifest", "err", err)
+			}
+		}
+	}
+
+	// 计算 HTML 的内容哈希
+	if result.HTML != "" {
+		result.HTMLSHA256 = sha256Hex([]byte(result.HTML))
+	}
+
 	// 在第一个响应中识别技术指纹
 	if fingerprints := thisRunner.Wappalyzer.Fingerprint(result.HeaderMap(), []byte(result.HTML)); fingerprints != nil {
 		for tech := range fingerprints {
@@ -414,7 +887,61 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 		}
 	}
 
+	// 禁用 JavaScript 时不截图
+	if run.options.Scan.DisableJavaScript {
+		return result, nil
+	}
+
+	// 识别单页应用
+	var spa spaInfo
+	if err := chromedp.Run(navigationCtx, chromedp.Evaluate("("+spaJs+")()", &spa)); err != nil {
+		if run.options.Logging.LogScanErrors {
+			logger.Error("could not detect single-page app", "err", err)
+		}
+	} else {
+		applySPA(result, spa)
+	}
+
+	// 处理 cookie 同意横幅
+	if run.options.Scan.CookieConsent != "" {
+		if framework := run.handleConsent(navigationCtx); framework != "" {
+			logger.Debug("cookie consent banner handled", "framework", framework)
+			result.ConsentHandled = true
+			result.ConsentFramework = framework
+		}
+	}
+
+	// 分步滚动到底部以触发懒加载内容
+	if run.options.Scan.ScrollToBottom {
+		var steps int
+		if err := chromedp.Run(navigationCtx, chromedp.Evaluate(scrollToBottomScript(), &steps,
+			func(p *runtime.EvaluateParams) *runtime.EvaluateParams { return p.WithAwaitPromise(true) })); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not scroll to the bottom of the page", "err", err)
+			}
+		} else {
+			logger.Debug("scrolled to the bottom of the page", "steps", steps)
+		}
+	}
+
+	// 以打印样式渲染页面
+	if run.options.Scan.EmulatePrintMedia {
+		if err := chromedp.Run(navigationCtx, emulation.SetEmulatedMedia().WithMedia("print")); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not emulate print media", "err", err)
+			}
+		}
+	}
+
+	// 截图按状态码分类时使用的主响应状态码
+	resultMutex.Lock()
+	statusCode := result.ResponseCode
+	resultMutex.Unlock()
+
 	// 获取截图
+	_, screenshotSpan := thisRunner.Tracer.Start(ctx, "screenshot")
+	defer screenshotSpan.End()
+
 	var img []byte
 
 	// 如果指定了选择器，截取特定元素
@@ -433,14 +960,11 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 				}
 
 				// 设置视口高度为元素的完整高度（如果需要的话）
-				fmt.Println("scrollHeight", scrollHeight)
-				fmt.Println("run.options.Chrome.WindowY", run.options.Chrome.WindowY)
 				if run.options.Scan.ScreenshotFullPage && scrollHeight > float64(run.options.Chrome.WindowY) {
-					fmt.Println("scrollHeight", scrollHeight)
 					return emulation.SetDeviceMetricsOverride(
 						int64(run.options.Chrome.WindowX),
 						int64(scrollHeight),
-						2.0,
+						run.options.Chrome.DeviceScaleFactor,
 						false,
 					).Do(ctx)
 				}
@@ -454,6 +978,15 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 			chromedp.Screenshot(run.options.Scan.Selector, &img, chromedp.NodeVisible, chromedp.ByQuery),
 		)
 	} else {
+		// 如果指定了文本，截取文本周围的区域
+		var clip *textClip
+		if run.options.Scan.CaptureAroundText != "" {
+			clip = run.findText(navigationCtx)
+			if clip == nil {
+				logger.Debug("text to capture around not found, capturing the page")
+			}
+		}
+
 		// 原来的全页截图逻辑
 		err = chromedp.Run(navigationCtx,
 			chromedp.ActionFunc(func(ctx context.Context) error {
@@ -462,10 +995,13 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 					WithQuality(80).
 					WithFormat(page.CaptureScreenshotFormat(run.options.Scan.ScreenshotFormat))
 
-				// 如果是全页
-				if run.options.Scan.ScreenshotFullPage {
+				// 如果是全页或文本区域
+				if run.options.Scan.ScreenshotFullPage || clip != nil {
 					params = params.WithCaptureBeyondViewport(true)
 				}
+				if clip != nil {
+					params = params.WithClip(&page.Viewport{X: clip.X, Y: clip.Y, Width: clip.Width, Height: clip.Height, Scale: 1})
+				}
 
 				img, err = params.Do(ctx)
 				return err
@@ -478,26 +1014,19 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 			logger.Error("could not grab screenshot", "err", err)
 		}
 
+		screenshotSpan.SetError(err)
 		result.Failed = true
 		result.FailedReason = err.Error()
 	} else {
 
-		// 给写入器一个截图来处理
-		if run.options.Scan.ScreenshotToWriter {
-			result.Screenshot = base64.StdEncoding.EncodeToString(img)
+		// 交给写入器，并在我们有路径时写入磁盘
+		result.Filename, result.Screenshot, err = storeCapture(run.options, target, statusCode, result.ProbedAt, "", img)
+		if err != nil {
+			return nil, err
 		}
 
-		// 如果我们有路径，将截图写入磁盘
-		if !run.options.Scan.ScreenshotSkipSave {
-			result.Filename = islazy.SafeFileName(target) + "." + run.options.Scan.ScreenshotFormat
-			result.Filename = islazy.LeftTrucate(result.Filename, 200)
-			if err := os.WriteFile(
-				filepath.Join(run.options.Scan.ScreenshotPath, result.Filename),
-				img, os.FileMode(0664),
-			); err != nil {
-				return nil, fmt.Errorf("could not write screenshot to disk: %w", err)
-			}
-		}
+		// 计算截图的内容哈希
+		result.ScreenshotSHA256 = sha256Hex(img)
 
 		// 计算并设置感知哈希
 		decoded, _, err := image.Decode(bytes.NewReader(img))
@@ -512,9 +1041,244 @@ func (run *Chromedp) Witness(target string, thisRunner *runner.Runner) (*models.
 		result.PerceptionHash = hash.ToString()
 	}
 
+	// 在固定间隔后进行连续截图。在滚动截图之前进行，
+	// 使连续截图显示的是页面顶部的视口，和首次截图一致
+	if run.options.Scan.BurstCount > 0 && !result.Failed {
+		captures, err := run.captureBurst(navigationCtx, target, statusCode, result.ProbedAt)
+		if err != nil && run.options.Logging.LogScanErrors {
+			logger.Error("could not capture burst screenshots", "err", err)
+		}
+		result.BurstCaptures = captures
+	}
+
+	// 在配置的滚动位置进行额外截图
+	if len(run.options.Scan.ScrollPositions) > 0 && !result.Failed {
+		captures, err := run.captureScrollPositions(navigationCtx, target, statusCode, result.ProbedAt)
+		if err != nil && run.options.Logging.LogScanErrors {
+			logger.Error("could not capture scroll positions", "err", err)
+		}
+		result.ScrollCaptures = captures
+	}
+
 	return result, nil
 }
 
+// captureScrollPositions 滚动到每个配置的位置并截取视口
+func (run *Chromedp) captureScrollPositions(ctx context.Context, target string, statusCode int, at time.Time) ([]models.ScrollCapture, error) {
+	positions, err := runner.ParseScrollPositions(run.options.Scan.ScrollPositions)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取页面的滚动高度和视口高度
+	var heights []float64
+	if err := chromedp.Run(ctx, chromedp.Evaluate("("+scrollHeightJs+")()", &heights)); err != nil {
+		return nil, err
+	}
+	if len(heights) != 2 {
+		return nil, errors.New("could not determine page height")
+	}
+
+	var captures []models.ScrollCapture
+	for i, position := range positions {
+		offset := position.Offset(heights[0], heights[1])
+
+		if err := chromedp.Run(ctx,
+			chromedp.Evaluate(fmt.Sprintf("window.scrollTo(0, %f)", offset), nil),
+			chromedp.Sleep(scrollSettleTime),
+		); err != nil {
+			return captures, err
+		}
+
+		img, err := run.viewportScreenshot(ctx)
+		if err != nil {
+			return captures, err
+		}
+
+		capture := models.ScrollCapture{Position: position.Raw, Offset: int64(offset)}
+		capture.Filename, capture.Screenshot, err = storeCapture(run.options, target, statusCode, at, fmt.Sprintf("-scroll-%d", i), img)
+		if err != nil {
+			return captures, err
+		}
+
+		captures = append(captures, capture)
+	}
+
+	return captures, nil
+}
+
+// captureBurst 在固定间隔后重复截取视口
+func (run *Chromedp) captureBurst(ctx context.Context, target string, statusCode int, at time.Time) ([]models.BurstCapture, error) {
+	var captures []models.BurstCapture
+	start := time.Now()
+
+	for i := 1; i <= run.options.Scan.BurstCount; i++ {
+		if err := chromedp.Run(ctx, chromedp.Sleep(time.Duration(run.options.Scan.BurstInterval)*time.Millisecond)); err != nil {
+			return captures, err
+		}
+
+		img, err := run.viewportScreenshot(ctx)
+		if err != nil {
+			return captures, err
+		}
+
+		capture := models.BurstCapture{Sequence: i, Elapsed: time.Since(start).Milliseconds()}
+		capture.Filename, capture.Screenshot, err = storeCapture(run.options, target, statusCode, at, fmt.Sprintf("-burst-%d", i), img)
+		if err != nil {
+			return captures, err
+		}
+
+		captures = append(captures, capture)
+	}
+
+	return captures, nil
+}
+
+// navigate 导航到目标，并等待 Scan.WaitUntil 配置的页面生命周期事件。
+// 设置了 Scan.Referer 时，它会作为主导航请求的 referrer 发送。
+func (run *Chromedp) navigate(ctx context.Context, target string) error {
+	event := lifecycleEvent(run.options.Scan.WaitUntil)
+	if event == "load" && run.options.Scan.Referer == "" {
+		// chromedp.Navigate 本身就会等待 load 事件
+		return chromedp.Run(ctx, chromedp.Navigate(target))
+	}
+
+	listenCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fired := make(chan *page.EventLifecycleEvent, 8)
+	chromedp.ListenTarget(listenCtx, func(ev interface{}) {
+		if e, ok := ev.(*page.EventLifecycleEvent); ok && e.Name == event {
+			select {
+			case fired <- e:
+			default:
+			}
+		}
+	})
+
+	return chromedp.Run(ctx, page.SetLifecycleEventsEnabled(true), chromedp.ActionFunc(func(ctx context.Context) error {
+		frameID, loaderID, errorText, err := page.Navigate(target).WithReferrer(run.options.Scan.Referer).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if errorText != "" {
+			return fmt.Errorf("page load error %s", errorText)
+		}
+
+		// 只接受主框架中本次导航的事件
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case e := <-fired:
+				if e.FrameID == frameID && (loaderID == "" || e.LoaderID == loaderID) {
+					return nil
+				}
+			}
+		}
+	}))
+}
+
+// findText 返回 Scan.CaptureAroundText 文本周围要截取的区域，
+// 找不到文本时返回 nil
+func (run *Chromedp) findText(ctx context.Context) *textClip {
+	var clip *textClip
+	script := aroundTextScript(run.options.Scan.CaptureAroundText, run.options.Scan.CaptureAroundTextPadding)
+	if err := chromedp.Run(ctx, chromedp.Evaluate(script, &clip)); err != nil {
+		if run.options.Logging.LogScanErrors {
+			run.log.Error("could not find the text to capture around", "err", err)
+		}
+		return nil
+	}
+
+	return clip
+}
+
+// handleConsent 等待 cookie 同意横幅出现并点击配置的按钮，
+// 返回所处理的同意框架名称，没有找到横幅时返回空字符串。
+func (run *Chromedp) handleConsent(ctx context.Context) string {
+	script := consentScript(run.options.Scan.CookieConsent)
+	deadline := time.Now().Add(consentWait)
+
+	for {
+		var framework string
+		if err := chromedp.Run(ctx, chromedp.Evaluate(script, &framework)); err == nil && framework != "" {
+			// 等待横幅消失
+			chromedp.Run(ctx, chromedp.Sleep(consentSettle))
+			return framework
+		}
+
+		if time.Now().After(deadline) {
+			return ""
+		}
+		if err := chromedp.Run(ctx, chromedp.Sleep(consentPollInterval)); err != nil {
+			return ""
+		}
+	}
+}
+
+// isChallenge 检查当前页面是否是 JavaScript 挑战页面
+func (run *Chromedp) isChallenge(ctx context.Context) bool {
+	var info []string
+	if err := chromedp.Run(ctx, chromedp.Evaluate("("+challengeJs+")()", &info)); err != nil || len(info) != 2 {
+		return false
+	}
+
+	return isChallengePage(info[0], info[1])
+}
+
+// waitForChallenge 等待挑战页面通过，必要时重新导航一次。
+// 返回是否检测到挑战，以及挑战是否已通过。
+func (run *Chromedp) waitForChallenge(ctx context.Context, target string) (detected bool, passed bool) {
+	if !run.isChallenge(ctx) {
+		return false, false
+	}
+
+	wait := time.Duration(run.options.Scan.ChallengeWait) * time.Second
+	for attempt := 0; attempt < 2; attempt++ {
+		// 第二次尝试前重新导航
+		if attempt > 0 {
+			if err := run.navigate(ctx, target); err != nil {
+				return true, false
+			}
+		}
+
+		deadline := time.Now().Add(wait)
+		for time.Now().Before(deadline) {
+			if err := chromedp.Run(ctx, chromedp.Sleep(challengePollInterval)); err != nil {
+				return true, false
+			}
+
+			if !run.isChallenge(ctx) {
+				return true, true
+			}
+		}
+	}
+
+	return true, false
+}
+
+// viewportScreenshot 截取当前视口
+func (run *Chromedp) viewportScreenshot(ctx context.Context) ([]byte, error) {
+	var img []byte
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		img, err = page.CaptureScreenshot().
+			WithQuality(80).
+			WithFormat(page.CaptureScreenshotFormat(run.options.Scan.ScreenshotFormat)).
+			Do(ctx)
+		return err
+	}))
+
+	return img, err
+}
+
 func (run *Chromedp) Close() {
 	run.log.Debug("closing browser allocation context")
+
+	if run.clientCert != nil {
+		if err := run.clientCert.Close(); err != nil {
+			run.log.Error("could not close the client certificate proxy", "err", err)
+		}
+	}
 }
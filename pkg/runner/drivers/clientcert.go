@@ -0,0 +1,335 @@
+package driver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/runner"
+)
+
+// clientCertProxy is a local intercepting proxy that presents a client
+// certificate to upstream servers on behalf of Chrome.
+//
+// Chrome has no command line option to present a client certificate
+// without importing it into the system certificate store. Instead, Chrome
+// is pointed at this proxy, which terminates TLS with a throwaway
+// certificate (Chrome is already configured to ignore certificate errors)
+// and re-originates the connection, presenting the client certificate if
+// a target's host asks for one. Other hosts the page loads never see the
+// certificate.
+//
+// Because Chrome only sees the proxy, the TLS details and remote address
+// Chrome reports are the proxy's. The upstream connection details are kept
+// so the drivers can report those instead.
+type clientCertProxy struct {
+	listener   net.Listener
+	server     *http.Server
+	transport  *http.Transport
+	clientCert tls.Certificate
+	serverCert tls.Certificate
+	log        *slog.Logger
+
+	mu          sync.Mutex
+	presented   map[string]bool
+	targets     map[string]int
+	connections map[string]proxiedConnection
+}
+
+// proxiedConnection is the upstream side of a proxied connection
+type proxiedConnection struct {
+	remoteAddr string
+	state      *tls.ConnectionState
+}
+
+// newClientCertProxy loads the configured client certificate and starts
+// the proxy on a random loopback port.
+func newClientCertProxy(logger *slog.Logger, opts runner.Options) (*clientCertProxy, error) {
+	if opts.Chrome.Proxy != "" {
+		return nil, errors.New("a client certificate cannot be combined with a proxy")
+	}
+	if opts.Chrome.WSS != "" {
+		return nil, errors.New("a client certificate cannot be used with a remote chrome instance")
+	}
+
+	if opts.Chrome.ClientKey == "" {
+		return nil, errors.New("a client certificate requires a client key")
+	}
+
+	clientCert, err := tls.LoadX509KeyPair(opts.Chrome.ClientCert, opts.Chrome.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	serverCert, err := selfSignedCertificate()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &clientCertProxy{
+		listener:    listener,
+		clientCert:  clientCert,
+		serverCert:  serverCert,
+		log:         logger,
+		presented:   make(map[string]bool),
+		targets:     make(map[string]int),
+		connections: make(map[string]proxiedConnection),
+	}
+	p.transport = &http.Transport{
+		DialContext:         p.dial,
+		DialTLSContext:      p.dialTLS,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     30 * time.Second,
+	}
+	p.server = &http.Server{Handler: p}
+
+	go func() {
+		if err := p.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("client certificate proxy stopped", "err", err)
+		}
+	}()
+
+	logger.Debug("client certificate proxy started", "address", listener.Addr().String())
+
+	return p, nil
+}
+
+// URL returns the proxy URL to configure Chrome with
+func (p *clientCertProxy) URL() string {
+	return "http://" + p.listener.Addr().String()
+}
+
+// Presented returns true if the client certificate was requested by,
+// and presented to, host
+func (p *clientCertProxy) Presented(host string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.presented[host]
+}
+
+// Allow permits the client certificate to be presented to host while a
+// target on it is witnessed. Every call must be paired with Release.
+func (p *clientCertProxy) Allow(host string) {
+	p.mu.Lock()
+	p.targets[host]++
+	first := p.targets[host] == 1
+	p.mu.Unlock()
+
+	// pooled connections to the host may have been opened without the
+	// certificate while it was loaded by another target
+	if first {
+		p.transport.CloseIdleConnections()
+	}
+}
+
+// Release undoes a call to Allow
+func (p *clientCertProxy) Release(host string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.targets[host]--
+	if p.targets[host] <= 0 {
+		delete(p.targets, host)
+	}
+}
+
+// Connection returns the TLS details and remote address of the last
+// upstream connection to host. The TLS details are empty for plain http
+// connections. ok is false if the proxy has not connected to host.
+func (p *clientCertProxy) Connection(host string) (details models.TLS, remoteAddr string, ok bool) {
+	p.mu.Lock()
+	conn, ok := p.connections[host]
+	p.mu.Unlock()
+
+	if !ok {
+		return models.TLS{}, "", false
+	}
+
+	if conn.state != nil {
+		details = connectionTLS(*conn.state)
+	}
+
+	return details, conn.remoteAddr, true
+}
+
+// Close stops the proxy
+func (p *clientCertProxy) Close() error {
+	p.transport.CloseIdleConnections()
+	return p.server.Close()
+}
+
+// dial connects to an upstream server over plain http
+func (p *clientCertProxy) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := (&net.Dialer{Timeout: 30 * time.Second}).DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.connections[host] = proxiedConnection{remoteAddr: conn.RemoteAddr().String()}
+	p.mu.Unlock()
+
+	return conn, nil
+}
+
+// dialTLS connects to an upstream server, presenting the client
+// certificate if asked for it by a target's host
+func (p *clientCertProxy) dialTLS(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := (&net.Dialer{Timeout: 30 * time.Second}).DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			// an empty certificate tells the server none is available
+			if p.targets[host] == 0 {
+				return &tls.Certificate{}, nil
+			}
+
+			p.presented[host] = true
+			return &p.clientCert, nil
+		},
+	})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	state := tlsConn.ConnectionState()
+	p.mu.Lock()
+	p.connections[host] = proxiedConnection{remoteAddr: conn.RemoteAddr().String(), state: &state}
+	p.mu.Unlock()
+
+	return tlsConn, nil
+}
+
+// ServeHTTP handles proxy requests from Chrome
+func (p *clientCertProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		// plain http requests are forwarded as is
+		p.reverseProxy("http", r.URL.Host).ServeHTTP(w, r)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		p.log.Debug("could not hijack proxy connection", "err", err)
+		return
+	}
+
+	if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		conn.Close()
+		return
+	}
+
+	// terminate tls from chrome and serve the decrypted requests
+	tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{p.serverCert}})
+	server := &http.Server{Handler: p.reverseProxy("https", r.Host)}
+	_ = server.Serve(&singleConnListener{conn: tlsConn})
+}
+
+// reverseProxy returns a reverse proxy for requests to host
+func (p *clientCertProxy) reverseProxy(scheme, host string) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(&url.URL{Scheme: scheme, Host: host})
+			pr.Out.Host = pr.In.Host
+		},
+		Transport: p.transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			p.log.Debug("client certificate proxy request failed", "host", host, "err", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+}
+
+// singleConnListener is a net.Listener that accepts a single connection
+type singleConnListener struct {
+	conn net.Conn
+	once sync.Once
+}
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.once.Do(func() {
+		conn = l.conn
+	})
+
+	if conn == nil {
+		return nil, io.EOF
+	}
+
+	return conn, nil
+}
+
+func (l *singleConnListener) Close() error { return nil }
+
+func (l *singleConnListener) Addr() net.Addr { return l.conn.LocalAddr() }
+
+// selfSignedCertificate generates a throwaway certificate for the proxy to
+// present to Chrome
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "gowitness client certificate proxy"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
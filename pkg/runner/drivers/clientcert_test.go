@@ -0,0 +1,108 @@
+package driver
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClientCertProxyDialTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, len(r.TLS.PeerCertificates))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := serverURL.Hostname()
+
+	sum := sha256.Sum256(server.Certificate().Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name          string
+		allow         bool
+		wantPresented bool
+		wantPeerCerts string
+	}{
+		{
+			name:          "Test with the target host",
+			allow:         true,
+			wantPresented: true,
+			wantPeerCerts: "1",
+		},
+		{
+			name:          "Test with another host",
+			allow:         false,
+			wantPresented: false,
+			wantPeerCerts: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCert, err := selfSignedCertificate()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := &clientCertProxy{
+				clientCert:  clientCert,
+				log:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+				presented:   make(map[string]bool),
+				targets:     make(map[string]int),
+				connections: make(map[string]proxiedConnection),
+			}
+			p.transport = &http.Transport{DialContext: p.dial, DialTLSContext: p.dialTLS}
+			defer p.transport.CloseIdleConnections()
+
+			if tt.allow {
+				p.Allow(host)
+				defer p.Release(host)
+			}
+
+			resp, err := (&http.Client{Transport: p.transport}).Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(body) != tt.wantPeerCerts {
+				t.Errorf("peer certificates =>\n\nhave: %s\nwant %s", body, tt.wantPeerCerts)
+			}
+
+			if got := p.Presented(host); got != tt.wantPresented {
+				t.Errorf("Presented() =>\n\nhave: %v\nwant %v", got, tt.wantPresented)
+			}
+
+			details, remoteAddr, ok := p.Connection(host)
+			if !ok {
+				t.Fatal("Connection() => no connection recorded")
+			}
+			if remoteAddr != serverURL.Host {
+				t.Errorf("Connection() remote address =>\n\nhave: %s\nwant %s", remoteAddr, serverURL.Host)
+			}
+			if details.FingerprintSHA256 != fingerprint {
+				t.Errorf("Connection() fingerprint =>\n\nhave: %s\nwant %s", details.FingerprintSHA256, fingerprint)
+			}
+			if details.Protocol == "" || details.PEM == "" {
+				t.Errorf("Connection() => missing TLS details: %+v", details)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// consentWait is how long to wait for a cookie consent banner to appear
+const consentWait = 3 * time.Second
+
+// consentPollInterval is how often to check for a cookie consent banner
+const consentPollInterval = 500 * time.Millisecond
+
+// consentSettle is how long to wait after a consent button was clicked for
+// the banner to go away
+const consentSettle = 500 * time.Millisecond
+
+// consentButton is the accept and reject button selector of a consent
+// framework
+type consentButton struct {
+	Framework string
+	Accept    string
+	Reject    string
+}
+
+// consentButtons are the buttons of common cookie consent frameworks
+var consentButtons = []consentButton{
+	{"onetrust", "#onetrust-accept-btn-handler", "#onetrust-reject-all-handler"},
+	{"cookiebot", "#CybotCookiebotDialogBodyLevelButtonLevelOptinAllowAll, #CybotCookiebotDialogBodyButtonAccept", "#CybotCookiebotDialogBodyButtonDecline"},
+	{"usercentrics", "[data-testid='uc-accept-all-button']", "[data-testid='uc-deny-all-button']"},
+	{"didomi", "#didomi-notice-agree-button", "#didomi-notice-disagree-button"},
+	{"quantcast", ".qc-cmp2-summary-buttons button[mode='primary']", ".qc-cmp2-summary-buttons button[mode='secondary']"},
+	{"trustarc", "#truste-consent-button", "#truste-consent-required"},
+	{"osano", ".osano-cm-accept-all", ".osano-cm-denyAll"},
+	{"cookieyes", ".cky-btn-accept", ".cky-btn-reject"},
+	{"complianz", ".cmplz-accept", ".cmplz-deny"},
+	{"cookie-notice", "#cn-accept-cookie", "#cn-refuse-cookie"},
+	{"klaro", ".cm-btn-accept-all", ".cn-decline"},
+}
+
+// consentJs clicks the first visible consent button, also looking into the
+// shadow roots some frameworks render into. It returns the framework name,
+// or an empty string if no banner was found.
+const consentJs = `(buttons) => {
+	const roots = [document];
+	for (const host of document.querySelectorAll("#usercentrics-root, #usercentrics-cmp-ui, #cmpwrapper")) {
+		if (host.shadowRoot) roots.push(host.shadowRoot);
+	}
+
+	for (const button of buttons) {
+		for (const root of roots) {
+			const el = root.querySelector(button.selector);
+			if (el && el.getClientRects().length > 0) {
+				el.click();
+				return button.framework;
+			}
+		}
+	}
+
+	return "";
+}`
+
+// consentSelector is a consent button to click
+type consentSelector struct {
+	Framework string `json:"framework"`
+	Selector  string `json:"selector"`
+}
+
+// consentSelectors returns the buttons to click for a consent action,
+// either accept or reject
+func consentSelectors(action string) []consentSelector {
+	var selectors []consentSelector
+	for _, button := range consentButtons {
+		selector := button.Accept
+		if action == "reject" {
+			selector = button.Reject
+		}
+
+		selectors = append(selectors, consentSelector{Framework: button.Framework, Selector: selector})
+	}
+
+	return selectors
+}
+
+// consentScript returns consentJs as an expression called with the
+// selectors of a consent action
+func consentScript(action string) string {
+	selectors, _ := json.Marshal(consentSelectors(action))
+	return fmt.Sprintf("(%s)(%s)", consentJs, selectors)
+}
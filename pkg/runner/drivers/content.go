@@ -0,0 +1,92 @@
+package driver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/runner"
+)
+
+// shouldSaveContent determines if the body of a response with the given
+// MIME type should be fetched and stored.
+//
+// Content is saved when --save-content is set and no type filter exists, or
+// when the MIME type matches one of the configured content types. A filter
+// value may be an exact type (application/json) or a prefix (text/).
+func shouldSaveContent(opts runner.Options, mimeType string) bool {
+	if len(opts.Scan.SaveContentTypes) == 0 {
+		return opts.Scan.SaveContent
+	}
+
+	// drop any parameters such as ; charset=utf-8
+	mimeType = strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+	if mimeType == "" {
+		return false
+	}
+
+	for _, t := range opts.Scan.SaveContentTypes {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+
+		if mimeType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mimeType, t)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// storeContent returns the content of a network log entry for a response
+// body. The body is returned as is, unless a content path is set. Then it
+// is written to a file in the content path instead, so that bodies do not
+// pile up in memory until the result is written, and only the file name is
+// returned. Files are named by the SHA-256 hash of the body, so identical
+// bodies (e.g. common libraries) are stored once.
+func storeContent(opts runner.Options, body []byte) (content []byte, file string, err error) {
+	if opts.Scan.ContentPath == "" {
+		return body, "", nil
+	}
+
+	file = sha256Hex(body)
+	path := filepath.Join(opts.Scan.ContentPath, file)
+	if _, err := os.Stat(path); err == nil {
+		return nil, file, nil
+	}
+
+	if err := os.MkdirAll(opts.Scan.ContentPath, 0755); err != nil {
+		return nil, "", err
+	}
+
+	// write to a temporary file first, so that a concurrent write of the
+	// same body never leaves a partial file behind
+	tmp, err := os.CreateTemp(opts.Scan.ContentPath, file+".*.tmp")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return nil, "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, "", err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, "", err
+	}
+
+	return nil, file, nil
+}
+
+// sha256Hex returns the hex encoded SHA-256 hash of b
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package driver
+
+import (
+	"fmt"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// maxExternalResources is the maximum number of script and link sources
+// recorded per page
+const maxExternalResources = 500
+
+// externalResourcesJs lists the http(s) sources of <script src> and
+// <link href> elements, with their rel and subresource integrity values
+const externalResourcesJs = `() => {
+	const seen = new Set();
+	const resources = [];
+	const add = (type, el, url) => {
+		if (!url || !/^https?:/i.test(url) || seen.has(type + url) || resources.length >= %d) return;
+		seen.add(type + url);
+		resources.push({
+			type: type,
+			url: url,
+			rel: el.getAttribute('rel') || '',
+			integrity: el.getAttribute('integrity') || '',
+		});
+	};
+
+	for (const el of document.querySelectorAll('script[src]')) add('script', el, el.src);
+	for (const el of document.querySelectorAll('link[href]')) add('link', el, el.href);
+
+	return resources;
+}`
+
+// externalResourcesScript returns externalResourcesJs with its limits
+func externalResourcesScript() string {
+	return fmt.Sprintf(externalResourcesJs, maxExternalResources)
+}
+
+// externalResource is a script or link source found in the DOM
+type externalResource struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Rel       string `json:"rel"`
+	Integrity string `json:"integrity"`
+}
+
+// externalResourceModels converts script and link sources to their models.
+// Hosts and third party flags are set by the runner.
+func externalResourceModels(resources []externalResource) []models.ExternalResource {
+	var results []models.ExternalResource
+	for _, resource := range resources {
+		results = append(results, models.ExternalResource{
+			Type:      resource.Type,
+			URL:       resource.URL,
+			Rel:       resource.Rel,
+			Integrity: resource.Integrity,
+		})
+	}
+
+	return results
+}
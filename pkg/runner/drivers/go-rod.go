@@ -2,14 +2,15 @@ package driver
 
 import (
 	"bytes"
-	"encoding/base64"
+	"context"
+	"errors"
 	"fmt"
 	"image"
 	"log/slog"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/corona10/goimagehash"
@@ -20,6 +21,7 @@ import (
 	"github.com/sensepost/gowitness/pkg/log"
 	"github.com/sensepost/gowitness/pkg/models"
 	"github.com/sensepost/gowitness/pkg/runner"
+	"github.com/sensepost/gowitness/pkg/targetline"
 	"github.com/ysmood/gson"
 )
 
@@ -33,6 +35,10 @@ type Gorod struct {
 	options runner.Options
 	// 日志记录器
 	log *slog.Logger
+	// 出示客户端证书的本地代理（如果配置了）
+	clientCert *clientCertProxy
+	// browserVersion 是浏览器报告的版本字符串
+	browserVersion string
 }
 
 // NewGorod 创建一个准备进行探测的新 Runner。
@@ -44,8 +50,18 @@ func NewGorod(logger *slog.Logger, opts runner.Options) (*Gorod, error) {
 		err      error
 	)
 
+	// 通过本地代理出示客户端证书
+	var clientCert *clientCertProxy
+	if opts.Chrome.ClientCert != "" {
+		clientCert, err = newClientCertProxy(logger, opts)
+		if err != nil {
+			return nil, err
+		}
+		opts.Chrome.Proxy = clientCert.URL()
+	}
+
 	if opts.Chrome.WSS == "" {
-		userData, err = os.MkdirTemp("", "gowitness-v3-gorod-*")
+		userData, err = newUserDataDir("gowitness-v3-gorod-*", opts.Chrome.ProfilePath)
 		if err != nil {
 			return nil, err
 		}
@@ -62,7 +78,9 @@ func NewGorod(logger *slog.Logger, opts runner.Options) (*Gorod, error) {
 			Set("mute-audio").
 			Set("no-default-browser-check").
 			Set("no-first-run").
-			Set("deny-permission-prompts")
+			Set("deny-permission-prompts").
+			Set("window-size", fmt.Sprintf("%d,%d", opts.Chrome.WindowX, opts.Chrome.WindowY)).
+			Set("window-position", fmt.Sprintf("%d,%d", opts.Chrome.WindowPositionX, opts.Chrome.WindowPositionY))
 
 		log.Debug("go-rod chrome args", "args", chrmLauncher.FormatArgs())
 
@@ -76,6 +94,16 @@ func NewGorod(logger *slog.Logger, opts runner.Options) (*Gorod, error) {
 			chrmLauncher.Proxy(opts.Chrome.Proxy)
 		}
 
+		// 不加载图像
+		if opts.Scan.DisableJavaScript {
+			chrmLauncher.Set("blink-settings", "imagesEnabled=false")
+		}
+
+		// 显示滚动条
+		if opts.Chrome.ShowScrollbars {
+			chrmLauncher.Delete("hide-scrollbars")
+		}
+
 		url, err = chrmLauncher.Launch()
 		if err != nil {
 			return nil, err
@@ -97,31 +125,72 @@ func NewGorod(logger *slog.Logger, opts runner.Options) (*Gorod, error) {
 		return nil, err
 	}
 
+	// 授予指定的权限
+	if granted := grantedPermissions(opts); len(granted) > 0 {
+		var permissions []proto.BrowserPermissionType
+		for _, permission := range granted {
+			permissions = append(permissions, proto.BrowserPermissionType(permission))
+		}
+
+		if err := (proto.BrowserGrantPermissions{Permissions: permissions}).Call(browser); err != nil {
+			return nil, fmt.Errorf("could not grant permissions: %w", err)
+		}
+	}
+
+	// 记录浏览器版本，以便结果可以复现
+	version, err := browser.Version()
+	if err != nil {
+		return nil, fmt.Errorf("could not get browser version: %w", err)
+	}
+	logger.Debug("browser version", "version", version.Product)
+
 	return &Gorod{
-		browser:  browser,
-		userData: userData,
-		options:  opts,
-		log:      logger,
+		browser:        browser,
+		userData:       userData,
+		options:        opts,
+		log:            logger,
+		clientCert:     clientCert,
+		browserVersion: version.Product,
 	}, nil
 }
 
 // witness 执行探测 URL 的工作。
 // 就 runner 而言，这是所有工作汇聚的地方。
-func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result, error) {
+func (run *Gorod) Witness(ctx context.Context, target string, thisRunner *runner.Runner) (*models.Result, error) {
 	logger := run.log.With("target", target)
 	logger.Debug("witnessing 👀")
 
+	// 目标可能带有请求方法和请求体
+	request := targetline.Parse(target)
+	target = request.URL
+
+	// 只向目标的主机出示客户端证书
+	if run.clientCert != nil {
+		host := urlHostname(target)
+		run.clientCert.Allow(host)
+		defer run.clientCert.Release(host)
+	}
+
+	// 所有目标共享同一个浏览器，浏览器的代理在启动时就已确定，
+	// 所以目标行中指定的代理无法生效，改用 Chrome.Proxy
+	if request.Proxy != "" {
+		logger.Warn("per-target proxies are not supported by the go-rod driver, using the default proxy")
+	}
+
 	page, err := run.browser.Page(proto.TargetCreateTarget{})
 	if err != nil {
 		return nil, fmt.Errorf("could not get a page: %w", err)
 	}
 	defer page.Close()
 
-	// 配置视口大小
+	// 显式设置视口和屏幕大小，使截图尺寸不受无头模式的影响
 	if run.options.Chrome.WindowX > 0 && run.options.Chrome.WindowY > 0 {
 		if err := page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
-			Width:  run.options.Chrome.WindowX,
-			Height: run.options.Chrome.WindowY,
+			Width:             run.options.Chrome.WindowX,
+			Height:            run.options.Chrome.WindowY,
+			DeviceScaleFactor: run.options.Chrome.DeviceScaleFactor,
+			ScreenWidth:       &run.options.Chrome.WindowX,
+			ScreenHeight:      &run.options.Chrome.WindowY,
 		}); err != nil {
 			return nil, fmt.Errorf("unable to set viewport: %w", err)
 		}
@@ -139,16 +208,14 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 	}
 
 	// 设置额外的头部（如果有）
-	if len(run.options.Chrome.Headers) > 0 {
+	extra, invalid := extraHeaders(run.options)
+	for _, header := range invalid {
+		logger.Warn("custom header did not parse correctly", "header", header)
+	}
+	if len(extra) > 0 {
 		var headers []string
-		for _, header := range run.options.Chrome.Headers {
-			kv := strings.SplitN(header, ":", 2)
-			if len(kv) != 2 {
-				logger.Warn("custom header did not parse correctly", "header", header)
-				continue
-			}
-
-			headers = append(headers, strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+		for k, v := range extra {
+			headers = append(headers, k, v)
 		}
 		_, err := page.SetExtraHeaders(headers)
 		if err != nil {
@@ -156,21 +223,104 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 		}
 	}
 
+	// 模拟地理位置
+	if run.options.Chrome.Geolocation != "" {
+		geolocation, err := runner.ParseGeolocation(run.options.Chrome.Geolocation)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := (proto.EmulationSetGeolocationOverride{
+			Latitude:  gson.Num(geolocation.Latitude),
+			Longitude: gson.Num(geolocation.Longitude),
+			Accuracy:  gson.Num(geolocation.Accuracy),
+		}).Call(page); err != nil {
+			return nil, fmt.Errorf("could not set geolocation override: %w", err)
+		}
+	}
+
+	// 禁用 JavaScript 执行
+	if run.options.Scan.DisableJavaScript {
+		if err := (proto.EmulationSetScriptExecutionDisabled{Value: true}).Call(page); err != nil {
+			return nil, fmt.Errorf("could not disable javascript: %w", err)
+		}
+	}
+
+	// 在任何页面脚本之前执行的 JavaScript
+	if run.options.Scan.JavaScriptOnNewDocument != "" {
+		if _, err := page.EvalOnNewDocument(run.options.Scan.JavaScriptOnNewDocument); err != nil {
+			return nil, fmt.Errorf("could not add javascript to evaluate on new documents: %w", err)
+		}
+	}
+
+	// 扩大资源计时缓冲区，以记录页面的所有资源
+	if run.options.Scan.CaptureResourceTimings {
+		if _, err := page.EvalOnNewDocument(resourceTimingBufferScript()); err != nil {
+			return nil, fmt.Errorf("could not raise the resource timing buffer size: %w", err)
+		}
+	}
+
+	// 如果不是普通的 GET 导航，拦截主文档请求以改写方法和请求体
+	if !request.IsPlain() {
+		if err := (proto.FetchEnable{
+			Patterns: []*proto.FetchRequestPattern{
+				{URLPattern: "*", ResourceType: proto.NetworkResourceTypeDocument},
+			},
+		}).Call(page); err != nil {
+			return nil, fmt.Errorf("could not enable request interception: %w", err)
+		}
+	}
+
 	// 使用页面事件来获取有关目标的信息。这是我们
 	// 了解第一个请求结果的方式，以便将其保存为
 	// 输出写入器的整体 URL 结果。
 	var (
 		first  *proto.NetworkRequestWillBeSent
 		result = &models.Result{
-			URL:      target,
-			ProbedAt: time.Now(),
+			URL:            target,
+			Method:         request.Method,
+			ProbedAt:       time.Now(),
+			BrowserVersion: run.browserVersion,
+			Referer:        run.options.Scan.Referer,
 		}
 		resultMutex   = sync.Mutex{}
 		netlog        = make(map[string]models.NetworkLog)
+		receipts      = make(map[string]responseReceipt)
+		rewritten     atomic.Bool
+		transferred   atomic.Int64
+		idle          = newIdleWatchdog(run.options.Scan.IdleTimeout)
+		websockets    = newWebsocketRecorder(run.options)
 		dismissEvents = false // 设置为 true 以停止 EachEvent 回调
 	)
 
 	go page.EachEvent(
+		// 改写第一个被拦截的文档请求，其余请求原样放行
+		func(e *proto.FetchRequestPaused) bool {
+			go func() {
+				p := proto.FetchContinueRequest{RequestID: e.RequestID}
+
+				if rewritten.CompareAndSwap(false, true) {
+					p.Method = request.Method
+					if request.Body != "" {
+						p.Headers = []*proto.FetchHeaderEntry{{Name: "Content-Type", Value: request.ContentType()}}
+						for k, v := range e.Request.Headers {
+							if strings.EqualFold(k, "content-type") {
+								continue
+							}
+							p.Headers = append(p.Headers, &proto.FetchHeaderEntry{Name: k, Value: v.Str()})
+						}
+						p.PostData = []byte(request.Body)
+					}
+				}
+
+				if err := p.Call(page); err != nil {
+					logger.Error("could not continue intercepted request", "err", err)
+				}
+			}()
+
+			return dismissEvents
+		},
+
 		// 关闭任何 JavaScript 对话框
 		func(e *proto.PageJavascriptDialogOpening) bool {
 			_ = proto.PageHandleJavaScriptDialog{Accept: true}.Call(page)
@@ -203,24 +353,43 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 		// 网络相关事件
 		// 将请求写入网络请求映射
 		func(e *proto.NetworkRequestWillBeSent) bool {
+			idle.touch()
+
 			// 记录第一个请求的请求 ID。我们稍后会回到
 			// 这里来提取有关探测的信息。
 			if first == nil {
 				first = e
 			}
 
+			// 重定向时主请求以相同的请求 ID 再次发送
+			if e.RedirectResponse != nil && first.RequestID == e.RequestID {
+				resultMutex.Lock()
+				result.Redirects = append(result.Redirects, models.Redirect{
+					URL:          e.RedirectResponse.URL,
+					Location:     e.Request.URL,
+					ResponseCode: e.RedirectResponse.Status,
+				})
+				resultMutex.Unlock()
+			}
+
 			// 记录新请求
-			netlog[string(e.RequestID)] = models.NetworkLog{
+			entry := models.NetworkLog{
 				Time:        e.WallTime.Time(),
 				RequestType: models.HTTP,
 				URL:         e.Request.URL,
 			}
+			if run.options.Scan.SaveNetworkHeaders {
+				entry.Headers = networkHeaders("request", e.Request.Headers, gson.JSON.Str)
+			}
+			netlog[string(e.RequestID)] = entry
 
 			return dismissEvents
 		},
 
 		// 将响应写入网络请求映射
 		func(e *proto.NetworkResponseReceived) bool {
+			idle.touch()
+
 			// 获取现有的 requestid，并添加响应信息
 			if entry, ok := netlog[string(e.RequestID)]; ok {
 				// 更新第一个请求的详情（头部、TLS 等）
@@ -231,6 +400,9 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 					result.ResponseReason = e.Response.StatusText
 					result.Protocol = e.Response.Protocol
 					result.ContentLength = int64(e.Response.EncodedDataLength)
+					if e.Response.RemotePort != nil {
+						result.RemoteAddr = remoteAddr(e.Response.RemoteIPAddress, *e.Response.RemotePort)
+					}
 
 					// 写入头部
 					for k, v := range e.Response.Headers {
@@ -269,16 +441,34 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 				entry.URL = e.Response.URL
 				entry.RemoteIP = e.Response.RemoteIPAddress
 				entry.MIMEType = e.Response.MIMEType
+				entry.Protocol = e.Response.Protocol
+				entry.Pushed = e.Response.Timing != nil && e.Response.Timing.PushStart > 0
+				entry.Trailers = announcedTrailers(e.Response.Headers, gson.JSON.Str)
 				entry.Time = e.Response.ResponseTime.Time()
+				var timing networkTiming
+				if t := e.Response.Timing; t != nil {
+					timing = networkTiming{
+						RequestTime: t.RequestTime, DNSStart: t.DNSStart, DNSEnd: t.DNSEnd,
+						ConnectStart: t.ConnectStart, ConnectEnd: t.ConnectEnd, SSLStart: t.SslStart, SSLEnd: t.SslEnd,
+						SendStart: t.SendStart, SendEnd: t.SendEnd, ReceiveHeadersEnd: t.ReceiveHeadersEnd,
+					}
+					timing.apply(&entry)
+				}
+				if run.options.Scan.SaveNetworkHeaders {
+					entry.Headers = append(entry.Headers, networkHeaders("response", e.Response.Headers, gson.JSON.Str)...)
+				}
 
 				// 写入网络日志
 				resultMutex.Lock()
 				entryIndex := len(result.Network)
 				result.Network = append(result.Network, entry)
 				resultMutex.Unlock()
+				if e.Response.Timing != nil {
+					receipts[string(e.RequestID)] = responseReceipt{index: entryIndex, headersReceived: timing.headersReceived()}
+				}
 
 				// 如果我们需要写入响应体，就这样做
-				if run.options.Scan.SaveContent {
+				if shouldSaveContent(run.options, e.Response.MIMEType) {
 					go func(index int) {
 						body, err := proto.NetworkGetResponseBody{RequestID: e.RequestID}.Call(page)
 						if err != nil {
@@ -290,8 +480,15 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 							}
 						}
 
+						content, file, err := storeContent(run.options, []byte(body.Body))
+						if err != nil {
+							run.log.Error("could not store network request response body", "url", e.Response.URL, "err", err)
+							return
+						}
+
 						resultMutex.Lock()
-						result.Network[index].Content = []byte(body.Body)
+						result.Network[index].Content = content
+						result.Network[index].ContentFile = file
 						resultMutex.Unlock()
 					}(entryIndex)
 				}
@@ -300,6 +497,25 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 			return dismissEvents
 		},
 
+		// 收到数据表示连接没有空闲
+		func(e *proto.NetworkDataReceived) bool {
+			idle.touch()
+			return dismissEvents
+		},
+		// 统计传输的字节数
+		func(e *proto.NetworkLoadingFinished) bool {
+			transferred.Add(int64(e.EncodedDataLength))
+
+			// 记录响应体的下载时间
+			if receipt, ok := receipts[string(e.RequestID)]; ok {
+				resultMutex.Lock()
+				result.Network[receipt.index].ReceiveTime = receipt.receiveTime(float64(e.Timestamp))
+				resultMutex.Unlock()
+			}
+
+			return dismissEvents
+		},
+
 		// 将请求标记为失败
 		func(e *proto.NetworkLoadingFailed) bool {
 			// 获取现有的 requestid 并添加失败信息
@@ -310,6 +526,7 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 				if first != nil && first.RequestID == e.RequestID {
 					result.Failed = true
 					result.FailedReason = e.ErrorText
+					result.NetError = netError(e.ErrorText)
 				} else {
 					entry.Error = e.ErrorText
 
@@ -323,24 +540,138 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 			return dismissEvents
 		},
 
-		// TODO: wss
+		// 记录 WebSocket 连接和文本帧
+		func(e *proto.NetworkWebSocketCreated) bool {
+			websockets.created(string(e.RequestID), e.URL)
+			return dismissEvents
+		},
+		func(e *proto.NetworkWebSocketFrameSent) bool {
+			idle.touch()
+			if e.Response != nil {
+				websockets.frame(string(e.RequestID), "sent", int(e.Response.Opcode), e.Response.PayloadData)
+			}
+			return dismissEvents
+		},
+		func(e *proto.NetworkWebSocketFrameReceived) bool {
+			idle.touch()
+			if e.Response != nil {
+				websockets.frame(string(e.RequestID), "received", int(e.Response.Opcode), e.Response.PayloadData)
+			}
+			return dismissEvents
+		},
 	)()
 
+	// 跟踪 service worker。EachEvent 会启用 ServiceWorker 域。
+	workers := newServiceWorkerRecorder(run.options)
+	if run.options.Scan.CapturePWA {
+		go page.EachEvent(
+			func(e *proto.ServiceWorkerWorkerRegistrationUpdated) bool {
+				for _, registration := range e.Registrations {
+					workers.registration(string(registration.RegistrationID), registration.ScopeURL, registration.IsDeleted)
+				}
+				return dismissEvents
+			},
+			func(e *proto.ServiceWorkerWorkerVersionUpdated) bool {
+				for _, version := range e.Versions {
+					workers.version(version.VersionID, string(version.RegistrationID), version.ScriptURL, string(version.Status))
+				}
+				return dismissEvents
+			},
+		)()
+	}
+
+	// 模拟慢速网络。EachEvent 已经启用了 Network 域。
+	if run.options.Scan.NetworkThrottle != "" {
+		throttle, err := runner.ParseNetworkThrottle(run.options.Scan.NetworkThrottle)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := (proto.NetworkEmulateNetworkConditions{
+			Offline:            throttle.Offline,
+			Latency:            throttle.Latency,
+			DownloadThroughput: throttle.DownloadThroughput,
+			UploadThroughput:   throttle.UploadThroughput,
+		}).Call(page); err != nil {
+			return nil, fmt.Errorf("could not emulate network conditions: %w", err)
+		}
+	}
+
 	// 最后，导航到目标
-	if err := page.Navigate(target); err != nil {
+	// 空闲超时只在导航期间生效
+	_, navigateSpan := thisRunner.Tracer.Start(ctx, "navigate")
+	loadCtx, loadCancel := idle.watch(page.GetContext())
+	err = run.navigate(page.Context(loadCtx), target)
+	loadCancel()
+	if idleErr := idle.err(); idleErr != nil {
+		navigateSpan.SetError(idleErr)
+		navigateSpan.End()
+		return nil, fmt.Errorf("could not navigate to target: %w", idleErr)
+	}
+	if err != nil {
+		navigateSpan.SetError(err)
+		navigateSpan.End()
 		return nil, fmt.Errorf("could not navigate to target: %s", err)
 	}
+	navigateSpan.End()
 
 	// 等待配置的延追
 	if run.options.Scan.Delay > 0 {
 		time.Sleep(time.Duration(run.options.Scan.Delay) * time.Second)
 	}
 
+	// 等待动画帧，直到页面在视觉上稳定
+	if run.options.Scan.WaitAnimationFrames > 0 && !run.options.Scan.DisableJavaScript {
+		res, err := page.Eval(animationFramesJs, run.options.Scan.WaitAnimationFrames,
+			animationFrameStableFrames, animationFrameWaitLimit)
+		if err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not wait for animation frames", "err", err)
+			}
+		} else {
+			logger.Debug("waited for animation frames", "frames", res.Value.Int())
+		}
+	}
+
 	// 运行我们有的任何 JavaScript
 	if run.options.Scan.JavaScript != "" {
-		_, err := page.Eval(run.options.Scan.JavaScript)
+		_, javascriptSpan := thisRunner.Tracer.Start(ctx, "javascript")
+		res, err := page.Eval(run.options.Scan.JavaScript)
 		if err != nil {
+			javascriptSpan.SetError(err)
 			logger.Warn("failed to evaluate user-provided javascript", "err", err)
+		} else if run.options.Scan.JavaScriptReturn && res.Type != proto.RuntimeRemoteObjectTypeUndefined {
+			// 将函数的返回值以 JSON 记录
+			result.JavaScriptReturn = res.Value.JSON("", "")
+		}
+		javascriptSpan.End()
+	}
+
+	// 等待 JavaScript 挑战页面通过
+	if run.options.Scan.ChallengeWait > 0 {
+		result.ChallengeDetected, result.ChallengePassed = run.waitForChallenge(page, target)
+		if result.ChallengeDetected {
+			logger.Debug("javascript challenge detected", "passed", result.ChallengePassed)
+		}
+	}
+
+	// 记录是否出示了客户端证书。Chrome 只连接到本地代理，它报告的
+	// TLS 详情和远程地址都属于代理，所以改用代理到目标的上游连接
+	if run.clientCert != nil {
+		host := urlHostname(result.FinalURL)
+		result.ClientCertPresented = run.clientCert.Presented(host)
+		result.TLS, result.RemoteAddr, _ = run.clientCert.Connection(host)
+	} else if result.TLS.Protocol != "" {
+		// 获取叶证书指纹，用于关联共享证书的结果，以及证书链
+		certificate, err := proto.NetworkGetCertificate{Origin: urlOrigin(result.FinalURL)}.Call(page)
+		if err == nil {
+			result.TLS.FingerprintSHA256, err = certificateFingerprint(certificate.TableNames)
+		}
+		if err == nil {
+			result.TLS.PEM, err = certificateChainPEM(certificate.TableNames)
+		}
+		if err != nil && run.options.Logging.LogScanErrors {
+			logger.Error("could not get certificate fingerprint", "err", err)
 		}
 	}
 
@@ -363,6 +694,7 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 				Secure:       cookie.Secure,
 				Session:      cookie.Session,
 				Priority:     string(cookie.Priority),
+				SameSite:     string(cookie.SameSite),
 				SourceScheme: string(cookie.SourceScheme),
 				SourcePort:   int64(cookie.SourcePort),
 			})
@@ -390,11 +722,111 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 		}
 	}
 
+	// 记录页面语言
+	if run.options.Scan.CaptureLanguage {
+		var language []string
+		res, err := page.Eval("() => " + languageScript(run.options.Scan.DetectLanguage))
+		if err == nil {
+			err = res.Value.Unmarshal(&language)
+		}
+		if err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not get page language", "err", err)
+			}
+		} else {
+			result.Language = pageLanguage(language)
+		}
+	}
+
+	// 记录页面复杂度指标
+	if run.options.Scan.PageMetrics {
+		res, err := page.Eval("() => " + domNodesJs)
+		if err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not count dom nodes", "err", err)
+			}
+		} else {
+			result.DOMNodes = int64(res.Value.Int())
+		}
+		result.TransferredBytes = transferred.Load()
+	}
+
+	// 记录 service worker 和 web 应用清单，service worker 事件需要在
+	// 停止事件处理程序之前等待
+	if run.options.Scan.CapturePWA {
+		workers.waitActivated()
+		workers.apply(result)
+
+		manifest, err := proto.PageGetAppManifest{}.Call(page)
+		if err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not get the web app manifest", "err", err)
+			}
+		} else {
+			result.ManifestURL = manifest.URL
+			result.Manifest = manifest.Data
+		}
+	}
+
 	// 停止事件处理程序
 	dismissEvents = true
 
+	// 记录 WebSocket 帧
+	result.WebSockets = websockets.websockets()
+
+	// 记录 data: 和 blob: 资源
+	if run.options.Scan.CaptureInlineResources {
+		includeData := run.options.Scan.SaveContent || len(run.options.Scan.SaveContentTypes) > 0
+		var resources []inlineResource
+		res, err := page.Eval(inlineResourcesScript(), includeData)
+		if err == nil {
+			err = res.Value.Unmarshal(&resources)
+		}
+		if err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not capture inline resources", "err", err)
+			}
+		} else {
+			resultMutex.Lock()
+			result.Network = append(result.Network, inlineNetworkLogs(run.options, resources)...)
+			resultMutex.Unlock()
+		}
+	}
+
+	// 记录脚本和链接的来源
+	var externals []externalResource
+	res, err := page.Eval(externalResourcesScript())
+	if err == nil {
+		err = res.Value.Unmarshal(&externals)
+	}
+	if err != nil {
+		if run.options.Logging.LogScanErrors {
+			logger.Error("could not list external resources", "err", err)
+		}
+	} else {
+		result.ExternalResources = externalResourceModels(externals)
+	}
+
+	// 记录资源计时
+	if run.options.Scan.CaptureResourceTimings {
+		res, err := page.Eval(resourceTimingsScript())
+		if err == nil {
+			err = res.Value.Unmarshal(&result.ResourceTimings)
+		}
+		if err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not get resource timings", "err", err)
+			}
+		}
+	}
+
+	// 计算 HTML 的内容哈希
+	if result.HTML != "" {
+		result.HTMLSHA256 = sha256Hex([]byte(result.HTML))
+	}
+
 	// 在第一个响应中识别技术指纹
-	if fingerprints := runner.Wappalyzer.Fingerprint(result.HeaderMap(), []byte(result.HTML)); fingerprints != nil {
+	if fingerprints := thisRunner.Wappalyzer.Fingerprint(result.HeaderMap(), []byte(result.HTML)); fingerprints != nil {
 		for tech := range fingerprints {
 			result.Technologies = append(result.Technologies, models.Technology{
 				Value: tech,
@@ -402,46 +834,97 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 		}
 	}
 
+	// 禁用 JavaScript 时不截图
+	if run.options.Scan.DisableJavaScript {
+		return result, nil
+	}
+
+	// 识别单页应用
+	var spa spaInfo
+	if res, err := page.Eval(spaJs); err != nil {
+		if run.options.Logging.LogScanErrors {
+			logger.Error("could not detect single-page app", "err", err)
+		}
+	} else if err := res.Value.Unmarshal(&spa); err == nil {
+		applySPA(result, spa)
+	}
+
+	// 处理 cookie 同意横幅
+	if run.options.Scan.CookieConsent != "" {
+		if framework := run.handleConsent(page); framework != "" {
+			logger.Debug("cookie consent banner handled", "framework", framework)
+			result.ConsentHandled = true
+			result.ConsentFramework = framework
+		}
+	}
+
+	// 分步滚动到底部以触发懒加载内容
+	if run.options.Scan.ScrollToBottom {
+		res, err := page.Eval(scrollToBottomJs, scrollToBottomMaxSteps, scrollToBottomStepDelay,
+			scrollToBottomIdleTime, scrollToBottomIdleLimit)
+		if err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not scroll to the bottom of the page", "err", err)
+			}
+		} else {
+			logger.Debug("scrolled to the bottom of the page", "steps", res.Value.Int())
+		}
+	}
+
+	// 以打印样式渲染页面
+	if run.options.Scan.EmulatePrintMedia {
+		if err := (proto.EmulationSetEmulatedMedia{Media: "print"}).Call(page); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not emulate print media", "err", err)
+			}
+		}
+	}
+
+	// 截图按状态码分类时使用的主响应状态码
+	resultMutex.Lock()
+	statusCode := result.ResponseCode
+	resultMutex.Unlock()
+
 	// 进行截图。能到这里通常意味着页面已响应且我们有
 	// 一些信息。但有时，我不确定为什么，page.Screenshot()
 	// 会因为超时而失败。在这种情况下，至少记录我们所拥有的，但将
 	// 截图标记为失败。这样我们至少不会失去所有的工作。
 	logger.Debug("taking a screenshot 🔎")
-	var screenshotOptions = &proto.PageCaptureScreenshot{}
-	switch run.options.Scan.ScreenshotFormat {
-	case "jpeg":
-		screenshotOptions.Format = proto.PageCaptureScreenshotFormatJpeg
-		screenshotOptions.Quality = gson.Int(80)
-	case "png":
-		screenshotOptions.Format = proto.PageCaptureScreenshotFormatPng
+	_, screenshotSpan := thisRunner.Tracer.Start(ctx, "screenshot")
+	defer screenshotSpan.End()
+
+	fullPage, screenshotOptions := run.options.Scan.ScreenshotFullPage, run.screenshotOptions()
+
+	// 如果指定了文本，截取文本周围的区域
+	if run.options.Scan.CaptureAroundText != "" {
+		if clip := run.findText(page); clip != nil {
+			fullPage = false
+			screenshotOptions.CaptureBeyondViewport = true
+			screenshotOptions.Clip = &proto.PageViewport{X: clip.X, Y: clip.Y, Width: clip.Width, Height: clip.Height, Scale: 1}
+		} else {
+			logger.Debug("text to capture around not found, capturing the page")
+		}
 	}
 
-	img, err := page.Screenshot(run.options.Scan.ScreenshotFullPage, screenshotOptions)
+	img, err := page.Screenshot(fullPage, screenshotOptions)
 	if err != nil {
 		if run.options.Logging.LogScanErrors {
 			logger.Error("could not grab screenshot", "err", err)
 		}
 
+		screenshotSpan.SetError(err)
 		result.Failed = true
 		result.FailedReason = err.Error()
 	} else {
-		// 给写入器一个截图来处理
-		if run.options.Scan.ScreenshotToWriter {
-			result.Screenshot = base64.StdEncoding.EncodeToString(img)
-		}
-
-		// 如果我们有路径，将截图写入磁盘
-		if !run.options.Scan.ScreenshotSkipSave {
-			result.Filename = islazy.SafeFileName(target) + "." + run.options.Scan.ScreenshotFormat
-			result.Filename = islazy.LeftTrucate(result.Filename, 200)
-			if err := os.WriteFile(
-				filepath.Join(run.options.Scan.ScreenshotPath, result.Filename),
-				img, os.FileMode(0664),
-			); err != nil {
-				return nil, fmt.Errorf("could not write screenshot to disk: %w", err)
-			}
+		// 交给写入器，并在我们有路径时写入磁盘
+		result.Filename, result.Screenshot, err = storeCapture(run.options, target, statusCode, result.ProbedAt, "", img)
+		if err != nil {
+			return nil, err
 		}
 
+		// 计算截图的内容哈希
+		result.ScreenshotSHA256 = sha256Hex(img)
+
 		// 计算并设置感知哈希
 		decoded, _, err := image.Decode(bytes.NewReader(img))
 		if err != nil {
@@ -455,14 +938,229 @@ func (run *Gorod) Witness(target string, runner *runner.Runner) (*models.Result,
 		result.PerceptionHash = hash.ToString()
 	}
 
+	// 在固定间隔后进行连续截图。在滚动截图之前进行，
+	// 使连续截图显示的是页面顶部的视口，和首次截图一致
+	if run.options.Scan.BurstCount > 0 && !result.Failed {
+		captures, err := run.captureBurst(page, target, statusCode, result.ProbedAt)
+		if err != nil && run.options.Logging.LogScanErrors {
+			logger.Error("could not capture burst screenshots", "err", err)
+		}
+		result.BurstCaptures = captures
+	}
+
+	// 在配置的滚动位置进行额外截图
+	if len(run.options.Scan.ScrollPositions) > 0 && !result.Failed {
+		captures, err := run.captureScrollPositions(page, target, statusCode, result.ProbedAt)
+		if err != nil && run.options.Logging.LogScanErrors {
+			logger.Error("could not capture scroll positions", "err", err)
+		}
+		result.ScrollCaptures = captures
+	}
+
 	return result, nil
 }
 
+// captureScrollPositions 滚动到每个配置的位置并截取视口
+func (run *Gorod) captureScrollPositions(page *rod.Page, target string, statusCode int, at time.Time) ([]models.ScrollCapture, error) {
+	positions, err := runner.ParseScrollPositions(run.options.Scan.ScrollPositions)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取页面的滚动高度和视口高度
+	heights, err := page.Eval(scrollHeightJs)
+	if err != nil {
+		return nil, err
+	}
+	values := heights.Value.Arr()
+	if len(values) != 2 {
+		return nil, errors.New("could not determine page height")
+	}
+	scrollHeight, viewportHeight := values[0].Num(), values[1].Num()
+
+	var captures []models.ScrollCapture
+	for i, position := range positions {
+		offset := position.Offset(scrollHeight, viewportHeight)
+
+		if _, err := page.Eval(`(y) => window.scrollTo(0, y)`, offset); err != nil {
+			return captures, err
+		}
+		time.Sleep(scrollSettleTime)
+
+		img, err := page.Screenshot(false, run.screenshotOptions())
+		if err != nil {
+			return captures, err
+		}
+
+		capture := models.ScrollCapture{Position: position.Raw, Offset: int64(offset)}
+		capture.Filename, capture.Screenshot, err = storeCapture(run.options, target, statusCode, at, fmt.Sprintf("-scroll-%d", i), img)
+		if err != nil {
+			return captures, err
+		}
+
+		captures = append(captures, capture)
+	}
+
+	return captures, nil
+}
+
+// captureBurst 在固定间隔后重复截取视口
+func (run *Gorod) captureBurst(page *rod.Page, target string, statusCode int, at time.Time) ([]models.BurstCapture, error) {
+	var captures []models.BurstCapture
+	start := time.Now()
+
+	for i := 1; i <= run.options.Scan.BurstCount; i++ {
+		time.Sleep(time.Duration(run.options.Scan.BurstInterval) * time.Millisecond)
+
+		img, err := page.Screenshot(false, run.screenshotOptions())
+		if err != nil {
+			return captures, err
+		}
+
+		capture := models.BurstCapture{Sequence: i, Elapsed: time.Since(start).Milliseconds()}
+		capture.Filename, capture.Screenshot, err = storeCapture(run.options, target, statusCode, at, fmt.Sprintf("-burst-%d", i), img)
+		if err != nil {
+			return captures, err
+		}
+
+		captures = append(captures, capture)
+	}
+
+	return captures, nil
+}
+
+// navigate 导航到目标，并等待 Scan.WaitUntil 配置的页面生命周期事件。
+// 页面的超时同样适用于等待过程。设置了 Scan.Referer 时，它会作为主导航
+// 请求的 referrer 发送。
+func (run *Gorod) navigate(page *rod.Page, target string) error {
+	wait := page.WaitNavigation(proto.PageLifecycleEventName(lifecycleEvent(run.options.Scan.WaitUntil)))
+	if run.options.Scan.Referer == "" {
+		if err := page.Navigate(target); err != nil {
+			return err
+		}
+	} else {
+		// page.Navigate 不支持 referrer，所以直接发送 Page.navigate
+		res, err := proto.PageNavigate{URL: target, Referrer: run.options.Scan.Referer}.Call(page)
+		if err != nil {
+			return err
+		}
+		if res.ErrorText != "" {
+			return &rod.NavigationError{Reason: res.ErrorText}
+		}
+	}
+	wait()
+
+	return nil
+}
+
+// findText 返回 Scan.CaptureAroundText 文本周围要截取的区域，
+// 找不到文本时返回 nil
+func (run *Gorod) findText(page *rod.Page) *textClip {
+	var clip *textClip
+	res, err := page.Eval("() => " + aroundTextScript(run.options.Scan.CaptureAroundText, run.options.Scan.CaptureAroundTextPadding))
+	if err == nil {
+		err = res.Value.Unmarshal(&clip)
+	}
+	if err != nil {
+		if run.options.Logging.LogScanErrors {
+			run.log.Error("could not find the text to capture around", "err", err)
+		}
+		return nil
+	}
+
+	return clip
+}
+
+// handleConsent 等待 cookie 同意横幅出现并点击配置的按钮，
+// 返回所处理的同意框架名称，没有找到横幅时返回空字符串。
+func (run *Gorod) handleConsent(page *rod.Page) string {
+	selectors := consentSelectors(run.options.Scan.CookieConsent)
+	deadline := time.Now().Add(consentWait)
+
+	for {
+		res, err := page.Eval(consentJs, selectors)
+		if err == nil && res.Value.Str() != "" {
+			// 等待横幅消失
+			time.Sleep(consentSettle)
+			return res.Value.Str()
+		}
+
+		if time.Now().After(deadline) {
+			return ""
+		}
+		time.Sleep(consentPollInterval)
+	}
+}
+
+// isChallenge 检查当前页面是否是 JavaScript 挑战页面
+func (run *Gorod) isChallenge(page *rod.Page) bool {
+	res, err := page.Eval(challengeJs)
+	if err != nil {
+		return false
+	}
+
+	values := res.Value.Arr()
+	if len(values) != 2 {
+		return false
+	}
+
+	return isChallengePage(values[0].Str(), values[1].Str())
+}
+
+// waitForChallenge 等待挑战页面通过，必要时重新导航一次。
+// 返回是否检测到挑战，以及挑战是否已通过。
+func (run *Gorod) waitForChallenge(page *rod.Page, target string) (detected bool, passed bool) {
+	if !run.isChallenge(page) {
+		return false, false
+	}
+
+	wait := time.Duration(run.options.Scan.ChallengeWait) * time.Second
+	for attempt := 0; attempt < 2; attempt++ {
+		// 第二次尝试前重新导航
+		if attempt > 0 {
+			if err := run.navigate(page, target); err != nil {
+				return true, false
+			}
+		}
+
+		deadline := time.Now().Add(wait)
+		for time.Now().Before(deadline) {
+			time.Sleep(challengePollInterval)
+
+			if !run.isChallenge(page) {
+				return true, true
+			}
+		}
+	}
+
+	return true, false
+}
+
+// screenshotOptions 返回配置的截图格式参数
+func (run *Gorod) screenshotOptions() *proto.PageCaptureScreenshot {
+	var screenshotOptions = &proto.PageCaptureScreenshot{}
+	switch run.options.Scan.ScreenshotFormat {
+	case "jpeg":
+		screenshotOptions.Format = proto.PageCaptureScreenshotFormatJpeg
+		screenshotOptions.Quality = gson.Int(80)
+	case "png":
+		screenshotOptions.Format = proto.PageCaptureScreenshotFormatPng
+	}
+
+	return screenshotOptions
+}
+
 // Close 清理 Browser 运行器。调用者需要
 // 关闭 Targets 通道
 func (run *Gorod) Close() {
 	run.log.Debug("closing the browser instance")
 
+	if run.clientCert != nil {
+		if err := run.clientCert.Close(); err != nil {
+			run.log.Error("could not close the client certificate proxy", "err", err)
+		}
+	}
+
 	if err := run.browser.Close(); err != nil {
 		log.Error("could not close the browser", "err", err)
 		return
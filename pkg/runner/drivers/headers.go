@@ -0,0 +1,40 @@
+package driver
+
+import (
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/runner"
+)
+
+// extraHeaders parses the configured extra request headers. The scanner
+// identification header is applied last so that it always wins over a
+// custom header with the same name. Headers that could not be parsed are
+// returned as invalid.
+func extraHeaders(opts runner.Options) (headers map[string]string, invalid []string) {
+	headers = make(map[string]string)
+
+	raw := append([]string{}, opts.Chrome.Headers...)
+	if opts.Chrome.ScannerHeader != "" {
+		raw = append(raw, opts.Chrome.ScannerHeader)
+	}
+
+	for _, header := range raw {
+		kv := strings.SplitN(header, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			invalid = append(invalid, header)
+			continue
+		}
+
+		key := strings.TrimSpace(kv[0])
+
+		// header names are case-insensitive, so drop any earlier variant
+		for existing := range headers {
+			if strings.EqualFold(existing, key) {
+				delete(headers, existing)
+			}
+		}
+		headers[key] = strings.TrimSpace(kv[1])
+	}
+
+	return headers, invalid
+}
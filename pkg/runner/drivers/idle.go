@@ -0,0 +1,79 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// idleWatchdog cancels a navigation when no network activity was seen for
+// longer than a timeout, so that hosts that accept a connection but never
+// send data fail fast. A nil watchdog is disabled.
+type idleWatchdog struct {
+	timeout time.Duration
+	last    atomic.Int64
+	fired   atomic.Bool
+}
+
+// newIdleWatchdog returns a watchdog for an idle timeout in seconds, or nil
+// if the timeout is disabled
+func newIdleWatchdog(seconds int) *idleWatchdog {
+	if seconds <= 0 {
+		return nil
+	}
+
+	w := &idleWatchdog{timeout: time.Duration(seconds) * time.Second}
+	w.touch()
+
+	return w
+}
+
+// touch records network activity
+func (w *idleWatchdog) touch() {
+	if w == nil {
+		return
+	}
+
+	w.last.Store(time.Now().UnixNano())
+}
+
+// watch returns a context derived from ctx that is cancelled when the
+// watchdog fires. The returned cancel function stops watching and should be
+// called as soon as navigation is done.
+func (w *idleWatchdog) watch(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if w == nil {
+		return ctx, cancel
+	}
+
+	w.touch()
+	go func() {
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if time.Since(time.Unix(0, w.last.Load())) > w.timeout {
+					w.fired.Store(true)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// err returns an error if the watchdog cancelled the navigation
+func (w *idleWatchdog) err() error {
+	if w == nil || !w.fired.Load() {
+		return nil
+	}
+
+	return fmt.Errorf("no network activity for %s", w.timeout)
+}
@@ -0,0 +1,158 @@
+package driver
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/runner"
+)
+
+// maxInlineResources is the maximum number of data: and blob: resources
+// recorded per page
+const maxInlineResources = 200
+
+// maxInlineURLLength is the length data: URLs are truncated to in the network
+// log. The decoded bytes are stored as content instead.
+const maxInlineURLLength = 256
+
+// inlineResourcesJs finds data: and blob: URLs referenced by the DOM, inline
+// styles and the resource timing buffer. blob: URLs are fetched in the page to
+// learn their size and type, and their bytes are included (base64 encoded) when
+// includeData is true.
+const inlineResourcesJs = `(includeData) => {
+	const urls = new Set();
+	const inline = (v) => typeof v === 'string' && (v.startsWith('data:') || v.startsWith('blob:'));
+	const add = (v) => { if (inline(v) && urls.size < %d) urls.add(v); };
+
+	for (const el of document.querySelectorAll('*')) {
+		for (const attr of ['src', 'href', 'data', 'poster', 'srcset']) {
+			const v = el.getAttribute(attr);
+			if (!v) continue;
+			if (attr === 'srcset') v.split(/,\s+/).forEach(part => add(part.trim().split(' ')[0]));
+			else add(v);
+		}
+		const style = el.getAttribute('style');
+		if (style) for (const m of style.matchAll(/url\(["']?([^"')]+)["']?\)/g)) add(m[1]);
+	}
+	for (const entry of performance.getEntriesByType('resource')) add(entry.name);
+
+	const read = (blob) => new Promise((resolve) => {
+		const reader = new FileReader();
+		reader.onload = () => resolve(String(reader.result).split(',')[1] || '');
+		reader.onerror = () => resolve('');
+		reader.readAsDataURL(blob);
+	});
+
+	return Promise.all([...urls].map(async (url) => {
+		if (url.startsWith('data:')) return { url };
+		try {
+			const blob = await (await fetch(url)).blob();
+			return { url, mime: blob.type, size: blob.size, data: includeData ? await read(blob) : '' };
+		} catch (e) {
+			return { url, error: String(e) };
+		}
+	}));
+}`
+
+// inlineResource is a data: or blob: resource found in a page
+type inlineResource struct {
+	URL   string `json:"url"`
+	MIME  string `json:"mime"`
+	Size  int64  `json:"size"`
+	Data  string `json:"data"`
+	Error string `json:"error"`
+}
+
+// inlineResourcesScript returns the inline resource discovery function
+func inlineResourcesScript() string {
+	return fmt.Sprintf(inlineResourcesJs, maxInlineResources)
+}
+
+// inlineNetworkLogs converts inline resources to network log entries.
+// data: URLs are decoded here, and the decoded bytes are kept as content if
+// content of that type should be saved.
+func inlineNetworkLogs(opts runner.Options, resources []inlineResource) []models.NetworkLog {
+	var logs []models.NetworkLog
+	now := time.Now()
+
+	for _, resource := range resources {
+		entry := models.NetworkLog{
+			RequestType: models.HTTP,
+			URL:         resource.URL,
+			MIMEType:    resource.MIME,
+			Size:        resource.Size,
+			Time:        now,
+			Error:       resource.Error,
+		}
+
+		var data []byte
+		if strings.HasPrefix(resource.URL, "data:") {
+			mimeType, decoded, err := decodeDataURL(resource.URL)
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			entry.MIMEType = mimeType
+			entry.Size = int64(len(decoded))
+			data = decoded
+
+			if len(entry.URL) > maxInlineURLLength {
+				entry.URL = entry.URL[:maxInlineURLLength] + "..."
+			}
+		} else if resource.Data != "" {
+			decoded, err := base64.StdEncoding.DecodeString(resource.Data)
+			if err == nil {
+				data = decoded
+			}
+		}
+
+		if data != nil && shouldSaveContent(opts, entry.MIMEType) {
+			content, file, err := storeContent(opts, data)
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			entry.Content = content
+			entry.ContentFile = file
+		}
+
+		logs = append(logs, entry)
+	}
+
+	return logs
+}
+
+// decodeDataURL decodes a data: URL of the form
+// data:[<mediatype>][;base64],<data>, returning the media type and bytes.
+func decodeDataURL(raw string) (string, []byte, error) {
+	header, payload, found := strings.Cut(strings.TrimPrefix(raw, "data:"), ",")
+	if !found {
+		return "", nil, errors.New("invalid data url")
+	}
+
+	mimeType := "text/plain"
+	isBase64 := false
+	for i, param := range strings.Split(header, ";") {
+		switch {
+		case i == 0 && param != "":
+			mimeType = param
+		case strings.EqualFold(param, "base64"):
+			isBase64 = true
+		}
+	}
+
+	if isBase64 {
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(payload))
+		if err != nil {
+			// some pages omit the padding
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(payload), "="))
+		}
+		return mimeType, data, err
+	}
+
+	data, err := url.PathUnescape(payload)
+	return mimeType, []byte(data), err
+}
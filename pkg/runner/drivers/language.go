@@ -0,0 +1,161 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxLanguageText is the maximum number of characters of page text used
+// to detect the language of a page that does not declare one
+const maxLanguageText = 5000
+
+// minLanguageLetters is the minimum number of letters needed in the page
+// text to guess its language
+const minLanguageLetters = 20
+
+// languageJs returns the language declared by the page, from the <html lang>
+// attribute or a Content-Language / language meta tag, and, if detect is set
+// and no language is declared, a sample of the page text to detect it from
+const languageJs = `(detect) => {
+	const meta = document.querySelector('meta[http-equiv="content-language" i], meta[name="language" i]');
+	const lang = (document.documentElement.lang || (meta && meta.content) || "").trim();
+	const text = (detect && !lang && document.body) ? document.body.innerText.slice(0, %d) : "";
+	return [lang, text];
+}`
+
+// languageScript returns languageJs with its limits as an expression
+// called with detect
+func languageScript(detect bool) string {
+	return fmt.Sprintf("(%s)(%t)", fmt.Sprintf(languageJs, maxLanguageText), detect)
+}
+
+// pageLanguage returns the primary language subtag (e.g. "en" for "en-US")
+// of the language declared by a page, or the language detected from the
+// page text if it does not declare one
+func pageLanguage(values []string) string {
+	if len(values) != 2 {
+		return ""
+	}
+
+	if declared := strings.TrimSpace(values[0]); declared != "" {
+		// a Content-Language may list several languages
+		declared, _, _ = strings.Cut(declared, ",")
+		primary, _, _ := strings.Cut(strings.TrimSpace(declared), "-")
+		primary, _, _ = strings.Cut(primary, "_")
+		return strings.ToLower(primary)
+	}
+
+	return detectLanguage(values[1])
+}
+
+// scriptLanguages are the languages guessed from the dominant writing
+// system of a text, for scripts mostly used by a single language
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// stopwords are frequent words of languages written in the Latin script,
+// used to tell them apart
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "for", "with", "you", "this", "are", "on", "your"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "sie", "den", "ein", "eine", "für", "auf", "ich"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "pour", "dans", "que", "vous", "du", "sur", "pas"},
+	"es": {"el", "los", "las", "y", "es", "una", "para", "con", "por", "que", "del", "en", "su", "al"},
+	"it": {"il", "di", "che", "per", "una", "sono", "della", "con", "non", "gli", "le", "del", "è", "alla"},
+	"pt": {"o", "os", "as", "e", "do", "da", "uma", "para", "com", "não", "que", "em", "dos", "você"},
+	"nl": {"de", "het", "een", "en", "van", "is", "niet", "met", "voor", "op", "zijn", "dat", "je", "ook"},
+}
+
+// detectLanguage guesses the language of a text. Texts in a script used by
+// a single language are classified by their dominant script, Latin script
+// texts by their most frequent stopwords. It returns an empty string if the
+// text is too short or the language could not be guessed.
+func detectLanguage(text string) string {
+	var letters, latin, kana int
+	scripts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		default:
+			for _, script := range scriptLanguages {
+				if unicode.Is(script.table, r) {
+					scripts[script.language]++
+					break
+				}
+			}
+		}
+	}
+
+	if letters < minLanguageLetters {
+		return ""
+	}
+
+	// Japanese mixes kana with Han characters
+	if kana > 0 && kana*10 >= letters {
+		return "ja"
+	}
+
+	best, bestCount := "", 0
+	for _, script := range scriptLanguages {
+		if count := scripts[script.language]; count > bestCount {
+			best, bestCount = script.language, count
+		}
+	}
+	if bestCount > latin {
+		return best
+	}
+
+	return detectLatinLanguage(text)
+}
+
+// detectLatinLanguage guesses the language of a Latin script text from
+// the number of stopwords of each language it contains
+func detectLatinLanguage(text string) string {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		counts[word]++
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for _, language := range []string{"en", "de", "fr", "es", "it", "pt", "nl"} {
+		score := 0
+		for _, word := range stopwords[language] {
+			score += counts[word]
+		}
+
+		switch {
+		case score > bestScore:
+			best, bestScore, secondScore = language, score, bestScore
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+
+	// too few stopwords, or too close to another language, to be sure
+	if bestScore < 3 || bestScore*10 < secondScore*12 {
+		return ""
+	}
+
+	return best
+}
@@ -0,0 +1,5 @@
+package driver
+
+// domNodesJs counts the elements in the document, as a quick measure of
+// page complexity
+const domNodesJs = `document.getElementsByTagName("*").length`
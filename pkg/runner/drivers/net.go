@@ -0,0 +1,182 @@
+package driver
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// remoteAddr formats a response's remote ip and port as ip:port, returning
+// an empty string if the ip is unknown.
+func remoteAddr(ip string, port int) string {
+	if ip == "" {
+		return ""
+	}
+
+	// chrome wraps ipv6 addresses in brackets
+	if len(ip) > 1 && ip[0] == '[' && ip[len(ip)-1] == ']' {
+		ip = ip[1 : len(ip)-1]
+	}
+
+	return net.JoinHostPort(ip, strconv.Itoa(port))
+}
+
+// networkTiming is the CDP resource timing of a response. RequestTime is a
+// monotonic time in seconds, the other fields are milliseconds relative to
+// it, or -1 if the phase did not happen.
+type networkTiming struct {
+	RequestTime       float64
+	DNSStart          float64
+	DNSEnd            float64
+	ConnectStart      float64
+	ConnectEnd        float64
+	SSLStart          float64
+	SSLEnd            float64
+	SendStart         float64
+	SendEnd           float64
+	ReceiveHeadersEnd float64
+}
+
+// apply sets the durations of the request phases up to the response headers
+// on a network log entry
+func (t networkTiming) apply(entry *models.NetworkLog) {
+	entry.DNSTime = timingPhase(t.DNSStart, t.DNSEnd)
+	entry.ConnectTime = timingPhase(t.ConnectStart, t.ConnectEnd)
+	entry.TLSTime = timingPhase(t.SSLStart, t.SSLEnd)
+	entry.SendTime = timingPhase(t.SendStart, t.SendEnd)
+	entry.WaitTime = timingPhase(t.SendEnd, t.ReceiveHeadersEnd)
+}
+
+// headersReceived returns the monotonic time in milliseconds at which the
+// response headers were received
+func (t networkTiming) headersReceived() float64 {
+	return t.RequestTime*1000 + t.ReceiveHeadersEnd
+}
+
+// timingPhase returns the duration of a timing phase, or 0 if it did not
+// happen
+func timingPhase(start, end float64) float64 {
+	if start < 0 || end < start {
+		return 0
+	}
+
+	return end - start
+}
+
+// responseReceipt is where a response is in the network log and when its
+// headers were received, to time the download of its body once loading
+// finishes
+type responseReceipt struct {
+	index           int
+	headersReceived float64
+}
+
+// receiveTime returns the time in milliseconds the body of a response took to
+// download, given the monotonic time in seconds loading finished at
+func (r responseReceipt) receiveTime(finished float64) float64 {
+	if r.headersReceived <= 0 {
+		return 0
+	}
+
+	return max(0, finished*1000-r.headersReceived)
+}
+
+// networkHeaders converts CDP headers to network log headers of the given
+// type (request or response), sorted by key.
+func networkHeaders[V any](kind string, headers map[string]V, value func(V) string) []models.NetworkHeader {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]models.NetworkHeader, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, models.NetworkHeader{
+			Type:  kind,
+			Key:   k,
+			Value: value(headers[k]),
+		})
+	}
+
+	return result
+}
+
+// announcedTrailers returns the trailer field names a response announced
+// with a Trailer header, if any.
+func announcedTrailers[V any](headers map[string]V, value func(V) string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, "trailer") {
+			return strings.TrimSpace(value(v))
+		}
+	}
+
+	return ""
+}
+
+// netErrors maps Chrome net::ERR_* error names to error categories. Names
+// that are not listed are matched by prefix in netErrorPrefixes.
+var netErrors = map[string]models.NetError{
+	"ERR_NAME_NOT_RESOLVED":          models.NetErrorDNS,
+	"ERR_NAME_RESOLUTION_FAILED":     models.NetErrorDNS,
+	"ERR_CONNECTION_REFUSED":         models.NetErrorConnectionRefused,
+	"ERR_CONNECTION_RESET":           models.NetErrorConnectionReset,
+	"ERR_CONNECTION_CLOSED":          models.NetErrorConnectionReset,
+	"ERR_CONNECTION_ABORTED":         models.NetErrorConnectionReset,
+	"ERR_EMPTY_RESPONSE":             models.NetErrorConnectionReset,
+	"ERR_CONNECTION_TIMED_OUT":       models.NetErrorTimeout,
+	"ERR_TIMED_OUT":                  models.NetErrorTimeout,
+	"ERR_ADDRESS_UNREACHABLE":        models.NetErrorUnreachable,
+	"ERR_ADDRESS_INVALID":            models.NetErrorUnreachable,
+	"ERR_NETWORK_CHANGED":            models.NetErrorUnreachable,
+	"ERR_INTERNET_DISCONNECTED":      models.NetErrorUnreachable,
+	"ERR_CONNECTION_FAILED":          models.NetErrorUnreachable,
+	"ERR_BAD_SSL_CLIENT_AUTH_CERT":   models.NetErrorTLS,
+	"ERR_TUNNEL_CONNECTION_FAILED":   models.NetErrorProxy,
+	"ERR_BLOCKED_BY_CLIENT":          models.NetErrorBlocked,
+	"ERR_BLOCKED_BY_RESPONSE":        models.NetErrorBlocked,
+	"ERR_BLOCKED_BY_ADMINISTRATOR":   models.NetErrorBlocked,
+	"ERR_UNSAFE_PORT":                models.NetErrorBlocked,
+	"ERR_ABORTED":                    models.NetErrorAborted,
+	"ERR_INVALID_RESPONSE":           models.NetErrorHTTP,
+	"ERR_INVALID_HTTP_RESPONSE":      models.NetErrorHTTP,
+	"ERR_TOO_MANY_REDIRECTS":         models.NetErrorHTTP,
+	"ERR_RESPONSE_HEADERS_TRUNCATED": models.NetErrorHTTP,
+	"ERR_HTTP2_PROTOCOL_ERROR":       models.NetErrorHTTP,
+}
+
+// netErrorPrefixes maps groups of net::ERR_* error names to categories
+var netErrorPrefixes = []struct {
+	prefix   string
+	category models.NetError
+}{
+	{"ERR_DNS_", models.NetErrorDNS},
+	{"ERR_SSL_", models.NetErrorTLS},
+	{"ERR_CERT_", models.NetErrorCertificate},
+	{"ERR_PROXY_", models.NetErrorProxy},
+	{"ERR_HTTP2_", models.NetErrorHTTP},
+}
+
+// netError returns the category of a Chrome network error text such as
+// net::ERR_NAME_NOT_RESOLVED, or an empty category for an empty text
+func netError(errorText string) models.NetError {
+	name := strings.TrimPrefix(strings.TrimSpace(errorText), "net::")
+	if name == "" {
+		return ""
+	}
+
+	if category, ok := netErrors[name]; ok {
+		return category
+	}
+
+	for _, p := range netErrorPrefixes {
+		if strings.HasPrefix(name, p.prefix) {
+			return p.category
+		}
+	}
+
+	return models.NetErrorOther
+}
@@ -0,0 +1,51 @@
+package driver
+
+import (
+	"strings"
+
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/runner"
+)
+
+// permissionAliases maps friendly permission names to their CDP
+// Browser.PermissionType values
+var permissionAliases = map[string]string{
+	"camera":        "videoCapture",
+	"microphone":    "audioCapture",
+	"mic":           "audioCapture",
+	"geolocation":   "geolocation",
+	"location":      "geolocation",
+	"notifications": "notifications",
+	"clipboard":     "clipboardReadWrite",
+	"midi":          "midi",
+}
+
+// permissionTypes resolves permission names to CDP permission types.
+// Names that are not aliases are assumed to already be CDP types.
+func permissionTypes(names []string) []string {
+	var types []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if t, ok := permissionAliases[strings.ToLower(name)]; ok {
+			name = t
+		}
+		types = append(types, name)
+	}
+
+	return types
+}
+
+// grantedPermissions returns the CDP permission types to grant. Spoofing a
+// geolocation implies granting the geolocation permission.
+func grantedPermissions(opts runner.Options) []string {
+	types := permissionTypes(opts.Chrome.GrantPermissions)
+	if opts.Chrome.Geolocation != "" && !islazy.SliceHasStr(types, "geolocation") {
+		types = append(types, "geolocation")
+	}
+
+	return types
+}
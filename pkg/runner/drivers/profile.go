@@ -0,0 +1,120 @@
+package driver
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// profileSkipped are files and directories not copied from a browser
+// profile. Lock files would make Chrome think the profile is in use, and
+// caches only slow the copy down.
+var profileSkipped = []string{
+	"SingletonLock", "SingletonCookie", "SingletonSocket", "lockfile",
+	"Cache", "Code Cache", "GPUCache", "ShaderCache", "GrShaderCache",
+	"DawnCache", "Service Worker/CacheStorage", "Crashpad",
+}
+
+// newUserDataDir creates a temporary Chrome user data directory. If profile
+// is set, the existing user data directory at that path is copied into it so
+// that its sessions are reused without locking or changing the original.
+// The caller is responsible for removing the directory.
+func newUserDataDir(pattern string, profile string) (string, error) {
+	dir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+
+	if profile == "" {
+		return dir, nil
+	}
+
+	if err := copyProfile(profile, dir); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// copyProfile copies a Chrome user data directory from src to dst
+func copyProfile(src string, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &fs.PathError{Op: "copy profile", Path: src, Err: fs.ErrInvalid}
+	}
+
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		if profileSkip(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0700)
+		case d.Type().IsRegular():
+			return copyProfileFile(path, target)
+		default:
+			// symlinks, sockets and the like are left out
+			return nil
+		}
+	})
+}
+
+// profileSkip checks if a path, relative to the profile root, should not
+// be copied. Entries match the base name or the path from the profile
+// directory (e.g. Default/Cache).
+func profileSkip(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	base := filepath.Base(rel)
+
+	for _, skip := range profileSkipped {
+		if base == skip || strings.HasSuffix(rel, "/"+skip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copyProfileFile copies a single file, keeping it private to the user
+func copyProfileFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
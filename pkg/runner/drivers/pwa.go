@@ -0,0 +1,155 @@
+package driver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/runner"
+)
+
+// serviceWorkerWait is how long to wait for a page to register and
+// activate a service worker. Pages usually register them after the load
+// event, so they are not there yet when the navigation finished.
+const serviceWorkerWait = 2 * time.Second
+
+// serviceWorkerPollInterval is how often to check if a service worker was
+// activated
+const serviceWorkerPollInterval = 100 * time.Millisecond
+
+// serviceWorkerStatuses ranks service worker version statuses, so that the
+// most advanced version of a registration is reported
+var serviceWorkerStatuses = map[string]int{
+	"redundant":  1,
+	"new":        2,
+	"installing": 3,
+	"installed":  4,
+	"activating": 5,
+	"activated":  6,
+}
+
+// serviceWorkerVersion is a version of a service worker registration
+type serviceWorkerVersion struct {
+	registrationID string
+	scriptURL      string
+	status         string
+}
+
+// serviceWorkerRecorder collects the service worker registrations and
+// versions reported by the ServiceWorker domain. A nil recorder is
+// disabled.
+type serviceWorkerRecorder struct {
+	wait bool
+
+	mutex    sync.Mutex
+	scopes   map[string]string
+	deleted  map[string]bool
+	versions map[string]serviceWorkerVersion
+	order    []string
+}
+
+// newServiceWorkerRecorder returns a recorder if PWA capture is enabled in
+// the scan options, or nil otherwise
+func newServiceWorkerRecorder(opts runner.Options) *serviceWorkerRecorder {
+	if !opts.Scan.CapturePWA {
+		return nil
+	}
+
+	return &serviceWorkerRecorder{
+		// without JavaScript pages can't register service workers
+		wait:     !opts.Scan.DisableJavaScript,
+		scopes:   make(map[string]string),
+		deleted:  make(map[string]bool),
+		versions: make(map[string]serviceWorkerVersion),
+	}
+}
+
+// registration records a service worker registration update
+func (r *serviceWorkerRecorder) registration(id string, scope string, deleted bool) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.scopes[id]; !ok {
+		r.order = append(r.order, id)
+	}
+	r.scopes[id] = scope
+	r.deleted[id] = deleted
+}
+
+// version records a service worker version update
+func (r *serviceWorkerRecorder) version(id string, registrationID string, scriptURL string, status string) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.versions[id] = serviceWorkerVersion{
+		registrationID: registrationID,
+		scriptURL:      scriptURL,
+		status:         status,
+	}
+}
+
+// activated checks if any service worker version was activated
+func (r *serviceWorkerRecorder) activated() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, version := range r.versions {
+		if version.status == "activated" && !r.deleted[version.registrationID] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// waitActivated waits for a service worker to be activated, at most
+// serviceWorkerWait
+func (r *serviceWorkerRecorder) waitActivated() {
+	if r == nil || !r.wait {
+		return
+	}
+
+	deadline := time.Now().Add(serviceWorkerWait)
+	for !r.activated() && time.Now().Before(deadline) {
+		time.Sleep(serviceWorkerPollInterval)
+	}
+}
+
+// apply sets the service worker fields of a result to the first active
+// registration and its most advanced version
+func (r *serviceWorkerRecorder) apply(result *models.Result) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, id := range r.order {
+		if r.deleted[id] {
+			continue
+		}
+
+		result.ServiceWorkerRegistered = true
+		result.ServiceWorkerScope = r.scopes[id]
+
+		var best serviceWorkerVersion
+		for _, version := range r.versions {
+			if version.registrationID == id && serviceWorkerStatuses[version.status] > serviceWorkerStatuses[best.status] {
+				best = version
+			}
+		}
+		result.ServiceWorkerScriptURL = best.scriptURL
+		result.ServiceWorkerStatus = best.status
+
+		return
+	}
+}
@@ -0,0 +1,201 @@
+package driver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/runner"
+)
+
+// screenshotExtension returns the file extension for screenshots, including
+// the compression suffix if screenshots are compressed.
+func screenshotExtension(opts runner.Options) string {
+	ext := "." + runner.StoredScreenshotFormat(opts)
+	if opts.Scan.ScreenshotCompress {
+		ext += ".gz"
+	}
+
+	return ext
+}
+
+// maxFilenameLength is the length screenshot file names, and every directory
+// in templated names, are truncated to
+const maxFilenameLength = 200
+
+// screenshotFilename returns the file name for a screenshot of target, using
+// the filename template if one is configured. The suffix is added before the
+// extension, e.g. for scroll captures. Query strings and fragments are left out
+// of the name when Scan.StripQuery is set, and the name is placed in a status
+// code subdirectory when Scan.ScreenshotStatusDirs is set.
+func screenshotFilename(opts runner.Options, target string, statusCode int, at time.Time, suffix string) (string, error) {
+	if opts.Scan.StripQuery {
+		target = runner.StripQuery(target)
+	}
+
+	var name string
+	if opts.Scan.FilenameTemplate == "" {
+		name = islazy.LeftTrucate(islazy.SafeFileName(target)+suffix+screenshotExtension(opts), maxFilenameLength)
+	} else {
+		expanded, err := runner.ExpandFilenameTemplate(opts.Scan.FilenameTemplate, target, at)
+		if err != nil {
+			return "", err
+		}
+		name = truncatePath(expanded+suffix+screenshotExtension(opts), maxFilenameLength)
+	}
+
+	if opts.Scan.ScreenshotStatusDirs {
+		name = path.Join(statusDirectory(statusCode), name)
+	}
+
+	return name, nil
+}
+
+// truncatePath truncates every component of a slash separated path to max
+// characters, so that long targets expanded in filename templates do not
+// exceed file system name limits
+func truncatePath(name string, max int) string {
+	components := strings.Split(name, "/")
+	for i, component := range components {
+		components[i] = islazy.LeftTrucate(component, max)
+	}
+
+	return strings.Join(components, "/")
+}
+
+// statusDirectory returns the subdirectory screenshots are sorted into for
+// a response status code, e.g. 2xx, or failed if there was no valid status
+func statusDirectory(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return "failed"
+	}
+
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
+
+// captureJpegQuality is the quality jpeg screenshots are captured with
+const captureJpegQuality = 80
+
+// storeCapture saves a capture of target in the store format, with the
+// configured watermark. It returns the file name (if saved to disk) and the
+// base64 encoded image (if screenshots are passed to writers).
+func storeCapture(opts runner.Options, target string, statusCode int, at time.Time, suffix string, img []byte) (filename, screenshot string, err error) {
+	stored, err := convertScreenshot(opts, target, statusCode, at, img)
+	if err != nil {
+		return "", "", fmt.Errorf("could not convert screenshot: %w", err)
+	}
+
+	if opts.Scan.ScreenshotToWriter {
+		screenshot = base64.StdEncoding.EncodeToString(stored)
+	}
+
+	if !opts.Scan.ScreenshotSkipSave {
+		filename, err = screenshotFilename(opts, target, statusCode, at, suffix)
+		if err != nil {
+			return "", "", err
+		}
+		if err := writeScreenshot(opts, filename, stored); err != nil {
+			return "", "", fmt.Errorf("could not write screenshot to disk: %w", err)
+		}
+
+		// the original is the capture as the browser returned it, without
+		// the watermark
+		if opts.Scan.ScreenshotKeepOriginal {
+			if original := runner.OriginalScreenshotFilename(opts, filename); original != "" {
+				if err := writeScreenshot(opts, original, img); err != nil {
+					return "", "", fmt.Errorf("could not write original screenshot to disk: %w", err)
+				}
+			}
+		}
+	}
+
+	return filename, screenshot, nil
+}
+
+// convertScreenshot converts a captured screenshot to the store format and
+// overlays the configured watermark, so that the image is encoded only once.
+// The image is returned as is if neither is needed.
+func convertScreenshot(opts runner.Options, target string, statusCode int, at time.Time, img []byte) ([]byte, error) {
+	format := runner.StoredScreenshotFormat(opts)
+	if format == opts.Scan.ScreenshotFormat && opts.Scan.ScreenshotWatermark == "" {
+		return img, nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(img))
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Scan.ScreenshotWatermark != "" {
+		decoded, err = watermarkScreenshot(opts, target, statusCode, at, decoded)
+		if err != nil {
+			return nil, fmt.Errorf("could not watermark screenshot: %w", err)
+		}
+	}
+
+	// re-encode jpeg captures without a store format at the capture quality
+	quality := captureJpegQuality
+	if opts.Scan.ScreenshotStoreFormat != "" {
+		quality = opts.Scan.ScreenshotStoreQuality
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, decoded, &jpeg.Options{Quality: quality})
+	case "png":
+		err = png.Encode(&buf, decoded)
+	default:
+		err = fmt.Errorf("unsupported screenshot store format %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeScreenshot writes a screenshot to the screenshot path, gzip
+// compressing it if configured to do so.
+func writeScreenshot(opts runner.Options, filename string, img []byte) error {
+	path := filepath.Join(opts.Scan.ScreenshotPath, filename)
+
+	// filename templates may contain subdirectories
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if !opts.Scan.ScreenshotCompress {
+		return os.WriteFile(path, img, os.FileMode(0664))
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(0664))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := gzip.NewWriterLevel(file, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(img); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,159 @@
+package driver
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/runner"
+)
+
+func TestScreenshotFilename(t *testing.T) {
+	long := "https://example.com/" + strings.Repeat("a", 300)
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		target   string
+		want     string
+	}{
+		{
+			name:   "Test with default name",
+			target: "https://example.com/path",
+			want:   "https---example.com-path.jpeg",
+		},
+		{
+			name:     "Test with template",
+			template: "{host}/{port}-{date}",
+			target:   "https://example.com/path",
+			want:     "example.com/443-2024-06-01.jpeg",
+		},
+		{
+			name:     "Test with long path in template",
+			template: "{host}/{path}",
+			target:   long,
+			want:     "example.com/" + strings.Repeat("a", 100) + ".jpeg",
+		},
+		{
+			name:     "Test with long directory in template",
+			template: "{path}/{host}",
+			target:   long,
+			want:     strings.Repeat("a", 100) + "/example.com.jpeg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := runner.NewDefaultOptions()
+			opts.Scan.FilenameTemplate = tt.template
+
+			got, err := screenshotFilename(*opts, tt.target, 200, at, "")
+			if err != nil {
+				t.Fatalf("screenshotFilename() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("screenshotFilename() =>\n\nhave: %v\nwant %v", got, tt.want)
+			}
+			for _, component := range strings.Split(got, "/") {
+				if len(component) > maxFilenameLength {
+					t.Errorf("component %q is longer than %d", component, maxFilenameLength)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreCapture(t *testing.T) {
+	var capture bytes.Buffer
+	if err := jpeg.Encode(&capture, image.NewRGBA(image.Rect(0, 0, 400, 300)), &jpeg.Options{Quality: captureJpegQuality}); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		storeFormat string
+		watermark   string
+		wantFormat  string
+		wantSame    bool
+	}{
+		{
+			name:       "Test without conversion",
+			wantFormat: "jpeg",
+			wantSame:   true,
+		},
+		{
+			name:       "Test with watermark",
+			watermark:  "{host}",
+			wantFormat: "jpeg",
+		},
+		{
+			name:        "Test with conversion",
+			storeFormat: "png",
+			wantFormat:  "png",
+		},
+		{
+			name:        "Test with watermark and conversion",
+			storeFormat: "png",
+			watermark:   "{host} {status}",
+			wantFormat:  "png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := runner.NewDefaultOptions()
+			opts.Scan.ScreenshotPath = t.TempDir()
+			opts.Scan.ScreenshotStoreFormat = tt.storeFormat
+			opts.Scan.ScreenshotKeepOriginal = true
+			opts.Scan.ScreenshotWatermark = tt.watermark
+
+			filename, _, err := storeCapture(*opts, "https://example.com", 200, at, "", capture.Bytes())
+			if err != nil {
+				t.Fatalf("storeCapture() error = %v", err)
+			}
+
+			stored, err := os.ReadFile(filepath.Join(opts.Scan.ScreenshotPath, filename))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, format, err := image.Decode(bytes.NewReader(stored))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("stored format =>\n\nhave: %v\nwant %v", format, tt.wantFormat)
+			}
+			if same := bytes.Equal(stored, capture.Bytes()); same != tt.wantSame {
+				t.Errorf("stored capture unchanged =>\n\nhave: %v\nwant %v", same, tt.wantSame)
+			}
+
+			// the original is kept as captured, without the watermark,
+			// whenever the stored screenshot differs from it
+			original := runner.OriginalScreenshotFilename(*opts, filename)
+			if tt.wantSame {
+				if original != "" {
+					t.Errorf("OriginalScreenshotFilename() =>\n\nhave: %v\nwant \"\"", original)
+				}
+				return
+			}
+			if original == "" || original == filename {
+				t.Fatalf("OriginalScreenshotFilename() => %q does not name the original of %q", original, filename)
+			}
+
+			kept, err := os.ReadFile(filepath.Join(opts.Scan.ScreenshotPath, original))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(kept, capture.Bytes()) {
+				t.Errorf("the kept original is not the captured screenshot")
+			}
+		})
+	}
+}
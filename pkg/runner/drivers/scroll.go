@@ -0,0 +1,70 @@
+package driver
+
+import (
+	"fmt"
+	"time"
+)
+
+// scrollSettleTime is how long to wait after scrolling before a capture
+const scrollSettleTime = 500 * time.Millisecond
+
+// scrollHeightJs returns the scrollable height and viewport height of a page
+const scrollHeightJs = `() => [
+	Math.max(document.body ? document.body.scrollHeight : 0, document.documentElement.scrollHeight),
+	window.innerHeight
+]`
+
+// scrollToBottom step limits. Infinite scroll pages are scrolled for at most
+// scrollToBottomMaxSteps viewports.
+const (
+	scrollToBottomMaxSteps  = 50
+	scrollToBottomStepDelay = 250
+	scrollToBottomIdleTime  = 500
+	scrollToBottomIdleLimit = 5000
+)
+
+// scrollToBottomJs scrolls a page to the bottom one viewport at a time to
+// trigger lazy loading, waits for resource loads to go quiet and scrolls
+// back to the top. It returns the number of steps scrolled.
+const scrollToBottomJs = `async (maxSteps, stepDelay, idleTime, idleLimit) => {
+	const sleep = (ms) => new Promise((r) => setTimeout(r, ms));
+	const height = () => Math.max(document.body ? document.body.scrollHeight : 0, document.documentElement.scrollHeight);
+
+	let steps = 0;
+	while (steps < maxSteps) {
+		const before = height();
+		window.scrollBy(0, window.innerHeight);
+		steps++;
+		await sleep(stepDelay);
+
+		if (window.scrollY + window.innerHeight >= height() - 1 && height() === before) break;
+	}
+
+	// wait until no new resources finished loading for idleTime
+	performance.setResourceTimingBufferSize(10000);
+	const start = Date.now();
+	let count = performance.getEntriesByType("resource").length;
+	let quiet = Date.now();
+	while (Date.now() - start < idleLimit) {
+		await sleep(100);
+		const current = performance.getEntriesByType("resource").length;
+		if (current !== count) {
+			count = current;
+			quiet = Date.now();
+		} else if (Date.now() - quiet >= idleTime) {
+			break;
+		}
+	}
+
+	window.scrollTo(0, 0);
+	await sleep(stepDelay);
+
+	return steps;
+}`
+
+// scrollToBottomScript returns scrollToBottomJs as an expression called
+// with its arguments
+func scrollToBottomScript() string {
+	return fmt.Sprintf("(%s)(%d, %d, %d, %d)", scrollToBottomJs,
+		scrollToBottomMaxSteps, scrollToBottomStepDelay, scrollToBottomIdleTime, scrollToBottomIdleLimit)
+}
@@ -0,0 +1,88 @@
+package driver
+
+import (
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// spaRenderRatio is how many times larger than the HTML document served by
+// the server the rendered DOM must be for a page to be a single-page app
+const spaRenderRatio = 2
+
+// spaTechnologies are the technology fingerprints of frameworks that render
+// pages client side
+var spaTechnologies = []string{
+	"Next.js", "Nuxt.js", "Gatsby", "Remix", "SvelteKit", "React", "Preact",
+	"Vue.js", "Angular", "AngularJS", "Svelte", "Ember.js", "Backbone.js",
+}
+
+// spaJs returns the decoded size of the HTML document served by the server,
+// the size of the rendered DOM, and the client side framework whose root
+// or router the page has, if any
+const spaJs = `() => {
+	const nav = performance.getEntriesByType ? performance.getEntriesByType("navigation")[0] : null;
+	const html = document.documentElement ? document.documentElement.outerHTML : "";
+
+	const hasKey = (el, prefix) => el && Object.keys(el).some((k) => k.startsWith(prefix));
+	const roots = Array.from(document.querySelectorAll("body > div, body > main, body > section, #root, #app, #__next, #__nuxt"));
+
+	let framework = "";
+	if (window.__NEXT_DATA__ || document.getElementById("__next")) framework = "Next.js";
+	else if (window.__NUXT__ || window.$nuxt || document.getElementById("__nuxt")) framework = "Nuxt.js";
+	else if (window.___gatsby || document.getElementById("___gatsby")) framework = "Gatsby";
+	else if (window.__remixContext) framework = "Remix";
+	else if (window.__sveltekit_dev || document.querySelector("[data-sveltekit-preload-data]")) framework = "SvelteKit";
+	else if (document.querySelector("[ng-version]") || window.getAllAngularRootElements) framework = "Angular";
+	else if (window.angular || document.querySelector("[ng-app], [data-ng-app]")) framework = "AngularJS";
+	else if (document.querySelector("[data-v-app]") || roots.some((el) => el.__vue_app__ || el.__vue__)) framework = "Vue.js";
+	else if (document.querySelector("[data-reactroot]") || roots.some((el) => el._reactRootContainer || hasKey(el, "__reactContainer$"))) framework = "React";
+	else if (window.Ember || document.querySelector(".ember-application")) framework = "Ember.js";
+
+	return {
+		documentSize: nav ? nav.decodedBodySize : 0,
+		renderedSize: html.length,
+		framework: framework,
+	};
+}`
+
+// spaInfo is the result of spaJs
+type spaInfo struct {
+	DocumentSize int    `json:"documentSize"`
+	RenderedSize int    `json:"renderedSize"`
+	Framework    string `json:"framework"`
+}
+
+// applySPA flags a result as a single-page app if a client side framework
+// was found in the page or its technology fingerprints, and the rendered
+// DOM is much larger than the HTML document the server sent
+func applySPA(result *models.Result, info spaInfo) {
+	framework := info.Framework
+	if framework == "" {
+		framework = spaTechnology(result.Technologies)
+	}
+	if framework == "" || info.DocumentSize <= 0 {
+		return
+	}
+
+	if info.RenderedSize >= spaRenderRatio*info.DocumentSize {
+		result.IsSPA = true
+		result.SPAFramework = framework
+	}
+}
+
+// spaTechnology returns the first client side framework in technology
+// fingerprints, in the order of spaTechnologies
+func spaTechnology(technologies []models.Technology) string {
+	for _, name := range spaTechnologies {
+		for _, technology := range technologies {
+			// fingerprints may have a version, e.g. React:18.2.0
+			value, _, _ := strings.Cut(technology.Value, ":")
+			if strings.EqualFold(value, name) {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,56 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// aroundTextJs finds the first visible occurrence of a text in the page
+// (case-insensitive, within a single text node) and returns the clip around
+// it in document coordinates, padded and kept within the document. It
+// returns null if the text was not found.
+const aroundTextJs = `(text, padding) => {
+	const needle = text.toLowerCase();
+	const walker = document.createTreeWalker(document.body || document.documentElement, NodeFilter.SHOW_TEXT);
+
+	for (let node = walker.nextNode(); node; node = walker.nextNode()) {
+		const index = node.textContent.toLowerCase().indexOf(needle);
+		if (index < 0) continue;
+
+		const range = document.createRange();
+		range.setStart(node, index);
+		range.setEnd(node, Math.min(index + text.length, node.textContent.length));
+		const rect = range.getBoundingClientRect();
+		if (rect.width === 0 && rect.height === 0) continue;
+
+		const root = document.documentElement;
+		const width = Math.max(root.scrollWidth, window.innerWidth);
+		const height = Math.max(root.scrollHeight, window.innerHeight);
+		const x = Math.max(0, rect.left + window.scrollX - padding);
+		const y = Math.max(0, rect.top + window.scrollY - padding);
+
+		return {
+			x: x,
+			y: y,
+			width: Math.min(width, rect.right + window.scrollX + padding) - x,
+			height: Math.min(height, rect.bottom + window.scrollY + padding) - y,
+		};
+	}
+
+	return null;
+}`
+
+// textClip is the area around a text to capture, in CSS pixels
+type textClip struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// aroundTextScript returns aroundTextJs as an expression called with the
+// text and padding
+func aroundTextScript(text string, padding int) string {
+	quoted, _ := json.Marshal(text)
+	return fmt.Sprintf("(%s)(%s, %d)", aroundTextJs, quoted, padding)
+}
@@ -0,0 +1,47 @@
+package driver
+
+import "fmt"
+
+// maxResourceTimings is the maximum number of resource timing entries
+// recorded per page. The browser's resource timing buffer is raised to this
+// size before the page loads, as it only keeps 250 entries by default.
+const maxResourceTimings = 1000
+
+// resourceTimingBufferJs raises the resource timing buffer size. It runs on
+// new documents, before any page script.
+const resourceTimingBufferJs = `performance.setResourceTimingBufferSize(%d);`
+
+// resourceTimingsJs returns the resource timing entries of the page, with
+// the phases of each request in milliseconds. The keys match the json tags
+// of models.ResourceTiming.
+const resourceTimingsJs = `() => {
+	const phase = (start, end) => (start > 0 && end > start) ? end - start : 0;
+
+	return performance.getEntriesByType("resource").slice(0, %d).map((e) => ({
+		name: e.name,
+		initiator_type: e.initiatorType,
+		next_hop_protocol: e.nextHopProtocol,
+		start_time: e.startTime,
+		duration: e.duration,
+		redirect_time: phase(e.redirectStart, e.redirectEnd),
+		dns_time: phase(e.domainLookupStart, e.domainLookupEnd),
+		connect_time: phase(e.connectStart, e.connectEnd),
+		tls_time: phase(e.secureConnectionStart, e.connectEnd),
+		request_time: phase(e.requestStart, e.responseStart),
+		response_time: phase(e.responseStart, e.responseEnd),
+		transfer_size: e.transferSize,
+		encoded_body_size: e.encodedBodySize,
+		decoded_body_size: e.decodedBodySize,
+		response_status: e.responseStatus || 0,
+	}));
+}`
+
+// resourceTimingBufferScript returns resourceTimingBufferJs with its limit
+func resourceTimingBufferScript() string {
+	return fmt.Sprintf(resourceTimingBufferJs, maxResourceTimings)
+}
+
+// resourceTimingsScript returns resourceTimingsJs with its limit
+func resourceTimingsScript() string {
+	return fmt.Sprintf(resourceTimingsJs, maxResourceTimings)
+}
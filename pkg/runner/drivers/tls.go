@@ -0,0 +1,104 @@
+package driver
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"net/url"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// certificateFingerprint returns the hex encoded SHA-256 fingerprint of the
+// leaf certificate from a Network.getCertificate response. The first entry in
+// tableNames is the base64 encoded DER of the leaf.
+func certificateFingerprint(tableNames []string) (string, error) {
+	if len(tableNames) == 0 {
+		return "", errors.New("no certificates returned")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(tableNames[0])
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// certificateChainPEM returns the certificate chain from a
+// Network.getCertificate response as PEM blocks, leaf first
+func certificateChainPEM(tableNames []string) (string, error) {
+	var chain []byte
+	for _, encoded := range tableNames {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", err
+		}
+
+		chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	return string(chain), nil
+}
+
+// connectionTLS returns the TLS details of a connection made by Go rather
+// than Chrome, in the same shape as Chrome's security details
+func connectionTLS(state tls.ConnectionState) models.TLS {
+	details := models.TLS{
+		Protocol:             tls.VersionName(state.Version),
+		Cipher:               tls.CipherSuiteName(state.CipherSuite),
+		EncryptedClientHello: state.ECHAccepted,
+	}
+
+	if len(state.PeerCertificates) == 0 {
+		return details
+	}
+
+	leaf := state.PeerCertificates[0]
+	details.SubjectName = leaf.Subject.CommonName
+	details.Issuer = leaf.Issuer.CommonName
+	details.ValidFrom = leaf.NotBefore
+	details.ValidTo = leaf.NotAfter
+	for _, name := range leaf.DNSNames {
+		details.SanList = append(details.SanList, models.TLSSanList{Value: name})
+	}
+	for _, ip := range leaf.IPAddresses {
+		details.SanList = append(details.SanList, models.TLSSanList{Value: ip.String()})
+	}
+
+	sum := sha256.Sum256(leaf.Raw)
+	details.FingerprintSHA256 = hex.EncodeToString(sum[:])
+
+	var chain []byte
+	for _, cert := range state.PeerCertificates {
+		chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	details.PEM = string(chain)
+
+	return details
+}
+
+// urlOrigin returns the scheme://host[:port] origin of a URL
+func urlOrigin(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	return u.Scheme + "://" + u.Host
+}
+
+// urlHostname returns the hostname of a url, or an empty string if it
+// could not be parsed
+func urlHostname(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	return u.Hostname()
+}
@@ -0,0 +1,14 @@
+package driver
+
+// lifecycleEvent maps a Scan.WaitUntil condition to the name of the page
+// lifecycle event Chrome emits for it. Unknown conditions wait for "load".
+func lifecycleEvent(waitUntil string) string {
+	switch waitUntil {
+	case "domcontentloaded":
+		return "DOMContentLoaded"
+	case "networkidle":
+		return "networkIdle"
+	default:
+		return "load"
+	}
+}
@@ -0,0 +1,107 @@
+package driver
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/runner"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// watermarkPadding is the padding in unscaled pixels around the text
+	watermarkPadding = 4
+	// watermarkMaxLines is the number of lines long watermarks wrap to
+	// before they are truncated
+	watermarkMaxLines = 4
+	// watermarkScaleWidth is the screenshot width per scale step of the
+	// watermark text, so that it stays readable on large screenshots
+	watermarkScaleWidth = 800
+)
+
+// watermarkBackground is the translucent box drawn behind watermark text
+var watermarkBackground = color.RGBA{A: 180}
+
+// watermarkScreenshot returns a decoded screenshot with the configured
+// watermark drawn over it
+func watermarkScreenshot(opts runner.Options, target string, statusCode int, at time.Time, img image.Image) (image.Image, error) {
+	text, err := runner.ExpandWatermark(opts.Scan.ScreenshotWatermark, target, statusCode, at)
+	if err != nil {
+		return nil, err
+	}
+
+	canvas := image.NewRGBA(img.Bounds())
+	draw.Draw(canvas, canvas.Bounds(), img, img.Bounds().Min, draw.Src)
+	drawWatermark(canvas, text, opts.Scan.ScreenshotWatermarkPosition)
+
+	return canvas, nil
+}
+
+// drawWatermark draws text in a box in a corner of canvas, wrapping it to
+// the width of the canvas
+func drawWatermark(canvas *image.RGBA, text string, position string) {
+	face := basicfont.Face7x13
+	bounds := canvas.Bounds()
+	scale := max(1, bounds.Dx()/watermarkScaleWidth)
+
+	perLine := (bounds.Dx()/scale - 2*watermarkPadding) / face.Advance
+	if perLine < 1 || text == "" {
+		return
+	}
+	lines := wrapWatermark(text, perLine)
+
+	var longest int
+	for _, line := range lines {
+		longest = max(longest, len([]rune(line)))
+	}
+
+	// render the text unscaled, then scale it up onto the screenshot
+	label := image.NewRGBA(image.Rect(0, 0, longest*face.Advance+2*watermarkPadding, len(lines)*face.Height+2*watermarkPadding))
+	draw.Draw(label, label.Bounds(), image.NewUniform(watermarkBackground), image.Point{}, draw.Src)
+
+	drawer := font.Drawer{Dst: label, Src: image.White, Face: face}
+	for i, line := range lines {
+		drawer.Dot = fixed.P(watermarkPadding, watermarkPadding+face.Ascent+i*face.Height)
+		drawer.DrawString(line)
+	}
+
+	width, height := label.Bounds().Dx()*scale, label.Bounds().Dy()*scale
+	x, y := bounds.Min.X, bounds.Max.Y-height
+	switch position {
+	case "top-left":
+		y = bounds.Min.Y
+	case "top-right":
+		x, y = bounds.Max.X-width, bounds.Min.Y
+	case "bottom-right":
+		x = bounds.Max.X - width
+	}
+
+	xdraw.NearestNeighbor.Scale(canvas, image.Rect(x, y, x+width, y+height), label, label.Bounds(), xdraw.Over, nil)
+}
+
+// wrapWatermark splits text into lines of at most perLine characters,
+// truncating it after watermarkMaxLines lines
+func wrapWatermark(text string, perLine int) []string {
+	runes := []rune(text)
+
+	var lines []string
+	for len(runes) > 0 && len(lines) < watermarkMaxLines {
+		n := min(perLine, len(runes))
+		lines = append(lines, string(runes[:n]))
+		runes = runes[n:]
+	}
+
+	if len(runes) > 0 {
+		last := []rune(lines[len(lines)-1])
+		if len(last) > 3 {
+			lines[len(lines)-1] = string(last[:len(last)-3]) + "..."
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,55 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWrapWatermark(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		perLine int
+		want    []string
+	}{
+		{
+			name:    "Test with short text",
+			text:    "example.com",
+			perLine: 20,
+			want:    []string{"example.com"},
+		},
+		{
+			name:    "Test with wrapped text",
+			text:    "https://example.com",
+			perLine: 8,
+			want:    []string{"https://", "example.", "com"},
+		},
+		{
+			name:    "Test with truncated text",
+			text:    "aaaabbbbccccddddeeee",
+			perLine: 4,
+			want:    []string{"aaaa", "bbbb", "cccc", "d..."},
+		},
+		{
+			name:    "Test with multibyte text",
+			text:    "日本語のテキスト",
+			perLine: 3,
+			want:    []string{"日本語", "のテキ", "スト"},
+		},
+		{
+			name:    "Test with empty text",
+			text:    "",
+			perLine: 10,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapWatermark(tt.text, tt.perLine)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrapWatermark() =>\n\nhave: %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,609 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/corona10/goimagehash"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/runner"
+	"github.com/sensepost/gowitness/pkg/targetline"
+)
+
+// webdriverElementKey 是 W3C WebDriver 中元素引用的键
+const webdriverElementKey = "element-6066-11e4-a52e-4a4e9f0c2f6e"
+
+// webdriverStatusJs 从 Navigation Timing 中读取文档的响应状态码（Chrome 109+）。
+// WebDriver 本身不提供响应状态码。
+const webdriverStatusJs = `
+const entry = performance.getEntriesByType('navigation')[0];
+return entry && entry.responseStatus ? entry.responseStatus : 0;`
+
+// WebDriver 是通过远程 W3C WebDriver 端点（例如 Selenium Grid）
+// 探测 Web 目标的驱动程序。
+//
+// 它无法获取 CDP 级别的信息（网络日志、TLS、控制台等），
+// 只收集最终 URL、状态码、标题、HTML 和截图。
+type WebDriver struct {
+	// Runner 需要考虑的选项
+	options runner.Options
+	// 日志记录器
+	log *slog.Logger
+	// 与 WebDriver 端点通信的 HTTP 客户端
+	client *http.Client
+}
+
+// webdriverError 是 WebDriver 端点返回的错误
+type webdriverError struct {
+	Code    string `json:"error"`
+	Message string `json:"message"`
+}
+
+func (e *webdriverError) Error() string {
+	return fmt.Sprintf("webdriver error %s: %s", e.Code, e.Message)
+}
+
+// NewWebDriver 返回一个新的 WebDriver 实例
+func NewWebDriver(logger *slog.Logger, opts runner.Options) (*WebDriver, error) {
+	if opts.Chrome.WebDriverURL == "" {
+		return nil, errors.New("the webdriver driver needs a webdriver url")
+	}
+
+	if opts.Chrome.ClientCert != "" {
+		return nil, errors.New("client certificates are not supported by the webdriver driver")
+	}
+
+	// 这些选项需要 CDP，WebDriver 无法支持
+	if len(opts.Chrome.Headers) > 0 || opts.Chrome.ScannerHeader != "" {
+		logger.Warn("custom headers are not supported by the webdriver driver and will be ignored")
+	}
+	if opts.Scan.CaptureAroundText != "" {
+		logger.Warn("capturing around a text is not supported by the webdriver driver, capturing the viewport")
+	}
+	if opts.Scan.CapturePWA {
+		logger.Warn("service worker and manifest capture is not supported by the webdriver driver and will be ignored")
+	}
+	if opts.Scan.Referer != "" {
+		logger.Warn("a referer is not supported by the webdriver driver and will be ignored")
+	}
+	if opts.Scan.CaptureRedirectHosts {
+		logger.Warn("the redirect chain is not available with the webdriver driver, redirect hosts will not be captured")
+	}
+	if opts.Scan.ScreenshotFullPage {
+		logger.Warn("full page screenshots are not supported by the webdriver driver, capturing the viewport")
+	}
+
+	return &WebDriver{
+		options: opts,
+		log:     logger,
+		client:  &http.Client{},
+	}, nil
+}
+
+// command 向 WebDriver 端点发送一个命令，并将响应中的 value 解码到 out（如果不为 nil）
+func (run *WebDriver) command(ctx context.Context, method string, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	endpoint := strings.TrimSuffix(run.options.Chrome.WebDriverURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := run.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("could not decode webdriver response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		wdErr := &webdriverError{Code: resp.Status}
+		_ = json.Unmarshal(response.Value, wdErr)
+		return wdErr
+	}
+
+	if out != nil {
+		return json.Unmarshal(response.Value, out)
+	}
+
+	return nil
+}
+
+// capabilities 返回新建会话时请求的浏览器能力
+func (run *WebDriver) capabilities() map[string]any {
+	args := []string{
+		"--headless=new",
+		"--no-sandbox",
+		"--disable-dev-shm-usage",
+		"--mute-audio",
+		"--deny-permission-prompts",
+		fmt.Sprintf("--window-size=%d,%d", run.options.Chrome.WindowX, run.options.Chrome.WindowY),
+		fmt.Sprintf("--window-position=%d,%d", run.options.Chrome.WindowPositionX, run.options.Chrome.WindowPositionY),
+		"--user-agent=" + run.options.Chrome.UserAgent,
+	}
+
+	if run.options.Chrome.Proxy != "" {
+		args = append(args, "--proxy-server="+run.options.Chrome.Proxy)
+	}
+
+	// 与其他驱动一致地隐藏滚动条
+	if !run.options.Chrome.ShowScrollbars {
+		args = append(args, "--hide-scrollbars")
+	}
+
+	// 设备像素比
+	if run.options.Chrome.DeviceScaleFactor != 1 {
+		args = append(args, fmt.Sprintf("--force-device-scale-factor=%g", run.options.Chrome.DeviceScaleFactor))
+	}
+
+	// 不执行 JavaScript，也不加载图像
+	if run.options.Scan.DisableJavaScript {
+		args = append(args, "--blink-settings=imagesEnabled=false,scriptEnabled=false")
+	}
+
+	// WebDriver 只有 eager（DOMContentLoaded）和 normal（load）两种加载策略
+	strategy := "normal"
+	if run.options.Scan.WaitUntil == "domcontentloaded" {
+		strategy = "eager"
+	}
+
+	return map[string]any{
+		"capabilities": map[string]any{
+			"alwaysMatch": map[string]any{
+				"browserName":         "chrome",
+				"acceptInsecureCerts": true,
+				"pageLoadStrategy":    strategy,
+				"goog:chromeOptions": map[string]any{
+					"args": args,
+				},
+			},
+		},
+	}
+}
+
+// Witness 执行探测 URL 的工作。
+// 每个目标都使用一个新的 WebDriver 会话。
+func (run *WebDriver) Witness(ctx context.Context, target string, thisRunner *runner.Runner) (*models.Result, error) {
+	logger := run.log.With("target", target)
+	logger.Debug("witnessing 👀")
+
+	// WebDriver 只能进行普通的 GET 导航
+	request := targetline.Parse(target)
+	if !request.IsPlain() {
+		return nil, errors.New("the webdriver driver only supports plain GET targets")
+	}
+	if request.Proxy != "" {
+		logger.Warn("per-target proxies are not supported by the webdriver driver, ignoring the target proxy")
+	}
+	target = request.URL
+
+	// 创建会话
+	var session struct {
+		SessionID    string `json:"sessionId"`
+		Capabilities struct {
+			BrowserName    string `json:"browserName"`
+			BrowserVersion string `json:"browserVersion"`
+		} `json:"capabilities"`
+	}
+	if err := run.command(ctx, http.MethodPost, "/session", run.capabilities(), &session); err != nil {
+		return nil, fmt.Errorf("could not create webdriver session: %w", err)
+	}
+	if session.SessionID == "" {
+		return nil, errors.New("webdriver did not return a session id")
+	}
+	sessionPath := "/session/" + session.SessionID
+
+	// 使用独立的上下文删除会话，以便在超时后也能清理
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := run.command(cleanupCtx, http.MethodDelete, sessionPath, nil, nil); err != nil {
+			logger.Debug("could not delete webdriver session", "err", err)
+		}
+	}()
+
+	// 获取用于导航的超时上下文
+	navigationCtx, navigationCancel := context.WithTimeout(ctx, time.Duration(run.options.Scan.Timeout)*time.Second)
+	defer navigationCancel()
+
+	timeout := run.options.Scan.Timeout * 1000
+	if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/timeouts",
+		map[string]int{"pageLoad": timeout, "script": timeout}, nil); err != nil {
+		return nil, fmt.Errorf("could not set webdriver timeouts: %w", err)
+	}
+
+	result := &models.Result{
+		URL:      target,
+		ProbedAt: time.Now(),
+	}
+	if session.Capabilities.BrowserVersion != "" {
+		result.BrowserVersion = session.Capabilities.BrowserName + "/" + session.Capabilities.BrowserVersion
+	}
+
+	// 显式设置视口和屏幕大小，使截图尺寸不受无头模式的影响。这使用
+	// ChromeDriver 的 CDP 扩展命令，其他浏览器只使用窗口大小。
+	if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/goog/cdp/execute", map[string]any{
+		"cmd": "Emulation.setDeviceMetricsOverride",
+		"params": map[string]any{
+			"width":             run.options.Chrome.WindowX,
+			"height":            run.options.Chrome.WindowY,
+			"deviceScaleFactor": run.options.Chrome.DeviceScaleFactor,
+			"mobile":            false,
+			"screenWidth":       run.options.Chrome.WindowX,
+			"screenHeight":      run.options.Chrome.WindowY,
+		},
+	}, nil); err != nil {
+		logger.Debug("could not set device metrics", "err", err)
+	}
+
+	// 在任何页面脚本之前执行的 JavaScript。这使用 ChromeDriver 的 CDP
+	// 扩展命令，其他浏览器的 WebDriver 不支持。
+	if run.options.Scan.JavaScriptOnNewDocument != "" {
+		if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/goog/cdp/execute", map[string]any{
+			"cmd":    "Page.addScriptToEvaluateOnNewDocument",
+			"params": map[string]any{"source": run.options.Scan.JavaScriptOnNewDocument},
+		}, nil); err != nil {
+			return nil, fmt.Errorf("could not add javascript to evaluate on new documents: %w", err)
+		}
+	}
+
+	// 模拟慢速网络。这使用 ChromeDriver 的网络条件扩展命令。
+	if run.options.Scan.NetworkThrottle != "" {
+		throttle, err := runner.ParseNetworkThrottle(run.options.Scan.NetworkThrottle)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/chromium/network_conditions", map[string]any{
+			"network_conditions": map[string]any{
+				"offline":             throttle.Offline,
+				"latency":             throttle.Latency,
+				"download_throughput": throttle.DownloadThroughput,
+				"upload_throughput":   throttle.UploadThroughput,
+			},
+		}, nil); err != nil {
+			return nil, fmt.Errorf("could not emulate network conditions: %w", err)
+		}
+	}
+
+	// 扩大资源计时缓冲区，以记录页面的所有资源
+	if run.options.Scan.CaptureResourceTimings {
+		if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/goog/cdp/execute", map[string]any{
+			"cmd":    "Page.addScriptToEvaluateOnNewDocument",
+			"params": map[string]any{"source": resourceTimingBufferScript()},
+		}, nil); err != nil {
+			return nil, fmt.Errorf("could not raise the resource timing buffer size: %w", err)
+		}
+	}
+
+	// 导航到目标。与其他驱动一样，页面加载超时不被视为失败。
+	_, navigateSpan := thisRunner.Tracer.Start(ctx, "navigate")
+	if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/url", map[string]string{"url": target}, nil); err != nil {
+		var wdErr *webdriverError
+		if !errors.As(err, &wdErr) || wdErr.Code != "timeout" {
+			navigateSpan.SetError(err)
+			navigateSpan.End()
+			return nil, fmt.Errorf("could not navigate to target: %w", err)
+		}
+	}
+	navigateSpan.End()
+
+	// 如果有延迟，就等待
+	if run.options.Scan.Delay > 0 {
+		time.Sleep(time.Duration(run.options.Scan.Delay) * time.Second)
+	}
+
+	// 等待动画帧，直到页面在视觉上稳定
+	if run.options.Scan.WaitAnimationFrames > 0 && !run.options.Scan.DisableJavaScript {
+		var frames int
+		if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/execute/sync",
+			map[string]any{"script": "return " + animationFramesScript(run.options.Scan.WaitAnimationFrames), "args": []any{}}, &frames); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not wait for animation frames", "err", err)
+			}
+		} else {
+			logger.Debug("waited for animation frames", "frames", frames)
+		}
+	}
+
+	// 运行我们有的任何 JavaScript
+	if run.options.Scan.JavaScript != "" && !run.options.Scan.DisableJavaScript {
+		_, javascriptSpan := thisRunner.Tracer.Start(ctx, "javascript")
+		script, value := run.options.Scan.JavaScript, (*json.RawMessage)(nil)
+		if run.options.Scan.JavaScriptReturn {
+			// 调用函数并将其返回值以 JSON 记录
+			script, value = "return ("+run.options.Scan.JavaScript+")()", new(json.RawMessage)
+		}
+
+		if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/execute/sync",
+			map[string]any{"script": script, "args": []any{}}, value); err != nil {
+			javascriptSpan.SetError(err)
+			if run.options.Logging.LogScanErrors {
+				logger.Error("failed to evaluate user-provided javascript", "err", err)
+			}
+		} else if value != nil {
+			result.JavaScriptReturn = string(*value)
+		}
+		javascriptSpan.End()
+	}
+
+	// 获取最终 URL
+	if err := run.command(navigationCtx, http.MethodGet, sessionPath+"/url", nil, &result.FinalURL); err != nil {
+		if run.options.Logging.LogScanErrors {
+			logger.Error("could not get final url", "err", err)
+		}
+	}
+
+	// 获取响应状态码
+	if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/execute/sync",
+		map[string]any{"script": webdriverStatusJs, "args": []any{}}, &result.ResponseCode); err != nil {
+		if run.options.Logging.LogScanErrors {
+			logger.Error("could not get response status", "err", err)
+		}
+	}
+
+	// 获取标题
+	if err := run.command(navigationCtx, http.MethodGet, sessionPath+"/title", nil, &result.Title); err != nil {
+		if run.options.Logging.LogScanErrors {
+			logger.Error("could not get page title", "err", err)
+		}
+	}
+
+	// 获取 HTML
+	if !run.options.Scan.SkipHTML {
+		if err := run.command(navigationCtx, http.MethodGet, sessionPath+"/source", nil, &result.HTML); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not get page html", "err", err)
+			}
+		}
+	}
+
+	// 记录脚本和链接的来源
+	var externals []externalResource
+	if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/execute/sync",
+		map[string]any{"script": "return (" + externalResourcesScript() + ")()", "args": []any{}}, &externals); err != nil {
+		if run.options.Logging.LogScanErrors {
+			logger.Error("could not list external resources", "err", err)
+		}
+	} else {
+		result.ExternalResources = externalResourceModels(externals)
+	}
+
+	// 记录资源计时
+	if run.options.Scan.CaptureResourceTimings {
+		if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/execute/sync",
+			map[string]any{"script": "return (" + resourceTimingsScript() + ")()", "args": []any{}}, &result.ResourceTimings); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not get resource timings", "err", err)
+			}
+		}
+	}
+
+	// 记录页面语言
+	if run.options.Scan.CaptureLanguage {
+		var language []string
+		if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/execute/sync",
+			map[string]any{"script": "return " + languageScript(run.options.Scan.DetectLanguage), "args": []any{}}, &language); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not get page language", "err", err)
+			}
+		} else {
+			result.Language = pageLanguage(language)
+		}
+	}
+
+	// 记录 DOM 节点数量。WebDriver 无法获取传输的字节数。
+	if run.options.Scan.PageMetrics {
+		if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/execute/sync",
+			map[string]any{"script": "return " + domNodesJs, "args": []any{}}, &result.DOMNodes); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not count dom nodes", "err", err)
+			}
+		}
+	}
+
+	// 计算 HTML 的内容哈希
+	if result.HTML != "" {
+		result.HTMLSHA256 = sha256Hex([]byte(result.HTML))
+	}
+
+	// 识别技术指纹。WebDriver 不提供响应头部，所以只能使用 HTML。
+	if fingerprints := thisRunner.Wappalyzer.Fingerprint(result.HeaderMap(), []byte(result.HTML)); fingerprints != nil {
+		for tech := range fingerprints {
+			result.Technologies = append(result.Technologies, models.Technology{
+				Value: tech,
+			})
+		}
+	}
+
+	// 禁用 JavaScript 时不截图
+	if run.options.Scan.DisableJavaScript {
+		return result, nil
+	}
+
+	// 识别单页应用
+	var spa spaInfo
+	if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/execute/sync",
+		map[string]any{"script": "return (" + spaJs + ")()", "args": []any{}}, &spa); err != nil {
+		if run.options.Logging.LogScanErrors {
+			logger.Error("could not detect single-page app", "err", err)
+		}
+	} else {
+		applySPA(result, spa)
+	}
+
+	// 处理 cookie 同意横幅
+	if run.options.Scan.CookieConsent != "" {
+		if framework := run.handleConsent(navigationCtx, sessionPath); framework != "" {
+			logger.Debug("cookie consent banner handled", "framework", framework)
+			result.ConsentHandled = true
+			result.ConsentFramework = framework
+		}
+	}
+
+	// 分步滚动到底部以触发懒加载内容
+	if run.options.Scan.ScrollToBottom {
+		var steps int
+		if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/execute/sync",
+			map[string]any{"script": "return " + scrollToBottomScript(), "args": []any{}}, &steps); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not scroll to the bottom of the page", "err", err)
+			}
+		} else {
+			logger.Debug("scrolled to the bottom of the page", "steps", steps)
+		}
+	}
+
+	// 以打印样式渲染页面，同样使用 ChromeDriver 的 CDP 扩展命令
+	if run.options.Scan.EmulatePrintMedia {
+		if err := run.command(navigationCtx, http.MethodPost, sessionPath+"/goog/cdp/execute", map[string]any{
+			"cmd":    "Emulation.setEmulatedMedia",
+			"params": map[string]any{"media": "print"},
+		}, nil); err != nil {
+			if run.options.Logging.LogScanErrors {
+				logger.Error("could not emulate print media", "err", err)
+			}
+		}
+	}
+
+	// 获取截图
+	_, screenshotSpan := thisRunner.Tracer.Start(ctx, "screenshot")
+	defer screenshotSpan.End()
+
+	img, err := run.screenshot(navigationCtx, sessionPath)
+	if err != nil {
+		if run.options.Logging.LogScanErrors {
+			logger.Error("could not grab screenshot", "err", err)
+		}
+
+		screenshotSpan.SetError(err)
+		result.Failed = true
+		result.FailedReason = err.Error()
+		return result, nil
+	}
+
+	// 交给写入器，并在我们有路径时写入磁盘
+	result.Filename, result.Screenshot, err = storeCapture(run.options, target, result.ResponseCode, result.ProbedAt, "", img)
+	if err != nil {
+		return nil, err
+	}
+
+	// 计算截图的内容哈希
+	result.ScreenshotSHA256 = sha256Hex(img)
+
+	// 计算并设置感知哈希
+	decoded, _, err := image.Decode(bytes.NewReader(img))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot image: %w", err)
+	}
+
+	hash, err := goimagehash.PerceptionHash(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate image perception hash: %w", err)
+	}
+	result.PerceptionHash = hash.ToString()
+
+	return result, nil
+}
+
+// handleConsent 等待 cookie 同意横幅出现并点击配置的按钮，
+// 返回所处理的同意框架名称，没有找到横幅时返回空字符串。
+func (run *WebDriver) handleConsent(ctx context.Context, sessionPath string) string {
+	script := "return " + consentScript(run.options.Scan.CookieConsent)
+	deadline := time.Now().Add(consentWait)
+
+	for {
+		var framework string
+		if err := run.command(ctx, http.MethodPost, sessionPath+"/execute/sync",
+			map[string]any{"script": script, "args": []any{}}, &framework); err == nil && framework != "" {
+			// 等待横幅消失
+			time.Sleep(consentSettle)
+			return framework
+		}
+
+		if time.Now().After(deadline) {
+			return ""
+		}
+
+		select {
+		case <-ctx.Done():
+			return ""
+		case <-time.After(consentPollInterval):
+		}
+	}
+}
+
+// screenshot 截取视口（或配置的选择器对应的元素），并转换为配置的截图格式。
+// WebDriver 截图总是 PNG 格式。
+func (run *WebDriver) screenshot(ctx context.Context, sessionPath string) ([]byte, error) {
+	path := sessionPath + "/screenshot"
+
+	if run.options.Scan.Selector != "" {
+		var element map[string]string
+		if err := run.command(ctx, http.MethodPost, sessionPath+"/element",
+			map[string]string{"using": "css selector", "value": run.options.Scan.Selector}, &element); err != nil {
+			return nil, fmt.Errorf("could not find selector: %w", err)
+		}
+		path = sessionPath + "/element/" + element[webdriverElementKey] + "/screenshot"
+	}
+
+	var encoded string
+	if err := run.command(ctx, http.MethodGet, path, nil, &encoded); err != nil {
+		return nil, err
+	}
+
+	img, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if run.options.Scan.ScreenshotFormat != "jpeg" {
+		return img, nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(img))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, decoded, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Close 清理 WebDriver 驱动。会话在每次探测后删除，所以这里没有需要清理的内容。
+func (run *WebDriver) Close() {
+	run.log.Debug("closing webdriver driver")
+}
@@ -0,0 +1,119 @@
+package driver
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/runner"
+)
+
+// maxWebSockets is the maximum number of WebSocket connections recorded
+// per page
+const maxWebSockets = 100
+
+// websocketTextOpcode is the opcode of text WebSocket messages. Other
+// messages (binary, control) are not captured.
+const websocketTextOpcode = 1
+
+// websocketRecorder collects the WebSocket connections of a page and the
+// text frames sent over them. Frames are truncated to a maximum size and
+// capture stops once a connection reached its byte cap, so chatty sockets
+// cannot exhaust memory. A nil recorder is disabled.
+type websocketRecorder struct {
+	maxFrame      int
+	maxConnection int
+
+	mutex   sync.Mutex
+	sockets map[string]*models.WebSocket
+	sizes   map[string]int
+	order   []string
+}
+
+// newWebsocketRecorder returns a recorder for the frame limits in the scan
+// options, or nil if frame capture is disabled
+func newWebsocketRecorder(opts runner.Options) *websocketRecorder {
+	if !opts.Scan.CaptureWebSocketFrames {
+		return nil
+	}
+
+	return &websocketRecorder{
+		maxFrame:      opts.Scan.WebSocketFrameMaxSize,
+		maxConnection: opts.Scan.WebSocketMaxBytes,
+		sockets:       make(map[string]*models.WebSocket),
+		sizes:         make(map[string]int),
+	}
+}
+
+// created records a new WebSocket connection
+func (r *websocketRecorder) created(requestID string, url string) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.sockets[requestID]; ok || len(r.order) >= maxWebSockets {
+		return
+	}
+
+	r.sockets[requestID] = &models.WebSocket{URL: url}
+	r.order = append(r.order, requestID)
+}
+
+// frame records a frame sent or received on a connection
+func (r *websocketRecorder) frame(requestID string, direction string, opcode int, payload string) {
+	if r == nil || opcode != websocketTextOpcode {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	socket, ok := r.sockets[requestID]
+	if !ok || socket.Truncated {
+		return
+	}
+
+	frame := models.WebSocketFrame{
+		Direction: direction,
+		Time:      time.Now(),
+		Size:      len(payload),
+	}
+
+	if len(payload) > r.maxFrame {
+		payload = strings.ToValidUTF8(payload[:r.maxFrame], "")
+		frame.Truncated = true
+	}
+
+	if r.sizes[requestID]+len(payload) > r.maxConnection {
+		socket.Truncated = true
+		return
+	}
+
+	frame.Payload = payload
+	r.sizes[requestID] += len(payload)
+	socket.Frames = append(socket.Frames, frame)
+}
+
+// websockets returns the recorded connections in the order they were
+// created
+func (r *websocketRecorder) websockets() []models.WebSocket {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var sockets []models.WebSocket
+	for _, requestID := range r.order {
+		socket := *r.sockets[requestID]
+		socket.Frames = append([]models.WebSocketFrame(nil), socket.Frames...)
+		sockets = append(sockets, socket)
+	}
+
+	return sockets
+}
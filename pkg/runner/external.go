@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/models"
+	"golang.org/x/net/publicsuffix"
+)
+
+// classifyExternalResources 为页面引用的脚本和链接设置主机名，
+// 并标记与页面不属于同一个可注册域名的第三方资源。
+func classifyExternalResources(result *models.Result) {
+	primary := result.FinalURL
+	if primary == "" {
+		primary = result.URL
+	}
+
+	site := ""
+	if u, err := url.Parse(primary); err == nil {
+		site = registrableDomain(u.Hostname())
+	}
+
+	for i := range result.ExternalResources {
+		resource := &result.ExternalResources[i]
+
+		u, err := url.Parse(resource.URL)
+		if err != nil {
+			continue
+		}
+
+		resource.Host = strings.ToLower(u.Hostname())
+		resource.ThirdParty = site == "" || registrableDomain(resource.Host) != site
+	}
+}
+
+// registrableDomain 返回主机的可注册域名（eTLD+1），例如 cdn.example.co.uk
+// 返回 example.co.uk。IP 地址和无法识别的主机按原样返回。
+func registrableDomain(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+
+	return domain
+}
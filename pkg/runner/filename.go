@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sensepost/gowitness/internal/islazy"
+)
+
+// filenameToken 匹配文件名模板中的 {token}
+var filenameToken = regexp.MustCompile(`\{[a-z]+\}`)
+
+// FilenameTokens 是文件名模板中支持的占位符
+var FilenameTokens = []string{"{target}", "{scheme}", "{host}", "{port}", "{path}", "{hash}", "{timestamp}", "{date}"}
+
+// runSubdirectoryLayout 是每次运行子目录名称使用的时间格式
+const runSubdirectoryLayout = "2006-01-02T15-04-05"
+
+// RunSubdirectory 返回 path 下以运行开始时间命名的子目录路径。
+// 目录本身由 NewRunner 创建。
+func RunSubdirectory(path string, at time.Time) string {
+	return filepath.Join(path, at.Format(runSubdirectoryLayout))
+}
+
+// StoredScreenshotFormat 返回截图保存时使用的格式
+func StoredScreenshotFormat(opts Options) string {
+	if opts.Scan.ScreenshotStoreFormat != "" {
+		return opts.Scan.ScreenshotStoreFormat
+	}
+
+	return opts.Scan.ScreenshotFormat
+}
+
+// OriginalScreenshotFilename 返回未经转换格式、未加水印的原始截图的文件名。
+// 格式不变时，原始截图以 .original 区分，例如 example.original.png。
+// 如果保存的截图就是原始截图，返回空字符串。
+func OriginalScreenshotFilename(opts Options, filename string) string {
+	converted := opts.Scan.ScreenshotStoreFormat != "" && opts.Scan.ScreenshotStoreFormat != opts.Scan.ScreenshotFormat
+	if !converted && opts.Scan.ScreenshotWatermark == "" {
+		return ""
+	}
+
+	compressed := ""
+	if opts.Scan.ScreenshotCompress {
+		compressed = ".gz"
+	}
+
+	stored := StoredScreenshotFormat(opts)
+	stem := strings.TrimSuffix(filename, "."+stored+compressed)
+	if !converted {
+		stem += ".original"
+	}
+
+	return stem + "." + opts.Scan.ScreenshotFormat + compressed
+}
+
+// ExpandFilenameTemplate 根据目标展开截图文件名模板（不含扩展名）。
+//
+// 模板可以包含 / 来创建子目录，例如 "{host}/{port}-{hash}"。
+// 占位符的值会被清理，因此不会引入额外的路径分隔符。
+func ExpandFilenameTemplate(template string, target string, at time.Time) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+
+	hash := sha256.Sum256([]byte(target))
+	values := map[string]string{
+		"{target}":    target,
+		"{scheme}":    u.Scheme,
+		"{host}":      u.Hostname(),
+		"{port}":      port,
+		"{path}":      strings.Trim(u.Path, "/"),
+		"{hash}":      hex.EncodeToString(hash[:])[:12],
+		"{timestamp}": strconv.FormatInt(at.Unix(), 10),
+		"{date}":      at.Format("2006-01-02"),
+	}
+
+	var unknown error
+	name := filenameToken.ReplaceAllStringFunc(template, func(token string) string {
+		value, ok := values[token]
+		if !ok {
+			unknown = fmt.Errorf("unknown filename template token %s", token)
+			return token
+		}
+		return islazy.SafeFileName(value)
+	})
+	if unknown != nil {
+		return "", unknown
+	}
+
+	// 不允许写到截图目录之外
+	name = path.Clean(name)
+	if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", errors.New("filename template must expand to a relative path inside the screenshot path")
+	}
+
+	return name, nil
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultGeolocationAccuracy 是未指定精度时使用的精度（米）
+const defaultGeolocationAccuracy = 100
+
+// Geolocation 是要模拟的地理位置
+type Geolocation struct {
+	Latitude  float64
+	Longitude float64
+	Accuracy  float64
+}
+
+// ParseGeolocation 解析格式为 "lat,lon[,accuracy]" 的地理位置
+func ParseGeolocation(raw string) (Geolocation, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Geolocation{}, fmt.Errorf("invalid geolocation %q, expected lat,lon[,accuracy]", raw)
+	}
+
+	var values []float64
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return Geolocation{}, fmt.Errorf("invalid geolocation %q: %w", raw, err)
+		}
+		values = append(values, v)
+	}
+
+	geolocation := Geolocation{
+		Latitude:  values[0],
+		Longitude: values[1],
+		Accuracy:  defaultGeolocationAccuracy,
+	}
+	if len(values) == 3 {
+		geolocation.Accuracy = values[2]
+	}
+
+	// 坐标范围检查
+	if geolocation.Latitude < -90 || geolocation.Latitude > 90 {
+		return Geolocation{}, fmt.Errorf("invalid geolocation latitude %v, must be between -90 and 90", geolocation.Latitude)
+	}
+	if geolocation.Longitude < -180 || geolocation.Longitude > 180 {
+		return Geolocation{}, fmt.Errorf("invalid geolocation longitude %v, must be between -180 and 180", geolocation.Longitude)
+	}
+	if geolocation.Accuracy < 0 {
+		return Geolocation{}, fmt.Errorf("invalid geolocation accuracy %v, must be positive", geolocation.Accuracy)
+	}
+
+	return geolocation, nil
+}
@@ -0,0 +1,274 @@
+package runner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/targetline"
+)
+
+// HAR 1.2 结构。
+// 参见：http://www.softwareishard.com/blog/har-12-spec/
+type har struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Pages   []harPage  `json:"pages"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harPage struct {
+	StartedDateTime string         `json:"startedDateTime"`
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	PageTimings     harPageTimings `json:"pageTimings"`
+}
+
+type harPageTimings struct {
+	OnLoad float64 `json:"onLoad"`
+}
+
+type harEntry struct {
+	Pageref         string      `json:"pageref"`
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []harNVP     `json:"cookies"`
+	Headers     []harNVP     `json:"headers"`
+	QueryString []harNVP     `json:"queryString"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int64      `json:"status"`
+	StatusText  string     `json:"statusText"`
+	HTTPVersion string     `json:"httpVersion"`
+	Cookies     []harNVP   `json:"cookies"`
+	Headers     []harNVP   `json:"headers"`
+	Content     harContent `json:"content"`
+	RedirectURL string     `json:"redirectURL"`
+	HeadersSize int        `json:"headersSize"`
+	BodySize    int        `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harNVP struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// buildHar 将结果的网络日志转换为 HAR 文档。target 是探测的目标行，
+// 主请求使用它的方法和请求体。
+func buildHar(target string, result *models.Result) *har {
+	const pageID = "page_1"
+
+	h := &har{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "gowitness", Version: "3"},
+		Pages: []harPage{{
+			StartedDateTime: result.ProbedAt.Format(time.RFC3339Nano),
+			ID:              pageID,
+			Title:           result.Title,
+			PageTimings:     harPageTimings{OnLoad: -1},
+		}},
+		Entries: []harEntry{},
+	}}
+
+	request := targetline.Parse(target)
+	primary := harPrimaryEntry(result)
+
+	for i, entry := range result.Network {
+		e := harEntry{
+			Pageref:         pageID,
+			StartedDateTime: entry.Time.Format(time.RFC3339Nano),
+			Time:            entry.DNSTime + entry.ConnectTime + entry.SendTime + entry.WaitTime + entry.ReceiveTime,
+			Request: harRequest{
+				Method:      "GET",
+				URL:         entry.URL,
+				Cookies:     []harNVP{},
+				Headers:     harHeaders(entry.Headers, "request"),
+				QueryString: harQueryString(entry.URL),
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			Response: harResponse{
+				Status:      entry.StatusCode,
+				Cookies:     []harNVP{},
+				Headers:     harHeaders(entry.Headers, "response"),
+				Content:     harContent{Size: len(entry.Content), MimeType: entry.MIMEType},
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			Timings: harTimings{
+				Blocked: -1,
+				DNS:     harTiming(entry.DNSTime),
+				Connect: harTiming(entry.ConnectTime),
+				Send:    entry.SendTime,
+				Wait:    entry.WaitTime,
+				Receive: entry.ReceiveTime,
+				SSL:     harTiming(entry.TLSTime),
+			},
+			ServerIPAddress: entry.RemoteIP,
+			Error:           entry.Error,
+		}
+
+		// 主请求使用探测的方法和请求体，除非重定向把它变成了 GET
+		if i == primary {
+			e.Response.StatusText = result.ResponseReason
+			e.Response.HTTPVersion = result.Protocol
+			if harKeepsMethod(result) {
+				e.Request.Method = request.Method
+				if request.Body != "" {
+					e.Request.PostData = &harPostData{MimeType: request.ContentType(), Text: request.Body}
+					e.Request.BodySize = len(request.Body)
+				}
+			}
+		}
+
+		if len(entry.Content) > 0 {
+			e.Response.Content.Text = base64.StdEncoding.EncodeToString(entry.Content)
+			e.Response.Content.Encoding = "base64"
+		}
+
+		// 保存在磁盘上的内容不读回内存，只记录文件名
+		if entry.ContentFile != "" {
+			e.Response.Content.Comment = "content saved to " + entry.ContentFile
+		}
+
+		h.Log.Entries = append(h.Log.Entries, e)
+	}
+
+	return h
+}
+
+// harPrimaryEntry 返回网络日志中主请求的索引。网络日志按响应的顺序
+// 记录，主请求是第一个响应最终 URL 的请求。没有找到时返回 -1。
+func harPrimaryEntry(result *models.Result) int {
+	for i, entry := range result.Network {
+		if entry.URL == result.FinalURL {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// harKeepsMethod 检查主请求是否仍然使用探测的方法。除了 307 和 308，
+// 浏览器会将重定向后的请求改为不带请求体的 GET。
+func harKeepsMethod(result *models.Result) bool {
+	for _, redirect := range result.Redirects {
+		if redirect.ResponseCode != 307 && redirect.ResponseCode != 308 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// harTiming 将没有发生的计时阶段转换为 HAR 的 -1
+func harTiming(value float64) float64 {
+	if value == 0 {
+		return -1
+	}
+
+	return value
+}
+
+// harHeaders 返回指定类型的网络头部
+func harHeaders(headers []models.NetworkHeader, kind string) []harNVP {
+	nvps := []harNVP{}
+	for _, header := range headers {
+		if header.Type == kind {
+			nvps = append(nvps, harNVP{Name: header.Key, Value: header.Value})
+		}
+	}
+
+	return nvps
+}
+
+// harQueryString 返回 URL 的查询参数
+func harQueryString(raw string) []harNVP {
+	nvps := []harNVP{}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nvps
+	}
+
+	for key, values := range u.Query() {
+		for _, value := range values {
+			nvps = append(nvps, harNVP{Name: key, Value: value})
+		}
+	}
+
+	return nvps
+}
+
+// writeHar 将结果的 HAR 文件写入 HarPath，并返回文件名
+func (run *Runner) writeHar(target string, result *models.Result) (string, error) {
+	data, err := json.MarshalIndent(buildHar(target, result), "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	name := result.URL
+	if run.options.Scan.StripQuery {
+		name = StripQuery(name)
+	}
+
+	filename := islazy.LeftTrucate(islazy.SafeFileName(name)+".har", 200)
+	if err := os.WriteFile(filepath.Join(run.options.Scan.HarPath, filename), data, os.FileMode(0664)); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+func TestBuildHar(t *testing.T) {
+	network := []models.NetworkLog{
+		// a subresource can respond before the document
+		{URL: "https://example.com/app.js", StatusCode: 200},
+		{
+			URL: "https://example.com/login", StatusCode: 200,
+			DNSTime: 2, ConnectTime: 30, TLSTime: 20, SendTime: 1, WaitTime: 40, ReceiveTime: 7,
+		},
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		redirects  []models.Redirect
+		wantMethod string
+		wantBody   string
+	}{
+		{
+			name:       "Test with GET target",
+			target:     "https://example.com/login",
+			wantMethod: "GET",
+		},
+		{
+			name:       "Test with POST target",
+			target:     "POST https://example.com/login user=admin",
+			wantMethod: "POST",
+			wantBody:   "user=admin",
+		},
+		{
+			name:       "Test with POST target and 307 redirect",
+			target:     "POST http://example.com/login user=admin",
+			redirects:  []models.Redirect{{URL: "http://example.com/login", Location: "https://example.com/login", ResponseCode: 307}},
+			wantMethod: "POST",
+			wantBody:   "user=admin",
+		},
+		{
+			name:       "Test with POST target and 302 redirect",
+			target:     "POST https://example.com/form user=admin",
+			redirects:  []models.Redirect{{URL: "https://example.com/form", Location: "https://example.com/login", ResponseCode: 302}},
+			wantMethod: "GET",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &models.Result{
+				URL:            "https://example.com/login",
+				FinalURL:       "https://example.com/login",
+				ResponseReason: "OK",
+				Network:        network,
+				Redirects:      tt.redirects,
+			}
+
+			entries := buildHar(tt.target, result).Log.Entries
+			if len(entries) != 2 {
+				t.Fatalf("buildHar() entries = %d, want 2", len(entries))
+			}
+
+			if entries[0].Request.Method != "GET" || entries[0].Request.PostData != nil {
+				t.Errorf("subresource request = %s with body %v, want GET without body",
+					entries[0].Request.Method, entries[0].Request.PostData)
+			}
+
+			primary := entries[1]
+			if primary.Request.Method != tt.wantMethod {
+				t.Errorf("primary method =>\n\nhave: %v\nwant %v", primary.Request.Method, tt.wantMethod)
+			}
+			var body string
+			if primary.Request.PostData != nil {
+				body = primary.Request.PostData.Text
+			}
+			if body != tt.wantBody {
+				t.Errorf("primary body =>\n\nhave: %v\nwant %v", body, tt.wantBody)
+			}
+			if primary.Response.StatusText != "OK" {
+				t.Errorf("primary status text =>\n\nhave: %v\nwant %v", primary.Response.StatusText, "OK")
+			}
+		})
+	}
+}
+
+func TestBuildHarTimings(t *testing.T) {
+	result := &models.Result{
+		URL:      "https://example.com",
+		FinalURL: "https://example.com",
+		Network: []models.NetworkLog{
+			{URL: "https://example.com", DNSTime: 2, ConnectTime: 30, TLSTime: 20, SendTime: 1, WaitTime: 40, ReceiveTime: 7},
+			// a reused connection has no dns or connect phase
+			{URL: "https://example.com/style.css", SendTime: 1, WaitTime: 10, ReceiveTime: 2},
+		},
+	}
+
+	entries := buildHar("https://example.com", result).Log.Entries
+
+	want := []struct {
+		time    float64
+		timings harTimings
+	}{
+		{80, harTimings{Blocked: -1, DNS: 2, Connect: 30, Send: 1, Wait: 40, Receive: 7, SSL: 20}},
+		{13, harTimings{Blocked: -1, DNS: -1, Connect: -1, Send: 1, Wait: 10, Receive: 2, SSL: -1}},
+	}
+
+	for i, w := range want {
+		if entries[i].Time != w.time {
+			t.Errorf("entry %d time =>\n\nhave: %v\nwant %v", i, entries[i].Time, w.time)
+		}
+		if entries[i].Timings != w.timings {
+			t.Errorf("entry %d timings =>\n\nhave: %+v\nwant %+v", i, entries[i].Timings, w.timings)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"errors"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// ErrSkipResult can be returned by a ResultHook to drop a result
+// before it reaches the writers.
+var ErrSkipResult = errors.New("result skipped by hook")
+
+// ResultHook is the interface result post-processors will implement.
+//
+// Hooks are called in the order they were added, after a target was
+// witnessed and before the result is passed to writers. A hook may
+// modify the result in place.
+type ResultHook interface {
+	Process(*models.Result) error
+}
+
+// AddHook registers hooks to run on every result
+func (run *Runner) AddHook(hooks ...ResultHook) {
+	run.hooks = append(run.hooks, hooks...)
+}
+
+// AddPostWriteHook registers hooks to run on every result after it was
+// written by all writers, e.g. to notify other tools of written results.
+// Results queued by a batching writer reach these hooks once their batch
+// was written. During Run, up to Scan.HookConcurrency results pass
+// through the hooks at the same time, so hooks must be safe for
+// concurrent use.
+// Returning ErrSkipResult has no effect, the result is already written.
+func (run *Runner) AddPostWriteHook(hooks ...ResultHook) {
+	run.postWriteHooks = append(run.postWriteHooks, hooks...)
+}
+
+// runHooks passes a result through all registered hooks. If a hook
+// returns ErrSkipResult, the remaining hooks are not called.
+func (run *Runner) runHooks(result *models.Result) error {
+	for _, hook := range run.hooks {
+		if err := hook.Process(result); err != nil {
+			if errors.Is(err, ErrSkipResult) {
+				return err
+			}
+
+			run.log.Error("result hook failed", "target", result.URL, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// startPostWriteHooks starts Scan.HookConcurrency workers that pass
+// written results through the post-write hooks, so slow hooks (e.g. shell
+// commands or webhooks) do not hold up the writers.
+func (run *Runner) startPostWriteHooks() {
+	if len(run.postWriteHooks) == 0 {
+		return
+	}
+
+	concurrency := max(run.options.Scan.HookConcurrency, 1)
+	run.postWriteQueue = make(chan *models.Result, concurrency)
+	for range concurrency {
+		run.postWriteWg.Add(1)
+		go func() {
+			defer run.postWriteWg.Done()
+			for result := range run.postWriteQueue {
+				run.runPostWriteHooks(result)
+			}
+		}()
+	}
+}
+
+// stopPostWriteHooks waits for queued results to pass through the
+// post-write hooks and stops the workers
+func (run *Runner) stopPostWriteHooks() {
+	if run.postWriteQueue == nil {
+		return
+	}
+
+	close(run.postWriteQueue)
+	run.postWriteWg.Wait()
+	run.postWriteQueue = nil
+}
+
+// queuePostWriteHooks hands a written result to the post-write hook
+// workers, waiting for a free slot if they are all busy. Without running
+// workers, the hooks are called directly.
+func (run *Runner) queuePostWriteHooks(result *models.Result) {
+	if len(run.postWriteHooks) == 0 {
+		return
+	}
+
+	if run.postWriteQueue == nil {
+		run.runPostWriteHooks(result)
+		return
+	}
+
+	run.postWriteQueue <- result
+}
+
+// runPostWriteHooks passes a written result through all post-write hooks
+func (run *Runner) runPostWriteHooks(result *models.Result) {
+	for _, hook := range run.postWriteHooks {
+		if err := hook.Process(result); err != nil {
+			run.log.Error("post-write hook failed", "target", result.URL, "err", err)
+		}
+	}
+}
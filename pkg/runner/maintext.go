@@ -0,0 +1,266 @@
+package runner
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/models"
+	"golang.org/x/net/html"
+)
+
+// maxMainText 是记录的正文文本的最大字符数
+const maxMainText = 50000
+
+// minParagraphText 是一个段落参与正文评分所需的最少字符数
+const minParagraphText = 25
+
+// mainTextSkippedElements 是不包含正文的元素，提取时连同其子元素一起跳过
+var mainTextSkippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true,
+	"canvas": true, "iframe": true, "object": true, "embed": true, "nav": true,
+	"header": true, "footer": true, "aside": true, "form": true, "button": true,
+	"select": true, "input": true, "textarea": true, "dialog": true, "menu": true,
+}
+
+// mainTextBlockElements 是提取文本时另起一行的块级元素
+var mainTextBlockElements = map[string]bool{
+	"address": true, "article": true, "blockquote": true, "dd": true, "div": true,
+	"dl": true, "dt": true, "figcaption": true, "figure": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "hr": true, "li": true, "main": true,
+	"ol": true, "p": true, "pre": true, "section": true, "table": true, "td": true,
+	"th": true, "tr": true, "ul": true, "br": true,
+}
+
+// mainTextParagraphElements 是参与正文评分的段落元素
+var mainTextParagraphElements = map[string]bool{
+	"p": true, "pre": true, "blockquote": true, "td": true, "li": true, "dd": true,
+}
+
+var (
+	// mainTextUnlikely 匹配通常是样板内容（导航、广告、分享按钮等）的元素
+	// class 和 id
+	mainTextUnlikely = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|consent|disqus|extra|foot|header|legends|menu|modal|nav|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|ad-break|agegate|pagination|pager|advert`)
+	// mainTextLikely 匹配可能是正文的元素 class 和 id，优先于 mainTextUnlikely。
+	// 只匹配完整的单词（例如 post-content），使 "brand"、"domain" 或
+	// "context" 这样的名称不会让样板内容被保留。
+	mainTextLikely = regexp.MustCompile(`(?i)(^|[^a-z])(article|body|column|content|main|shadow|story|entry|post|text)([^a-z]|$)`)
+)
+
+// extractMainText 从结果的 HTML 中提取正文文本，去掉导航、广告和其他
+// 样板内容，记录在 MainText 中。
+//
+// 这里没有引入 go-readability，而是用 golang.org/x/net/html 实现了它的
+// 主要评分规则（class/id 权重和段落评分），避免为一个字段增加额外的依赖。
+func extractMainText(result *models.Result) {
+	if result.HTML == "" {
+		return
+	}
+
+	root, err := html.Parse(strings.NewReader(result.HTML))
+	if err != nil {
+		return
+	}
+
+	text := mainText(root)
+	if runes := []rune(text); len(runes) > maxMainText {
+		text = string(runes[:maxMainText])
+	}
+	result.MainText = text
+}
+
+// mainText 返回文档的正文文本。这是 readability 算法的简化版本：段落的
+// 文本长度和逗号数量计入其父元素（以及一半计入祖父元素）的分数，分数
+// 按链接文本的比例降低，得分最高的元素就是正文。没有段落时使用整个
+// <body> 的文本。
+func mainText(root *html.Node) string {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if mainTextSkipped(n) {
+				return
+			}
+
+			if mainTextParagraphElements[n.Data] {
+				text := strings.TrimSpace(nodeText(n))
+				if len([]rune(text)) >= minParagraphText {
+					score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")) +
+						min(float64(len([]rune(text)))/100, 3)
+
+					if parent := n.Parent; parent != nil {
+						if _, ok := scores[parent]; !ok {
+							candidates = append(candidates, parent)
+						}
+						scores[parent] += score
+
+						if grandparent := parent.Parent; grandparent != nil && grandparent.Type == html.ElementNode {
+							if _, ok := scores[grandparent]; !ok {
+								candidates = append(candidates, grandparent)
+							}
+							scores[grandparent] += score / 2
+						}
+					}
+				}
+			}
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	var best *html.Node
+	var bestScore float64
+	for _, candidate := range candidates {
+		score := scores[candidate] * (1 - linkDensity(candidate))
+		if candidate.Data == "article" || candidate.Data == "main" {
+			score *= 1.25
+		}
+
+		if best == nil || score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+
+	if best == nil {
+		best = findElement(root, "body")
+		if best == nil {
+			return ""
+		}
+	}
+
+	var builder strings.Builder
+	blockText(best, &builder)
+
+	var lines []string
+	for _, line := range strings.Split(builder.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// mainTextSkipped 检查元素是否是隐藏的或可能是样板内容
+func mainTextSkipped(n *html.Node) bool {
+	if mainTextSkippedElements[n.Data] {
+		return true
+	}
+
+	if _, ok := htmlAttr(n, "hidden"); ok {
+		return true
+	}
+	if value, _ := htmlAttr(n, "aria-hidden"); value == "true" {
+		return true
+	}
+	if style, _ := htmlAttr(n, "style"); strings.Contains(strings.ReplaceAll(style, " ", ""), "display:none") {
+		return true
+	}
+	if role, _ := htmlAttr(n, "role"); role == "navigation" || role == "banner" || role == "contentinfo" || role == "complementary" {
+		return true
+	}
+
+	// <body> 和正文容器即使 class 看起来像样板内容也不跳过
+	if n.Data == "body" || n.Data == "html" || n.Data == "article" || n.Data == "main" {
+		return false
+	}
+
+	class, _ := htmlAttr(n, "class")
+	id, _ := htmlAttr(n, "id")
+	match := class + " " + id
+
+	return mainTextUnlikely.MatchString(match) && !mainTextLikely.MatchString(match)
+}
+
+// nodeText 返回元素中未被跳过的文本
+func nodeText(n *html.Node) string {
+	var builder strings.Builder
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			builder.WriteString(n.Data)
+		case html.ElementNode:
+			if mainTextSkipped(n) {
+				return
+			}
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+
+	return builder.String()
+}
+
+// linkDensity 返回元素文本中链接文本所占的比例
+func linkDensity(n *html.Node) float64 {
+	total := len([]rune(strings.TrimSpace(nodeText(n))))
+	if total == 0 {
+		return 0
+	}
+
+	var links int
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			links += len([]rune(strings.TrimSpace(nodeText(n))))
+			return
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+
+	return min(float64(links)/float64(total), 1)
+}
+
+// blockText 将元素的文本写入 builder，块级元素另起一行
+func blockText(n *html.Node, builder *strings.Builder) {
+	switch n.Type {
+	case html.TextNode:
+		builder.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if mainTextSkipped(n) {
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && mainTextBlockElements[n.Data]
+	if block {
+		builder.WriteString("\n")
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		blockText(child, builder)
+	}
+
+	if block {
+		builder.WriteString("\n")
+	}
+}
+
+// findElement 返回第一个指定名称的元素
+func findElement(n *html.Node, name string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == name {
+		return n
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, name); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// paragraph is long enough to count towards the main text score
+const paragraph = "This paragraph is part of the article, and it is long enough, with commas, to be scored."
+
+func TestExtractMainText(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		want    []string
+		wantNot []string
+	}{
+		{
+			name: "Test with article and boilerplate",
+			html: `<html><body>
+				<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+				<header>Site header</header>
+				<article><h1>Title</h1><p>` + paragraph + `</p><p>` + paragraph + `</p></article>
+				<footer>Copyright footer</footer>
+				<script>var tracking = true;</script>
+			</body></html>`,
+			want:    []string{"Title", paragraph},
+			wantNot: []string{"Home", "Site header", "Copyright footer", "tracking"},
+		},
+		{
+			name: "Test with boilerplate classes containing likely words",
+			html: `<html><body><div class="post-content">
+				<div class="sidebar-brand">Sidebar brand links</div>
+				<div class="banner landing">Landing banner text</div>
+				<div class="menu command">Command menu</div>
+				<p>` + paragraph + `</p><p>` + paragraph + `</p>
+			</div></body></html>`,
+			want:    []string{paragraph},
+			wantNot: []string{"Sidebar brand", "Landing banner", "Command menu"},
+		},
+		{
+			name: "Test with likely class overriding unlikely class",
+			html: `<html><body>
+				<div class="comment-body"><p>` + paragraph + `</p><p>` + paragraph + `</p></div>
+			</body></html>`,
+			want: []string{paragraph},
+		},
+		{
+			name: "Test with hidden elements",
+			html: `<html><body><main>
+				<p>` + paragraph + `</p>
+				<p hidden>Hidden attribute text that is long enough to score.</p>
+				<p style="display: none">Hidden style text that is long enough to score.</p>
+				<div aria-hidden="true">Hidden from assistive technology</div>
+			</main></body></html>`,
+			want:    []string{paragraph},
+			wantNot: []string{"Hidden attribute", "Hidden style", "assistive technology"},
+		},
+		{
+			name: "Test with link list and content",
+			html: `<html><body>
+				<div><p><a href="/1">A link that is long enough to be a paragraph</a></p><p><a href="/2">Another link that is long enough too</a></p></div>
+				<div><p>` + paragraph + `</p></div>
+			</body></html>`,
+			want:    []string{paragraph},
+			wantNot: []string{"A link that"},
+		},
+		{
+			name:    "Test without paragraphs",
+			html:    `<html><body><div>Login</div><div>Password</div><script>x()</script></body></html>`,
+			want:    []string{"Login\nPassword"},
+			wantNot: []string{"x()"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &models.Result{HTML: tt.html}
+			extractMainText(result)
+
+			for _, want := range tt.want {
+				if !strings.Contains(result.MainText, want) {
+					t.Errorf("extractMainText() =>\n\nhave: %q\nwant it to contain %q", result.MainText, want)
+				}
+			}
+			for _, unwanted := range tt.wantNot {
+				if strings.Contains(result.MainText, unwanted) {
+					t.Errorf("extractMainText() =>\n\nhave: %q\nwant it not to contain %q", result.MainText, unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestMainTextLikely(t *testing.T) {
+	tests := []struct {
+		match string
+		want  bool
+	}{
+		{match: "post-content", want: true},
+		{match: "main_column", want: true},
+		{match: "article", want: true},
+		{match: "brand", want: false},
+		{match: "landing", want: false},
+		{match: "command", want: false},
+		{match: "domain", want: false},
+		{match: "context-menu", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.match, func(t *testing.T) {
+			if got := mainTextLikely.MatchString(tt.match); got != tt.want {
+				t.Errorf("mainTextLikely.MatchString() =>\n\nhave: %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
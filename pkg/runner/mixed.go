@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// insecureSchemes 是 HTTPS 页面中被视为混合内容的子资源协议
+var insecureSchemes = []string{"http", "ws"}
+
+// detectMixedContent 将 HTTPS 页面的网络日志中使用不安全协议的子资源
+// 记录为混合内容。主请求本身（例如重定向到 HTTPS 之前的 http:// 目标）
+// 不算作子资源。
+func detectMixedContent(result *models.Result) {
+	primary := result.FinalURL
+	if primary == "" {
+		primary = result.URL
+	}
+
+	u, err := url.Parse(primary)
+	if err != nil || !strings.EqualFold(u.Scheme, "https") {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range result.Network {
+		if entry.URL == result.URL || seen[entry.URL] {
+			continue
+		}
+
+		scheme, _, found := strings.Cut(entry.URL, ":")
+		if !found {
+			continue
+		}
+
+		for _, insecure := range insecureSchemes {
+			if strings.EqualFold(scheme, insecure) {
+				seen[entry.URL] = true
+				result.MixedContentURLs = append(result.MixedContentURLs, models.MixedContentURL{URL: entry.URL})
+				break
+			}
+		}
+	}
+
+	result.MixedContent = len(result.MixedContentURLs) > 0
+}
@@ -3,23 +3,26 @@ package runner
 // Options 是 gowitness 的全局选项
 type Options struct {
 	// Logging 是日志相关选项
-	Logging Logging
+	Logging Logging `yaml:"logging"`
 	// Chrome 是 Chrome 相关选项
-	Chrome Chrome
+	Chrome Chrome `yaml:"chrome"`
 	// Writer 是输出选项
-	Writer Writer
+	Writer Writer `yaml:"writer"`
 	// Scan 是扫描相关选项
-	Scan Scan
+	Scan Scan `yaml:"scan"`
 }
 
 // Logging 是日志相关选项
 type Logging struct {
 	// Debug 显示调试级别日志
-	Debug bool
+	Debug bool `yaml:"debug"`
 	// LogScanErrors 记录与扫描相关的错误
-	LogScanErrors bool
+	LogScanErrors bool `yaml:"log_scan_errors"`
 	// Silence 禁用所有日志
-	Silence bool
+	Silence bool `yaml:"silence"`
+	// OtlpEndpoint 是接收 OpenTelemetry 追踪数据的 OTLP/HTTP 端点，
+	// 例如 http://localhost:4318。为空时不进行追踪。
+	OtlpEndpoint string `yaml:"otlp_endpoint"`
 }
 
 // Chrome 是 Google Chrome 相关选项
@@ -27,86 +30,351 @@ type Chrome struct {
 	// Path 是 Chrome 二进制文件的路径。空值表示
 	// go-rod 将自动下载适合当前平台的二进制文件
 	// 来使用。
-	Path string
+	Path string `yaml:"path"`
 	// WSS 是 websocket URL。设置此值将阻止 gowitness
 	// 启动 Chrome，而是使用远程实例。
-	WSS string
-	// Proxy 要使用的代理服务器
-	Proxy string
+	WSS string `yaml:"wss"`
+	// WebDriverURL 是 webdriver 驱动使用的远程 W3C WebDriver 端点，
+	// 例如 Selenium Grid 的 http://localhost:4444/wd/hub
+	WebDriverURL string `yaml:"webdriver_url"`
+	// Proxy 要使用的代理服务器。chromedp 驱动中可以被目标行的
+	// proxy= 前缀按目标覆盖。
+	Proxy string `yaml:"proxy"`
 	// UserAgent 是要为 Chrome 设置的 user-agent 字符串
-	UserAgent string
+	UserAgent string `yaml:"user_agent"`
 	// Headers 是要添加到每个请求的头部
-	Headers []string
-	// WindowSize，以像素为单位。例如；X=1920,Y=1080
-	WindowX int
-	WindowY int
+	Headers []string `yaml:"headers"`
+	// ScannerHeader 是用于标识 gowitness 流量的头部（例如 "X-Scanner: gowitness"），
+	// 便于防御方在 WAF 或日志中关联。它总是最后应用，不会被 Headers 覆盖。
+	ScannerHeader string `yaml:"scanner_header"`
+	// WindowSize，以像素为单位。例如；X=1920,Y=1080。截图前视口和屏幕
+	// 大小总是显式地模拟为该大小，使截图尺寸不受无头模式的影响。
+	WindowX int `yaml:"window_x"`
+	WindowY int `yaml:"window_y"`
+	// WindowPosition 是浏览器窗口在屏幕上的位置，以像素为单位，
+	// 页面可以通过 window.screenX 和 window.screenY 读取
+	WindowPositionX int `yaml:"window_position_x"`
+	WindowPositionY int `yaml:"window_position_y"`
+	// ShowScrollbars 在截图中显示滚动条。默认隐藏滚动条，
+	// 使不同驱动的截图布局一致。
+	ShowScrollbars bool `yaml:"show_scrollbars"`
+	// DeviceScaleFactor 是设备像素比，例如 2 表示生成 retina 质量的截图。
+	// 截图的像素尺寸为窗口大小乘以该值。
+	DeviceScaleFactor float64 `yaml:"device_scale_factor"`
+	// GrantPermissions 是要自动授予的权限（例如 camera、microphone、
+	// geolocation、notifications）。默认拒绝所有权限请求。
+	GrantPermissions []string `yaml:"grant_permissions"`
+	// ClientCert 和 ClientKey 是用于双向 TLS 的 PEM 格式客户端证书和私钥。
+	// 设置后，Chrome 将通过一个本地代理访问目标，由代理出示客户端证书。
+	ClientCert string `yaml:"client_cert"`
+	ClientKey  string `yaml:"client_key"`
+	// ProfilePath 是一个已有的 Chrome 用户数据目录（user-data-dir）。
+	// 它会被复制到临时目录中使用，因此可以复用已登录的会话，且不会锁定或修改原目录。
+	ProfilePath string `yaml:"profile_path"`
+	// Geolocation 是要模拟的地理位置，格式为 "lat,lon[,accuracy]"。
+	// 设置后会自动授予 geolocation 权限。
+	Geolocation string `yaml:"geolocation"`
 }
 
 // Writer 选项
 type Writer struct {
-	Db        bool
-	DbURI     string
-	DbDebug   bool // 启用详细的数据库日志
-	Csv       bool
-	CsvFile   string
-	Jsonl     bool
-	JsonlFile string
-	Stdout    bool
-	None      bool
+	Db        bool   `yaml:"db"`
+	DbURI     string `yaml:"db_uri"`
+	DbDebug   bool   `yaml:"db_debug"` // 启用详细的数据库日志
+	Csv       bool   `yaml:"csv"`
+	CsvFile   string `yaml:"csv_file"`
+	Jsonl     bool   `yaml:"jsonl"`
+	JsonlFile string `yaml:"jsonl_file"`
+	Stdout    bool   `yaml:"stdout"`
+	None      bool   `yaml:"none"`
+	// Pem 将每个目标的 TLS 证书链写入以主机命名的 PEM 文件
+	Pem     bool   `yaml:"pem"`
+	PemPath string `yaml:"pem_path"`
+	// CsvColumns 是要写入的结果字段（例如 url,title,technologies）。
+	// 为空时写入所有非切片字段
+	CsvColumns []string `yaml:"csv_columns"`
+	// CsvSeparator 用于连接切片字段（例如 technologies、headers）的元素
+	CsvSeparator string `yaml:"csv_separator"`
+	// Nats 将结果以 JSON 发布到 NATS 主题
+	Nats          bool   `yaml:"nats"`
+	NatsURL       string `yaml:"nats_url"`
+	NatsSubject   string `yaml:"nats_subject"`
+	NatsBatchSize int    `yaml:"nats_batch_size"`
+	// NatsJetStream 等待 JetStream 对每条消息的确认
+	NatsJetStream bool `yaml:"nats_jetstream"`
+	// DbUpsertKey 是重新扫描时用于匹配已有结果的列（url 或 final_url）。
+	// 设置后，新结果替换已有结果并累计扫描次数，而不是插入重复的行。
+	DbUpsertKey string `yaml:"db_upsert_key"`
+	// DbBatchSize 是每个事务写入的结果数量。批量写入减少了每次提交的磁盘同步，
+	// 在线程数较多时明显提高 SQLite 的写入速度。
+	DbBatchSize int `yaml:"db_batch_size"`
 }
 
 // Scan 是扫描相关选项
 type Scan struct {
-	// Driver 是要使用的扫描驱动。可以是 [gorod, chromedp] 之一
-	Driver string
+	// Driver 是要使用的扫描驱动。可以是 [gorod, chromedp, webdriver] 之一
+	Driver string `yaml:"driver"`
 	// Threads（并非真正的线程）是要使用的 goroutines 数量。
 	// 更确切地说，这是我们将使用的 go-rod 页面池。
-	Threads int
+	Threads int `yaml:"threads"`
+	// Adaptive 启用自适应线程控制。启用后，Threads 是最大值，
+	// 运行器会根据失败率和资源压力在 AdaptiveMinThreads 和
+	// Threads 之间调整活动工作线程的数量。
+	Adaptive bool `yaml:"adaptive"`
+	// AdaptiveMinThreads 是自适应模式下的最小工作线程数量
+	AdaptiveMinThreads int `yaml:"adaptive_min_threads"`
 	// Timeout 是页面加载超时前的最长等待时间。
-	Timeout int
+	Timeout int `yaml:"timeout"`
+	// IdleTimeout 是导航期间没有任何网络活动时放弃的秒数，用于让
+	// 接受连接但从不发送数据的主机尽快失败。0 表示禁用。
+	IdleTimeout int `yaml:"idle_timeout"`
+	// PreflightTimeout 是启动浏览器之前 TCP 连接（以及 https 的 TLS 握手）
+	// 预检的超时秒数。无法到达的目标直接记为失败，不会启动 Chrome。
+	// 0 表示禁用预检。
+	PreflightTimeout int `yaml:"preflight_timeout"`
 	// Delay 是导航和截图之间的延迟秒数
-	Delay int
+	Delay int `yaml:"delay"`
+	// WaitUntil 是导航完成前要等待的页面生命周期事件。
+	// 可以是 [domcontentloaded, load, networkidle] 之一
+	WaitUntil string `yaml:"wait_until"`
+	// WaitAnimationFrames 是截图前至少等待的动画帧数量。等待这些帧之后，
+	// 还会继续等待到页面布局不再变化且没有运行中的有限 CSS 动画为止，
+	// 最多 5 秒。0 表示禁用。
+	WaitAnimationFrames int `yaml:"wait_animation_frames"`
 	// UriFilter 是可以处理的 URI。通常应该
 	// 是 http 和 https
-	UriFilter []string
+	UriFilter []string `yaml:"uri_filter"`
 	// SkipHTML 不写入 HTML 响应内容
-	SkipHTML bool
+	SkipHTML bool `yaml:"skip_html"`
 	// ScreenshotPath 是存储截图图像的路径。
 	// 空值表示驱动程序不会将截图写入磁盘。在
 	// 这种情况下，你需要指定写入器保存。
-	ScreenshotPath string
+	ScreenshotPath string `yaml:"screenshot_path"`
+	// ScreenshotRunSubdir 在 ScreenshotPath 下为每次运行创建一个以开始时间
+	// 命名的子目录（例如 screenshots/2024-06-01T12-00-00），避免重复运行互相覆盖。
+	// 应在创建驱动之前使用 RunSubdirectory 解析路径。
+	ScreenshotRunSubdir bool `yaml:"screenshot_run_subdir"`
 	// ScreenshotFormat 保存的截图格式
-	ScreenshotFormat string
+	ScreenshotFormat string `yaml:"screenshot_format"`
+	// ScreenshotStoreFormat 是截图在交给写入器和写入磁盘之前转换成的格式（jpeg 或 png）。
+	// 这样可以用无损的 png 截图计算感知哈希，同时以更紧凑的格式保存。
+	// 为空时不进行转换。
+	ScreenshotStoreFormat string `yaml:"screenshot_store_format"`
+	// ScreenshotStoreQuality 是转换为 jpeg 时使用的质量（1-100）
+	ScreenshotStoreQuality int `yaml:"screenshot_store_quality"`
+	// ScreenshotKeepOriginal 在转换格式或添加水印后同时在磁盘上保留原始截图
+	ScreenshotKeepOriginal bool `yaml:"screenshot_keep_original"`
 	// ScreenshotFullPage 保存完整的、滚动后的网页
-	ScreenshotFullPage bool
+	ScreenshotFullPage bool `yaml:"screenshot_full_page"`
 	// ScreenshotToWriter 将截图作为模型属性传递给写入器
-	ScreenshotToWriter bool
+	ScreenshotToWriter bool `yaml:"screenshot_to_writer"`
 	// ScreenshotSkipSave 跳过将截图保存到磁盘
-	ScreenshotSkipSave bool
+	ScreenshotSkipSave bool `yaml:"screenshot_skip_save"`
+	// ScreenshotInline 将 base64 编码的截图保存在结果中（数据库、CSV、JSON lines），
+	// 而不是截图目录中，使结果文件自包含。隐含 ScreenshotToWriter 和
+	// ScreenshotSkipSave，应在创建驱动之前应用。
+	ScreenshotInline bool `yaml:"screenshot_inline"`
+	// FilenameTemplate 是截图文件名模板（不含扩展名），可以包含 / 来创建子目录。
+	// 支持的占位符见 FilenameTokens。为空时使用清理后的目标 URL。
+	FilenameTemplate string `yaml:"filename_template"`
+	// BurstCount 是在首次截图之后额外截取的视口截图数量，
+	// 用于捕获轮播横幅或延迟弹窗等随时间变化的页面
+	BurstCount int `yaml:"burst_count"`
+	// BurstInterval 是连续截图之间的间隔（毫秒）
+	BurstInterval int `yaml:"burst_interval"`
+	// ScreenshotCompress 使用 gzip 压缩保存到磁盘的截图（例如 .jpeg.gz）
+	ScreenshotCompress bool `yaml:"screenshot_compress"`
+	// ScreenshotWatermark 是叠加在每张保存的截图上的水印文本（例如扫描时间、
+	// 操作者和目标 URL），使截图可以自证来源。支持的占位符见
+	// WatermarkTokens。为空时不添加水印。
+	ScreenshotWatermark string `yaml:"screenshot_watermark"`
+	// ScreenshotWatermarkPosition 是水印所在的角落，见 WatermarkPositions
+	ScreenshotWatermarkPosition string `yaml:"screenshot_watermark_position"`
 	// JavaScript 是要在每个页面上执行的 JavaScript
-	JavaScript     string
-	JavaScriptFile string
+	JavaScript     string `yaml:"javascript"`
+	JavaScriptFile string `yaml:"javascript_file"`
+	// JavaScriptReturn 调用 JavaScript 函数并将其返回值以 JSON 记录到结果中，
+	// 用于从每个页面提取数据（例如配置对象、CSRF 令牌或应用版本）
+	JavaScriptReturn bool `yaml:"javascript_return"`
+	// JavaScriptOnNewDocument 是在每个框架中任何页面脚本之前执行的 JavaScript，
+	// 可用于覆盖 navigator 或模拟 API 等必须在页面脚本之前完成的操作
+	JavaScriptOnNewDocument     string `yaml:"javascript_on_new_document"`
+	JavaScriptOnNewDocumentFile string `yaml:"javascript_on_new_document_file"`
 	// SaveContent 存储网络请求的内容（警告）这
 	// 可能会使写入的文件变得非常巨大
-	SaveContent bool
+	SaveContent bool `yaml:"save_content"`
+	// SaveContentTypes 限制只保存匹配这些 MIME 类型（或前缀，例如 text/）
+	// 的响应内容。为空时保存所有内容。设置后即隐含 SaveContent。
+	SaveContentTypes []string `yaml:"save_content_types"`
+	// ContentPath 是保存响应内容的目录。设置后，每个响应体在获取时就写入
+	// 以其 SHA-256 哈希命名的文件，结果中只记录文件名，而不是在内存中
+	// 积累所有内容直到结果被写入。用磁盘空间换取内存。
+	ContentPath string `yaml:"content_path"`
+	// SitemapFile 是写入站点地图 JSON 的文件。站点地图记录每个扫描页面中
+	// 的链接及其来源页面，无论链接本身是否被扫描，用于描绘站点的链接结构。
+	SitemapFile string `yaml:"sitemap_file"`
+	// CaptureInlineResources 在页面加载后查找 DOM 中引用的 data: 和 blob: 资源，
+	// 并将它们（大小、MIME 类型，以及按 SaveContent 规则保存的解码内容）记录到网络日志中
+	CaptureInlineResources bool `yaml:"capture_inline_resources"`
+	// CaptureWebSocketFrames 记录页面 WebSocket 连接上收发的文本帧
+	CaptureWebSocketFrames bool `yaml:"capture_websocket_frames"`
+	// WebSocketFrameMaxSize 是每个帧保存的最大字节数，超出部分被截断
+	WebSocketFrameMaxSize int `yaml:"websocket_frame_max_size"`
+	// WebSocketMaxBytes 是每个连接保存的最大字节数，达到后不再记录新的帧
+	WebSocketMaxBytes int `yaml:"websocket_max_bytes"`
+	// SaveNetworkHeaders 保存每个网络请求的请求和响应头部（会很冗长）
+	SaveNetworkHeaders bool `yaml:"save_network_headers"`
+	// ChallengeWait 是检测到 JavaScript 挑战页面（例如 Cloudflare 的
+	// "Checking your browser"）后等待其通过的秒数。如果仍未通过，会重新
+	// 导航一次并再次等待。0 表示禁用。
+	ChallengeWait int `yaml:"challenge_wait"`
+	// Shuffle 在分发前随机打乱目标顺序，避免同一主机的请求集中突发。
+	// 注意：这需要先读取所有目标。
+	Shuffle bool `yaml:"shuffle"`
+	// ShuffleSeed 是打乱顺序使用的随机种子。0 表示使用随机种子。
+	ShuffleSeed int64 `yaml:"shuffle_seed"`
+	// RunInfo 在运行结束时将运行的元数据（生效的选项、gowitness 和浏览器
+	// 版本、开始和结束时间、目标数量）以 JSON 写入截图路径
+	RunInfo bool `yaml:"run_info"`
+	// CheckpointFile 是以 JSON Lines 记录已完成目标的文件，用于在不使用
+	// 数据库的情况下恢复中断的扫描
+	CheckpointFile string `yaml:"checkpoint_file"`
+	// Resume 跳过 CheckpointFile 中已完成的目标，并继续追加记录，
+	// 而不是清空检查点文件
+	Resume bool `yaml:"resume"`
+	// MaxTargets 限制扫描的目标数量，达到后跳过剩余的目标。与 Shuffle
+	// 一起使用可以得到大型列表的随机样本。0 表示不限制。
+	MaxTargets int `yaml:"max_targets"`
+	// RetryAlternateScheme 在目标无法连接时，使用另一个协议
+	// （http 与 https 互换）重试一次
+	RetryAlternateScheme bool `yaml:"retry_alternate_scheme"`
+	// DedupeFinalURL 只保留重定向到同一最终 URL 的第一个结果
+	DedupeFinalURL bool `yaml:"dedupe_final_url"`
+	// StripQuery 在生成文件名和去重键时忽略查询字符串和片段，
+	// 结果中仍保留完整的原始 URL
+	StripQuery bool `yaml:"strip_query"`
+	// BlocklistHashFile 是包含"无意义"感知哈希的文件（例如停放页面）。
+	// 与其中任何哈希相近的结果会被丢弃，截图也会被删除。
+	BlocklistHashFile string `yaml:"blocklist_hash_file"`
+	// BlocklistHashThreshold 是被视为匹配的最大汉明距离
+	BlocklistHashThreshold int `yaml:"blocklist_hash_threshold"`
+	// SaveMatching 是一个正则表达式。设置后，只有标题或 HTML 匹配它的结果
+	// 才会被保存和写入。其他页面仍然会被访问和识别指纹，但结果会被丢弃，
+	// 截图也会被删除。
+	SaveMatching string `yaml:"save_matching"`
+	// Allowlist 是扫描范围的模式（主机、*.域名、CIDR 或 re:正则表达式）。
+	// 设置后只扫描匹配的目标。
+	Allowlist []string `yaml:"allowlist"`
+	// Denylist 是要跳过的目标模式，语法与 Allowlist 相同，
+	// 并且优先于 Allowlist
+	Denylist []string `yaml:"denylist"`
+	// DefaultPageSignatures 是一个 YAML 文件，包含额外的默认页面特征
+	// （name、titles、html），用于扩展内置的特征
+	DefaultPageSignatures string `yaml:"default_page_signatures"`
+	// SaveHar 为每个目标保存一个 HAR 文件
+	SaveHar bool `yaml:"save_har"`
+	// HarPath 是存储 HAR 文件的路径
+	HarPath string `yaml:"har_path"`
 	// Selector 是要截图的 CSS 选择器，为空时截取整个页面
-	Selector string
+	Selector string `yaml:"selector"`
+	// CaptureAroundText 截取页面中第一次出现该文本（不区分大小写，需要在
+	// 同一个文本节点中）的区域，而不是整个页面。找不到文本时截取整个页面。
+	CaptureAroundText string `yaml:"capture_around_text"`
+	// CaptureAroundTextPadding 是文本区域四周额外截取的像素
+	CaptureAroundTextPadding int `yaml:"capture_around_text_padding"`
+	// DisableJavaScript 禁用 JavaScript 执行和图像加载，并跳过截图。
+	// 这是一个只收集 HTML、头部等信息的快速清点模式。
+	DisableJavaScript bool `yaml:"disable_javascript"`
+	// ScrollToBottom 在截图前分步滚动到页面底部以触发懒加载的内容，
+	// 等待网络空闲后再滚动回顶部截图
+	ScrollToBottom bool `yaml:"scroll_to_bottom"`
+	// ScreenshotStatusDirs 将截图按主响应状态码保存到 ScreenshotPath 下的
+	// 子目录中，例如 2xx/、4xx/，没有状态码的保存到 failed/
+	ScreenshotStatusDirs bool `yaml:"screenshot_status_dirs"`
+	// MaxErrorRate 是允许失败的目标百分比（0-100）。扫描结束时超过该值
+	// 将以非零状态退出。为 0 时禁用。
+	MaxErrorRate float64 `yaml:"max_error_rate"`
+	// CookieConsent 是对检测到的 cookie 同意横幅（例如 OneTrust、Cookiebot）
+	// 在截图前执行的操作，可以是 accept 或 reject。为空时不处理。
+	CookieConsent string `yaml:"cookie_consent"`
+	// EmulatePrintMedia 在截图前模拟 print 媒体类型，使截图反映页面的打印样式
+	EmulatePrintMedia bool `yaml:"emulate_print_media"`
+	// Referer 是主导航请求发送的 referer，例如用于期望来自搜索引擎的深层链接。
+	// 页面中的其他请求不受影响。webdriver 驱动不支持。
+	Referer string `yaml:"referer"`
+	// PageMetrics 记录页面的 DOM 节点数量和传输的总字节数，
+	// 用于快速区分复杂的应用和简单的占位页面
+	PageMetrics bool `yaml:"page_metrics"`
+	// CapturePWA 记录页面注册的 service worker 和 <link rel=manifest> 链接的
+	// web 应用清单。等待 service worker 激活会增加每个目标的扫描时间。
+	CapturePWA bool `yaml:"capture_pwa"`
+	// NetworkThrottle 在导航前模拟慢速网络，可以是预设（slow-3g、fast-3g、
+	// offline），或 "latency,download,upload" 格式的自定义条件（毫秒和 kbit/s）。
+	// 可用于截取页面的加载状态或测试页面在弱网下的表现。
+	NetworkThrottle string `yaml:"network_throttle"`
+	// CaptureResourceTimings 记录页面 window.performance 的资源计时条目，
+	// 包括每个请求各阶段的耗时
+	CaptureResourceTimings bool `yaml:"capture_resource_timings"`
+	// CaptureRedirectHosts 将主请求重定向链中经过的中间主机（例如登录或 SSO
+	// 主机）作为新的目标截图，而不只是截取最终页面。每个主机只截图一次。
+	CaptureRedirectHosts bool `yaml:"capture_redirect_hosts"`
+	// CaptureLanguage 记录页面声明的语言（<html lang> 或 Content-Language
+	// meta 标签），用于按语言对结果分组
+	CaptureLanguage bool `yaml:"capture_language"`
+	// DetectLanguage 在页面没有声明语言时，根据页面文本猜测语言。
+	// 需要同时设置 CaptureLanguage。
+	DetectLanguage bool `yaml:"detect_language"`
+	// ExtractMainText 从页面 HTML 中提取去掉导航、广告和其他样板内容的
+	// 正文文本，用于全文搜索或对比页面内容
+	ExtractMainText bool `yaml:"extract_main_text"`
+	// ScrollPositions 是额外截图的垂直滚动位置列表，可以是像素偏移量
+	// （例如 800）或可滚动高度的百分比（例如 50%）。每个位置生成一张视口截图。
+	ScrollPositions []string `yaml:"scroll_positions"`
+	// Redact 是在结果持久化之前要屏蔽其值的头部或 cookie 名称，
+	// 例如用于扫描的认证 cookie 或 Authorization 头部
+	Redact []string `yaml:"redact"`
+	// AlertKeywords 是在页面标题或 HTML 中出现时需要发出警报的关键字
+	AlertKeywords []string `yaml:"alert_keywords"`
+	// HookCommand 是每个结果写入后运行的 shell 命令，是一个可以使用结果字段的
+	// text/template（例如 {{.URL}}）。结果字段同时作为 GOWITNESS_* 环境变量传递，
+	// 结果的 JSON 写入命令的标准输入。
+	HookCommand string `yaml:"hook_command"`
+	// HookWebhook 是每个结果写入后以 JSON POST 结果的 URL
+	HookWebhook string `yaml:"hook_webhook"`
+	// HookConcurrency 是运行写入后钩子（钩子命令或 webhook 请求）的工作线程数量
+	HookConcurrency int `yaml:"hook_concurrency"`
+	// SuccessStatusCodes 是被视为成功的响应状态码，可以是单个状态码（200）、
+	// 类别（2xx）或范围（200-399）。设置后，其他状态码的结果被标记为失败。
+	SuccessStatusCodes []string `yaml:"success_status_codes"`
+	// FailStatusCodes 是被视为失败的响应状态码，格式同 SuccessStatusCodes。
+	// 页面仍会被截图，只是结果被标记为失败，便于在报告中过滤错误页面。
+	FailStatusCodes []string `yaml:"fail_status_codes"`
 }
 
 // NewDefaultOptions 返回带有一些默认值的 Options
 func NewDefaultOptions() *Options {
 	return &Options{
 		Chrome: Chrome{
-			UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/128.0.0.0 Safari/537.36",
-			WindowX:   1920,
-			WindowY:   1080,
+			UserAgent:         "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/128.0.0.0 Safari/537.36",
+			WindowX:           1920,
+			WindowY:           1080,
+			DeviceScaleFactor: 1,
 		},
 		Scan: Scan{
-			Driver:           "chromedp",
-			Threads:          6,
-			Timeout:          60,
-			UriFilter:        []string{"http", "https"},
-			ScreenshotFormat: "jpeg",
+			Driver:                      "chromedp",
+			Threads:                     6,
+			AdaptiveMinThreads:          1,
+			Timeout:                     60,
+			WaitUntil:                   "load",
+			UriFilter:                   []string{"http", "https"},
+			ScreenshotFormat:            "jpeg",
+			ScreenshotStoreQuality:      80,
+			ScreenshotWatermarkPosition: "bottom-left",
+			BlocklistHashThreshold:      10,
+			WebSocketFrameMaxSize:       4096,
+			WebSocketMaxBytes:           65536,
+			CaptureAroundTextPadding:    100,
+			HookConcurrency:             4,
 		},
 		Logging: Logging{
 			Debug:         true,
@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// PanicError 表示驱动在探测目标时发生了 panic
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("driver panicked: %v", e.Value)
+}
+
+// driverWitness 调用驱动探测目标，并将驱动中的 panic 转换为 PanicError，
+// 这样单个目标不会导致整个扫描中止。
+func (run *Runner) driverWitness(ctx context.Context, target string) (result *models.Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	return run.Driver.Witness(ctx, target, run)
+}
+
+// safeWitness 探测目标，并从处理结果时（例如钩子或写入器中）发生的
+// panic 中恢复，使工作线程可以继续处理下一个目标。
+func (run *Runner) safeWitness(target string) (failed bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			run.log.Error("recovered from a panic while processing target", "target", target,
+				"panic", r, "stack", string(debug.Stack()))
+			failed = true
+		}
+	}()
+
+	return run.witness(target)
+}
+
+// writePanicResult 为驱动 panic 的目标写入一个失败的结果
+func (run *Runner) writePanicResult(target string, err *PanicError) {
+	result := &models.Result{
+		URL:          target,
+		ProbedAt:     time.Now(),
+		Failed:       true,
+		FailedReason: err.Error(),
+	}
+
+	run.runWriters(target, result)
+}
@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/targetline"
+)
+
+// preflight 在启动浏览器之前对目标做一次廉价的可达性检查：TCP 连接，
+// 对于 https 目标再进行一次 TLS 握手。无法到达的主机因此可以很快失败，
+// 而不必等待 Chrome 的导航超时。
+//
+// 使用代理时，目标只能通过代理访问，直接连接没有意义，所以跳过检查。
+func (run *Runner) preflight(ctx context.Context, target string) error {
+	t := targetline.Parse(target)
+	if t.Proxy != "" || run.options.Chrome.Proxy != "" {
+		return nil
+	}
+
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return err
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(run.options.Scan.PreflightTimeout)*time.Second)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if u.Scheme != "https" {
+		return nil
+	}
+
+	// 只检查主机是否能完成握手，证书由浏览器处理
+	client := tls.Client(conn, &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: true,
+	})
+
+	return client.HandshakeContext(ctx)
+}
+
+// preflightTarget 预检目标。启用了备用协议重试时，只要目标或其备用协议
+// 版本之一可以到达即可，由驱动决定最终使用哪一个。
+func (run *Runner) preflightTarget(ctx context.Context, target string) error {
+	err := run.preflight(ctx, target)
+	if err == nil || !run.options.Scan.RetryAlternateScheme {
+		return err
+	}
+
+	if alternate, ok := targetline.AlternateScheme(target); ok {
+		if run.preflight(ctx, alternate) == nil {
+			return nil
+		}
+	}
+
+	return err
+}
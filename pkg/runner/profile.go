@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfilePath 解析扫描配置文件的路径。
+//
+// name 可以是一个 YAML 文件的路径，也可以是保存在用户配置目录下的
+// 配置文件名称，例如 "internal" 对应 ~/.config/gowitness/profiles/internal.yaml。
+func ProfilePath(name string) (string, error) {
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+
+	if filepath.Ext(name) == "" && filepath.Base(name) == name {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+
+		for _, ext := range []string{".yaml", ".yml"} {
+			path := filepath.Join(configDir, "gowitness", "profiles", name+ext)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("scan profile %s not found", name)
+}
+
+// LoadProfile 将 YAML 扫描配置文件加载到 opts 中。
+//
+// 配置文件中没有设置的字段保持 opts 中现有的值，因此 opts 应该已经
+// 包含默认值。未知的字段会被视为错误，以便发现拼写错误。
+func LoadProfile(name string, opts *Options) error {
+	path, err := ProfilePath(name)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(opts); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("could not parse scan profile %s: %w", path, err)
+	}
+
+	return nil
+}
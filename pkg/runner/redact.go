@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// redactedValue 替换被脱敏的值
+const redactedValue = "[REDACTED]"
+
+// redactor 从结果中屏蔽指定名称的头部和 cookie 的值
+type redactor struct {
+	// names 是要脱敏的头部或 cookie 名称（小写）
+	names map[string]struct{}
+}
+
+// newRedactor 返回一个新的 redactor。如果没有要脱敏的名称，返回 nil。
+func newRedactor(names []string) *redactor {
+	if len(names) == 0 {
+		return nil
+	}
+
+	r := &redactor{names: make(map[string]struct{})}
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			r.names[strings.ToLower(name)] = struct{}{}
+		}
+	}
+
+	return r
+}
+
+// matches 返回 true 表示该名称需要脱敏
+func (r *redactor) matches(name string) bool {
+	_, ok := r.names[strings.ToLower(strings.TrimSpace(name))]
+	return ok
+}
+
+// redact 在结果被持久化之前屏蔽其中的敏感头部和 cookie。
+// 匹配的头部值和 cookie 值会被替换，Cookie 与 Set-Cookie 头部中
+// 匹配的 cookie 也会被屏蔽。
+func (r *redactor) redact(result *models.Result) {
+	if r == nil {
+		return
+	}
+
+	for i := range result.Headers {
+		result.Headers[i].Value = r.header(result.Headers[i].Key, result.Headers[i].Value)
+	}
+
+	for i := range result.Cookies {
+		if r.matches(result.Cookies[i].Name) {
+			result.Cookies[i].Value = redactedValue
+		}
+	}
+
+	for i := range result.Network {
+		for j := range result.Network[i].Headers {
+			header := &result.Network[i].Headers[j]
+			header.Value = r.header(header.Key, header.Value)
+		}
+	}
+}
+
+// header 返回头部值的脱敏版本
+func (r *redactor) header(key string, value string) string {
+	if r.matches(key) {
+		return redactedValue
+	}
+
+	switch strings.ToLower(key) {
+	case "cookie":
+		return r.cookies(value)
+	case "set-cookie":
+		// 多个 Set-Cookie 头部可能以换行符连接
+		lines := strings.Split(value, "\n")
+		for i, line := range lines {
+			attributes := strings.SplitN(line, ";", 2)
+			attributes[0] = r.cookies(attributes[0])
+			lines[i] = strings.Join(attributes, ";")
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	return value
+}
+
+// cookies 屏蔽以分号分隔的 name=value 对中匹配的 cookie
+func (r *redactor) cookies(value string) string {
+	pairs := strings.Split(value, ";")
+	for i, pair := range pairs {
+		name, _, found := strings.Cut(pair, "=")
+		if found && r.matches(name) {
+			pairs[i] = name + "=" + redactedValue
+		}
+	}
+
+	return strings.Join(pairs, ";")
+}
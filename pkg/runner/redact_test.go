@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+func TestRedactorHeader(t *testing.T) {
+	r := newRedactor([]string{"Authorization", " session "})
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  string
+	}{
+		{
+			name:  "Test with redacted header",
+			key:   "authorization",
+			value: "Bearer secret",
+			want:  redactedValue,
+		},
+		{
+			name:  "Test with other header",
+			key:   "Content-Type",
+			value: "text/html",
+			want:  "text/html",
+		},
+		{
+			name:  "Test with cookie header",
+			key:   "Cookie",
+			value: "theme=dark; session=secret; lang=en",
+			want:  "theme=dark; session=" + redactedValue + "; lang=en",
+		},
+		{
+			name:  "Test with set-cookie header",
+			key:   "Set-Cookie",
+			value: "session=secret; Path=/; HttpOnly",
+			want:  "session=" + redactedValue + "; Path=/; HttpOnly",
+		},
+		{
+			name:  "Test with multiple set-cookie headers",
+			key:   "set-cookie",
+			value: "theme=dark; Path=/\nSESSION=secret; Secure",
+			want:  "theme=dark; Path=/\nSESSION=" + redactedValue + "; Secure",
+		},
+		{
+			name:  "Test with set-cookie attribute named like a cookie",
+			key:   "Set-Cookie",
+			value: "theme=dark; session=attribute",
+			want:  "theme=dark; session=attribute",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.header(tt.key, tt.value)
+			if got != tt.want {
+				t.Errorf("header() =>\n\nhave: %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorCookies(t *testing.T) {
+	r := newRedactor([]string{"session"})
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{
+			name:  "Test with single cookie",
+			value: "session=secret",
+			want:  "session=" + redactedValue,
+		},
+		{
+			name:  "Test with unmatched cookies",
+			value: "theme=dark; lang=en",
+			want:  "theme=dark; lang=en",
+		},
+		{
+			name:  "Test with value containing equals",
+			value: "session=a=b; theme=dark",
+			want:  "session=" + redactedValue + "; theme=dark",
+		},
+		{
+			name:  "Test with pair without value",
+			value: "session; theme=dark",
+			want:  "session; theme=dark",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.cookies(tt.value)
+			if got != tt.want {
+				t.Errorf("cookies() =>\n\nhave: %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorRedact(t *testing.T) {
+	result := &models.Result{
+		Headers: []models.Header{{Key: "Authorization", Value: "Bearer secret"}},
+		Cookies: []models.Cookie{{Name: "session", Value: "secret"}, {Name: "theme", Value: "dark"}},
+		Network: []models.NetworkLog{
+			{Headers: []models.NetworkHeader{{Key: "Cookie", Value: "session=secret"}}},
+		},
+	}
+
+	newRedactor([]string{"authorization", "session"}).redact(result)
+
+	if result.Headers[0].Value != redactedValue {
+		t.Errorf("header =>\n\nhave: %v\nwant %v", result.Headers[0].Value, redactedValue)
+	}
+	if result.Cookies[0].Value != redactedValue || result.Cookies[1].Value != "dark" {
+		t.Errorf("cookies =>\n\nhave: %+v\nwant session redacted", result.Cookies)
+	}
+	if want := "session=" + redactedValue; result.Network[0].Headers[0].Value != want {
+		t.Errorf("network header =>\n\nhave: %v\nwant %v", result.Network[0].Headers[0].Value, want)
+	}
+
+	// a nil redactor leaves results untouched
+	var r *redactor
+	r.redact(result)
+	if newRedactor(nil) != nil {
+		t.Errorf("newRedactor(nil) is not nil")
+	}
+}
@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/targetline"
+)
+
+// maxRedirectTargets 是每次运行最多探测的重定向中间主机数量
+const maxRedirectTargets = 1000
+
+// redirectQueue 是从重定向链中发现的待探测目标。每个主机只加入队列一次。
+type redirectQueue struct {
+	mu      sync.Mutex
+	seen    map[string]struct{}
+	pending []string
+}
+
+// newRedirectQueue 返回一个新的重定向目标队列
+func newRedirectQueue() *redirectQueue {
+	return &redirectQueue{seen: make(map[string]struct{})}
+}
+
+// push 将目标加入队列。如果目标之前已经见过或者达到了数量上限，返回 false。
+func (q *redirectQueue) push(target string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.seen[target]; ok || len(q.seen) >= maxRedirectTargets {
+		return false
+	}
+	q.seen[target] = struct{}{}
+	q.pending = append(q.pending, target)
+
+	return true
+}
+
+// pop 从队列中取出下一个目标
+func (q *redirectQueue) pop() (string, bool) {
+	if q == nil {
+		return "", false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		return "", false
+	}
+	target := q.pending[0]
+	q.pending = q.pending[1:]
+
+	return target, true
+}
+
+// queueRedirectHosts 将结果重定向链中经过的中间主机的根 URL 加入队列。
+// 目标本身和最终页面的主机已经被截图，会被跳过。
+func (run *Runner) queueRedirectHosts(target string, result *models.Result) {
+	if run.redirects == nil {
+		return
+	}
+
+	skip := map[string]bool{
+		redirectOrigin(targetline.Parse(target).URL): true,
+		redirectOrigin(result.FinalURL):              true,
+	}
+
+	for _, redirect := range result.Redirects {
+		origin := redirectOrigin(redirect.URL)
+		if origin == "" || skip[origin] {
+			continue
+		}
+		skip[origin] = true
+
+		if run.redirects.push(origin + "/") {
+			run.log.Debug("queued redirect host", "target", target, "host", origin)
+		}
+	}
+}
+
+// detectCrossOriginRedirects 设置结果的重定向数量，并在重定向链中任一跳
+// 指向另一个源时标记跨源重定向。同一主机从 http 升级到 https 不算跨源。
+func detectCrossOriginRedirects(result *models.Result) {
+	result.RedirectCount = len(result.Redirects)
+
+	for _, redirect := range result.Redirects {
+		from, err := url.Parse(redirect.URL)
+		if err != nil {
+			continue
+		}
+		to, err := from.Parse(redirect.Location)
+		if err != nil {
+			continue
+		}
+
+		if !sameRedirectOrigin(from, to) {
+			result.CrossOriginRedirect = true
+			return
+		}
+	}
+}
+
+// sameRedirectOrigin 检查重定向的两端是否同源，默认端口视为相同，
+// 同一主机从 http 到 https 的升级也视为同源
+func sameRedirectOrigin(from, to *url.URL) bool {
+	if !strings.EqualFold(from.Hostname(), to.Hostname()) {
+		return false
+	}
+
+	fromScheme, toScheme := strings.ToLower(from.Scheme), strings.ToLower(to.Scheme)
+	if fromScheme == "http" && toScheme == "https" {
+		return redirectPort(from) == "80" && redirectPort(to) == "443"
+	}
+
+	return fromScheme == toScheme && redirectPort(from) == redirectPort(to)
+}
+
+// redirectPort 返回 URL 的端口，没有端口时返回协议的默认端口
+func redirectPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "ws":
+		return "80"
+	case "https", "wss":
+		return "443"
+	}
+
+	return ""
+}
+
+// redirectOrigin 返回 URL 的协议和主机（例如 https://sso.example.com），
+// 如果 URL 无效则返回空字符串
+func redirectOrigin(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
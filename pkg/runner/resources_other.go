@@ -0,0 +1,8 @@
+//go:build !unix
+
+package runner
+
+// fdPressure 在不支持的平台上始终返回 0
+func fdPressure() float64 {
+	return 0
+}
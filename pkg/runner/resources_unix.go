@@ -0,0 +1,24 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"syscall"
+)
+
+// fdPressure 返回当前进程已打开的文件描述符占软限制的比例。
+// 无法确定时返回 0。
+func fdPressure() float64 {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil || limit.Cur == 0 {
+		return 0
+	}
+
+	fds, err := os.ReadDir("/dev/fd")
+	if err != nil {
+		return 0
+	}
+
+	return float64(len(fds)) / float64(limit.Cur)
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sensepost/gowitness/internal/version"
+)
+
+// RunInfo 描述一次运行是如何产生的：使用的选项、gowitness 和浏览器的版本、
+// 开始和结束时间以及目标数量。与结果不同，它是运行本身的元数据，
+// 用于日后复现和审计一组截图。
+type RunInfo struct {
+	Version        string    `json:"version"`
+	GitHash        string    `json:"git_hash"`
+	GoVersion      string    `json:"go_version"`
+	Platform       string    `json:"platform"`
+	BrowserVersion string    `json:"browser_version"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+	Targets        int64     `json:"targets"`
+	Failed         int64     `json:"failed"`
+	Options        Options   `json:"options"`
+}
+
+// runInfo 返回本次运行的元数据
+func (run *Runner) runInfo(started time.Time, finished time.Time) RunInfo {
+	processed, failed := run.Stats()
+	browserVersion, _ := run.browserVersion.Load().(string)
+
+	return RunInfo{
+		Version:        version.Version,
+		GitHash:        version.GitHash,
+		GoVersion:      runtime.Version(),
+		Platform:       runtime.GOOS + "/" + runtime.GOARCH,
+		BrowserVersion: browserVersion,
+		StartedAt:      started,
+		FinishedAt:     finished,
+		Targets:        processed,
+		Failed:         failed,
+		Options:        run.redactedOptions(),
+	}
+}
+
+// writeRunInfo 将运行的元数据写入截图路径下以开始时间命名的 JSON 文件
+func (run *Runner) writeRunInfo(started time.Time, finished time.Time) error {
+	if err := os.MkdirAll(run.options.Scan.ScreenshotPath, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(run.runInfo(started, finished), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(run.options.Scan.ScreenshotPath, fmt.Sprintf("run-%s.json", started.Format(runSubdirectoryLayout)))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+
+	run.log.Info("wrote run info", "path", path)
+	return nil
+}
+
+// credentialHeaders 是运行信息中总是脱敏的额外头部（小写），
+// 即使没有用 --redact 指定
+var credentialHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"cookie":              {},
+	"x-api-key":           {},
+	"api-key":             {},
+	"x-auth-token":        {},
+	"x-access-token":      {},
+	"x-csrf-token":        {},
+}
+
+// redactedOptions 返回选项的副本，其中 URL 中的凭据、常见的凭据
+// 头部和需要脱敏的额外头部值被屏蔽
+func (run *Runner) redactedOptions() Options {
+	opts := run.options
+	opts.Writer.DbURI = redactURL(opts.Writer.DbURI)
+	opts.Writer.NatsURL = redactURL(opts.Writer.NatsURL)
+	opts.Chrome.Proxy = redactURL(opts.Chrome.Proxy)
+	opts.Chrome.WSS = redactURL(opts.Chrome.WSS)
+
+	opts.Chrome.Headers = append([]string(nil), opts.Chrome.Headers...)
+	for i, header := range opts.Chrome.Headers {
+		name, _, ok := strings.Cut(header, ":")
+		if !ok {
+			continue
+		}
+
+		_, credential := credentialHeaders[strings.ToLower(strings.TrimSpace(name))]
+		if credential || (run.redactor != nil && run.redactor.matches(name)) {
+			opts.Chrome.Headers[i] = name + ": " + redactedValue
+		}
+	}
+
+	return opts
+}
+
+// redactURL 屏蔽 URL 中的凭据。只有用户名时，用户名通常就是令牌，
+// 所以也一并屏蔽。
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+
+	if _, ok := u.User.Password(); !ok {
+		u.User = url.User("xxxxx")
+		return u.String()
+	}
+
+	return u.Redacted()
+}
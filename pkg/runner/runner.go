@@ -3,14 +3,21 @@ package runner
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/url"
 	"os"
+	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	wappalyzer "github.com/projectdiscovery/wappalyzergo"
 	"github.com/sensepost/gowitness/internal/islazy"
 	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/targetline"
+	"github.com/sensepost/gowitness/pkg/tracing"
 	"github.com/sensepost/gowitness/pkg/writers"
 )
 
@@ -18,11 +25,49 @@ import (
 type Runner struct {
 	Driver     Driver
 	Wappalyzer *wappalyzer.Wappalyze
+	// Tracer 记录每次探测的追踪数据，未配置时为 nil
+	Tracer *tracing.Tracer
 
 	// Runner 需要考虑的选项配置
 	options Options
 	// 要使用的结果写入器
 	writers []writers.Writer
+	// 在写入器之前处理结果的钩子
+	hooks []ResultHook
+	// 在写入器之后处理结果的钩子，以及运行期间把结果交给钩子工作池的队列
+	postWriteHooks []ResultHook
+	postWriteQueue chan *models.Result
+	postWriteWg    sync.WaitGroup
+	// 要丢弃的感知哈希黑名单
+	blocklist [][]byte
+	// 结果的标题或 HTML 需要匹配才会被保存的模式，未设置时为 nil
+	saveMatching *regexp.Regexp
+	// 用于识别默认页面的特征
+	defaultPages []DefaultPageSignature
+	// 扫描范围的允许列表和拒绝列表
+	allowlist *scopeList
+	denylist  *scopeList
+	// 已见的最终 URL，用于去重
+	finalURLs *finalURLSet
+	// 从重定向链中发现的待探测目标，未启用时为 nil
+	redirects *redirectQueue
+	// sitemap 收集页面中的链接，未启用时为 nil
+	sitemap *sitemap
+	// 屏蔽敏感头部和 cookie 的 redactor
+	redactor *redactor
+	// 记录已完成目标的检查点，未启用时为 nil
+	checkpoint *checkpoint
+	// 被视为成功或失败的响应状态码
+	successStatusCodes statusCodes
+	failStatusCodes    statusCodes
+	// 等待写入器写入的结果，以及还需要完成写入的写入器数量
+	pendingWrites map[*models.Result]*pendingWrite
+	pendingMutex  sync.Mutex
+	// 已处理和失败的目标数量
+	processed atomic.Int64
+	failed    atomic.Int64
+	// 产生结果的浏览器版本，用于运行元数据
+	browserVersion atomic.Value
 	// 日志处理器
 	log *slog.Logger
 
@@ -30,6 +75,17 @@ type Runner struct {
 	// 这通常由 gowitness/pkg/reader 提供。
 	Targets chan string
 
+	// Results 是可选的结果流，用于在不实现 Writer 接口的情况下处理结果。
+	// 默认为 nil（禁用）。要启用它，需要在 Run() 之前设置一个通道，例如
+	// make(chan *models.Result, 16)。
+	//
+	// 每个结果在所有写入器写入之后发送到通道，批量写入器排队的结果在其
+	// 批次写入后才会发送。发送会阻塞直到结果被接收，
+	// 因此消费者跟不上时，缓冲区填满后工作线程会暂停（背压），而不是
+	// 丢弃结果。消费者必须持续读取通道直到它被关闭：Run() 在所有目标
+	// 处理完成后关闭该通道。运行器被取消时，未发送的结果会被丢弃。
+	Results chan *models.Result
+
 	// 用于需要退出的情况
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -49,11 +105,180 @@ func NewRunner(logger *slog.Logger, driver Driver, opts Options, writers []write
 		logger.Debug("not saving screenshots to disk")
 	}
 
+	if opts.Scan.ContentPath != "" {
+		if !opts.Scan.SaveContent && len(opts.Scan.SaveContentTypes) == 0 {
+			return nil, errors.New("a content path needs content saving to be enabled")
+		}
+
+		contentPath, err := islazy.CreateDir(opts.Scan.ContentPath)
+		if err != nil {
+			return nil, err
+		}
+		opts.Scan.ContentPath = contentPath
+		logger.Debug("final content path", "content-path", opts.Scan.ContentPath)
+	}
+
+	if opts.Scan.SaveHar {
+		harPath, err := islazy.CreateDir(opts.Scan.HarPath)
+		if err != nil {
+			return nil, err
+		}
+		opts.Scan.HarPath = harPath
+		logger.Debug("final har path", "har-path", opts.Scan.HarPath)
+	}
+
 	// 截图格式检查
 	if !islazy.SliceHasStr([]string{"jpeg", "png"}, opts.Scan.ScreenshotFormat) {
 		return nil, errors.New("invalid screenshot format")
 	}
 
+	// 截图保存格式检查
+	if opts.Scan.ScreenshotStoreFormat != "" {
+		if !islazy.SliceHasStr([]string{"jpeg", "png"}, opts.Scan.ScreenshotStoreFormat) {
+			return nil, errors.New("invalid screenshot store format")
+		}
+		if opts.Scan.ScreenshotStoreQuality < 1 || opts.Scan.ScreenshotStoreQuality > 100 {
+			return nil, errors.New("screenshot store quality must be between 1 and 100")
+		}
+	}
+
+	// 窗口大小检查
+	if opts.Chrome.WindowX <= 0 || opts.Chrome.WindowY <= 0 {
+		return nil, errors.New("window size must be more than 0")
+	}
+
+	// 设备像素比检查
+	if opts.Chrome.DeviceScaleFactor <= 0 {
+		return nil, errors.New("device scale factor must be more than 0")
+	}
+
+	// 浏览器配置文件检查
+	if opts.Chrome.ProfilePath != "" {
+		if opts.Chrome.WSS != "" || opts.Scan.Driver == "webdriver" {
+			return nil, errors.New("a chrome profile path can only be used with a locally launched browser")
+		}
+		if info, err := os.Stat(opts.Chrome.ProfilePath); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("chrome profile path %q is not a directory", opts.Chrome.ProfilePath)
+		}
+	}
+
+	// cookie 同意操作检查
+	if opts.Scan.CookieConsent != "" && !islazy.SliceHasStr([]string{"accept", "reject"}, opts.Scan.CookieConsent) {
+		return nil, errors.New("invalid cookie consent action")
+	}
+
+	// WebSocket 帧大小限制检查
+	if opts.Scan.CaptureWebSocketFrames && (opts.Scan.WebSocketFrameMaxSize < 1 || opts.Scan.WebSocketMaxBytes < 1) {
+		return nil, errors.New("websocket frame and connection byte limits must be more than 0")
+	}
+
+	// 错误率阈值检查
+	if opts.Scan.MaxErrorRate < 0 || opts.Scan.MaxErrorRate > 100 {
+		return nil, errors.New("max error rate must be between 0 and 100")
+	}
+
+	// 目标数量上限检查
+	if opts.Scan.MaxTargets < 0 {
+		return nil, errors.New("max targets cannot be negative")
+	}
+
+	// 空闲超时检查
+	if opts.Scan.IdleTimeout < 0 {
+		return nil, errors.New("idle timeout cannot be negative")
+	}
+
+	// 预检超时检查
+	if opts.Scan.PreflightTimeout < 0 {
+		return nil, errors.New("preflight timeout cannot be negative")
+	}
+
+	// 动画帧等待检查
+	if opts.Scan.WaitAnimationFrames < 0 {
+		return nil, errors.New("animation frames to wait for cannot be negative")
+	}
+
+	// 导航等待条件检查
+	if !islazy.SliceHasStr([]string{"domcontentloaded", "load", "networkidle"}, opts.Scan.WaitUntil) {
+		return nil, errors.New("invalid wait-until condition")
+	}
+
+	// 自适应线程范围检查
+	if opts.Scan.Adaptive {
+		if opts.Scan.AdaptiveMinThreads < 1 {
+			opts.Scan.AdaptiveMinThreads = 1
+		}
+		if opts.Scan.AdaptiveMinThreads > opts.Scan.Threads {
+			return nil, errors.New("adaptive minimum threads cannot be more than threads")
+		}
+	}
+
+	// 扫描器标识头部检查
+	if opts.Chrome.ScannerHeader != "" {
+		kv := strings.SplitN(opts.Chrome.ScannerHeader, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, errors.New("scanner header must be in the format Name: Value")
+		}
+	}
+
+	// 地理位置检查
+	if opts.Chrome.Geolocation != "" {
+		if _, err := ParseGeolocation(opts.Chrome.Geolocation); err != nil {
+			return nil, err
+		}
+	}
+
+	// 网络条件检查
+	if opts.Scan.NetworkThrottle != "" {
+		if _, err := ParseNetworkThrottle(opts.Scan.NetworkThrottle); err != nil {
+			return nil, err
+		}
+	}
+
+	// 文件名模板检查
+	if opts.Scan.FilenameTemplate != "" {
+		if _, err := ExpandFilenameTemplate(opts.Scan.FilenameTemplate, "https://example.com:8443/path", time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	// 截图水印检查
+	if opts.Scan.ScreenshotWatermark != "" {
+		if !islazy.SliceHasStr(WatermarkPositions, opts.Scan.ScreenshotWatermarkPosition) {
+			return nil, fmt.Errorf("invalid screenshot watermark position, valid positions are: %s", strings.Join(WatermarkPositions, ", "))
+		}
+		if _, err := ExpandWatermark(opts.Scan.ScreenshotWatermark, "https://example.com:8443/path", 200, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	// 文本区域截图检查
+	if opts.Scan.CaptureAroundText != "" && opts.Scan.Selector != "" {
+		return nil, errors.New("capturing around a text cannot be combined with a selector")
+	}
+	if opts.Scan.CaptureAroundTextPadding < 0 {
+		return nil, errors.New("capture around text padding cannot be negative")
+	}
+
+	// 连续截图检查
+	if opts.Scan.BurstCount < 0 || (opts.Scan.BurstCount > 0 && opts.Scan.BurstInterval <= 0) {
+		return nil, errors.New("burst count cannot be negative and burst interval must be positive")
+	}
+
+	// 滚动位置检查
+	if _, err := ParseScrollPositions(opts.Scan.ScrollPositions); err != nil {
+		return nil, err
+	}
+
+	// 成功和失败状态码检查
+	successStatusCodes, err := parseStatusCodes(opts.Scan.SuccessStatusCodes)
+	if err != nil {
+		return nil, err
+	}
+	failStatusCodes, err := parseStatusCodes(opts.Scan.FailStatusCodes)
+	if err != nil {
+		return nil, err
+	}
+
 	// 包含要在每个页面上执行的 JavaScript 的文件。
 	// 直接读取并将值设置到 Scan.JavaScript。
 	if opts.Scan.JavaScriptFile != "" {
@@ -65,40 +290,238 @@ func NewRunner(logger *slog.Logger, driver Driver, opts Options, writers []write
 		opts.Scan.JavaScript = string(javascript)
 	}
 
+	// JavaScript 返回值检查
+	if opts.Scan.JavaScriptReturn && opts.Scan.JavaScript == "" {
+		return nil, errors.New("capturing the javascript return value needs javascript to evaluate")
+	}
+
+	// 站点地图检查
+	if opts.Scan.SitemapFile != "" && opts.Scan.SkipHTML {
+		return nil, errors.New("a sitemap needs the page html, which skipping html disables")
+	}
+
+	// 正文提取检查
+	if opts.Scan.ExtractMainText && opts.Scan.SkipHTML {
+		return nil, errors.New("extracting the main text needs the page html, which skipping html disables")
+	}
+
+	// 检查点检查
+	if opts.Scan.Resume && opts.Scan.CheckpointFile == "" {
+		return nil, errors.New("resuming a scan needs a checkpoint file")
+	}
+
+	// 语言检测检查
+	if opts.Scan.DetectLanguage && !opts.Scan.CaptureLanguage {
+		return nil, errors.New("detecting the page language needs language capture to be enabled")
+	}
+
+	// 感知哈希黑名单
+	var blocklist [][]byte
+	if opts.Scan.BlocklistHashFile != "" {
+		hashes, err := loadBlocklistHashes(opts.Scan.BlocklistHashFile)
+		if err != nil {
+			return nil, err
+		}
+		blocklist = hashes
+		logger.Debug("loaded perception hash blocklist", "hashes", len(blocklist))
+	}
+
+	// 保存匹配模式
+	var saveMatching *regexp.Regexp
+	if opts.Scan.SaveMatching != "" {
+		pattern, err := regexp.Compile(opts.Scan.SaveMatching)
+		if err != nil {
+			return nil, fmt.Errorf("invalid save matching regular expression: %w", err)
+		}
+		saveMatching = pattern
+	}
+
+	// 扫描范围
+	allowlist, err := parseScopeList(opts.Scan.Allowlist)
+	if err != nil {
+		return nil, err
+	}
+	denylist, err := parseScopeList(opts.Scan.Denylist)
+	if err != nil {
+		return nil, err
+	}
+
+	// 默认页面特征
+	defaultPages, err := loadDefaultPageSignatures(opts.Scan.DefaultPageSignatures)
+	if err != nil {
+		return nil, err
+	}
+
 	// 获取 wappalyzer 实例
 	wap, err := wappalyzer.New()
 	if err != nil {
 		return nil, err
 	}
 
+	// 配置 OpenTelemetry 追踪
+	var tracer *tracing.Tracer
+	if opts.Logging.OtlpEndpoint != "" {
+		tracer, err = tracing.NewTracer(opts.Logging.OtlpEndpoint, "gowitness")
+		if err != nil {
+			return nil, err
+		}
+		logger.Debug("exporting traces", "endpoint", opts.Logging.OtlpEndpoint)
+	}
+
+	// 打开检查点文件
+	checkpoint, err := openCheckpoint(opts.Scan.CheckpointFile, opts.Scan.Resume)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Scan.Resume {
+		logger.Info("resuming from checkpoint", "checkpoint-file", opts.Scan.CheckpointFile, "completed", checkpoint.size())
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Runner{
-		Driver:     driver,
-		Wappalyzer: wap,
-		options:    opts,
-		writers:    writers,
-		Targets:    make(chan string),
-		log:        logger,
-		ctx:        ctx,
-		cancel:     cancel,
-	}, nil
+	// 重定向中间主机队列
+	var redirects *redirectQueue
+	if opts.Scan.CaptureRedirectHosts {
+		redirects = newRedirectQueue()
+	}
+
+	run := &Runner{
+		Driver:       driver,
+		Wappalyzer:   wap,
+		Tracer:       tracer,
+		blocklist:    blocklist,
+		saveMatching: saveMatching,
+		defaultPages: defaultPages,
+		allowlist:    allowlist,
+		denylist:     denylist,
+		finalURLs:    newFinalURLSet(opts.Scan.StripQuery),
+		redirects:    redirects,
+		sitemap:      newSitemap(opts.Scan.SitemapFile),
+		redactor:     newRedactor(opts.Scan.Redact),
+		checkpoint:   checkpoint,
+		options:      opts,
+		writers:      writers,
+		Targets:      make(chan string),
+		log:          logger,
+		ctx:          ctx,
+		cancel:       cancel,
+
+		successStatusCodes: successStatusCodes,
+		failStatusCodes:    failStatusCodes,
+		pendingWrites:      make(map[*models.Result]*pendingWrite),
+	}
+
+	run.watchBatchers()
+
+	return run, nil
 }
 
-// runWriters 获取结果并将其传递给写入器
-func (run *Runner) runWriters(result *models.Result) error {
+// pendingWrite 是一个等待写入器写入的结果
+type pendingWrite struct {
+	// target 是产生结果的目标，结果写入后被记录到检查点
+	target string
+	// remaining 是还未完成写入的写入器数量，加上 runWriters 自身持有的一个
+	remaining int
+	errs      []error
+}
+
+// runWriters 将结果传递给每个写入器。批量写入器（writers.Batcher）可能在之后
+// 才写入结果，所以结果在所有写入器完成写入后才会传递给写入后的钩子和 Results
+// 结果流，并记录到检查点，见 writeDone。一个写入器失败不会阻止其他写入器
+// 写入结果。
+func (run *Runner) runWriters(target string, result *models.Result) {
+	run.checkpoint.hold(target)
+
+	run.pendingMutex.Lock()
+	run.pendingWrites[result] = &pendingWrite{target: target, remaining: len(run.writers) + 1}
+	run.pendingMutex.Unlock()
+
 	for _, writer := range run.writers {
-		if err := writer.Write(result); err != nil {
-			return err
+		err := writer.Write(result)
+
+		// 批量写入器返回 nil 时结果已排队，写入后会通过 OnWritten 通知
+		if _, ok := writer.(writers.Batcher); ok && err == nil {
+			continue
 		}
+		run.writeDone(writer, result, err)
 	}
 
-	return nil
+	run.writeDone(nil, result, nil)
+}
+
+// writeDone 记录一个写入器已写入结果，或放弃写入。所有写入器完成后，结果被
+// 传递给写入后的钩子，目标被记录到检查点，然后结果被发送到 Results 结果流。
+// 所有错误会被单独记录。
+func (run *Runner) writeDone(writer writers.Writer, result *models.Result, err error) {
+	run.pendingMutex.Lock()
+	write, ok := run.pendingWrites[result]
+	if !ok {
+		run.pendingMutex.Unlock()
+		return
+	}
+
+	if err != nil {
+		run.log.Error("writer failed to write result", "writer", fmt.Sprintf("%T", writer),
+			"target", result.URL, "err", err)
+		write.errs = append(write.errs, fmt.Errorf("%T: %w", writer, err))
+	}
+
+	write.remaining--
+	if write.remaining > 0 {
+		run.pendingMutex.Unlock()
+		return
+	}
+	delete(run.pendingWrites, result)
+	run.pendingMutex.Unlock()
+
+	// 每个写入器的错误已单独记录，这里汇总报告结果没能写入哪些写入器
+	if len(write.errs) > 0 {
+		run.log.Error("one or more writers failed for target", "target", result.URL,
+			"failed", len(write.errs), "writers", len(run.writers), "err", errors.Join(write.errs...))
+	}
+
+	// 通知写入后的钩子，慢速的钩子在工作池中运行，不会阻塞写入
+	run.queuePostWriteHooks(result)
+
+	// 没能写入的结果的目标在恢复时会被重新探测
+	if err := run.checkpoint.release(write.target, len(write.errs) == 0); err != nil {
+		run.log.Error("could not write checkpoint", "target", write.target, "err", err)
+	}
+
+	// 将结果发送到结果流（如果启用）
+	if run.Results != nil {
+		select {
+		case run.Results <- result:
+		case <-run.ctx.Done():
+		}
+	}
+}
+
+// watchBatchers 让批量写入器在结果真正写入后通知运行器
+func (run *Runner) watchBatchers() {
+	for _, writer := range run.writers {
+		if batcher, ok := writer.(writers.Batcher); ok {
+			batcher.OnWritten(func(result *models.Result, err error) {
+				run.writeDone(writer, result, err)
+			})
+		}
+	}
+}
+
+// flushWriters 写入批量写入器中排队的结果，放弃仍然无法写入的结果
+func (run *Runner) flushWriters() {
+	for _, writer := range run.writers {
+		if batcher, ok := writer.(writers.Batcher); ok {
+			if err := batcher.Flush(); err != nil {
+				run.log.Error("could not flush writer", "writer", fmt.Sprintf("%T", writer), "err", err)
+			}
+		}
+	}
 }
 
 // checkUrl 确保 URL 有效
 func (run *Runner) checkUrl(target string) error {
-	url, err := url.ParseRequestURI(target)
+	url, err := url.ParseRequestURI(targetline.Parse(target).URL)
 	if err != nil {
 		return err
 	}
@@ -107,12 +530,75 @@ func (run *Runner) checkUrl(target string) error {
 		return errors.New("url contains invalid scheme")
 	}
 
+	if !run.inScope(url) {
+		return ErrOutOfScope
+	}
+
 	return nil
 }
 
 // Run 执行运行器，处理从 Targets 通道接收到的目标
 func (run *Runner) Run() {
 	wg := sync.WaitGroup{}
+	started := time.Now()
+
+	// 自适应模式下，由控制器决定同时有多少个工作线程处于活动状态
+	var controller *adaptiveController
+	if run.options.Scan.Adaptive {
+		controller = newAdaptiveController(run.log, run.options.Scan.AdaptiveMinThreads, run.options.Scan.Threads)
+
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-run.ctx.Done():
+				controller.wake()
+			case <-stop:
+			}
+		}()
+	}
+
+	// 工作线程消费的目标通道
+	var targets <-chan string = run.Targets
+	if run.options.Scan.Resume {
+		targets = run.skipCompletedTargets(targets)
+	}
+	if run.options.Scan.Shuffle {
+		targets = run.shuffleTargets(targets)
+	}
+	if run.options.Scan.MaxTargets > 0 {
+		targets = run.limitTargets(targets)
+	}
+
+	// process 探测一个目标并更新统计。返回 false 表示工作线程应该退出。
+	process := func(target string) bool {
+		if controller != nil && !controller.acquire(run.ctx) {
+			return false
+		}
+
+		run.checkpoint.hold(target)
+		failed := run.safeWitness(target)
+		run.processed.Add(1)
+		if failed {
+			run.failed.Add(1)
+		}
+
+		// 被取消的运行中的目标可能没有完成，不记录到检查点。有结果的目标
+		// 在结果写入后才会被记录，见 writeDone。
+		if err := run.checkpoint.release(target, run.ctx.Err() == nil); err != nil {
+			run.log.Error("could not write checkpoint", "target", target, "err", err)
+		}
+
+		if controller != nil {
+			controller.release(failed)
+		}
+
+		// 运行器可能已被取消（例如找不到 Chrome）
+		return run.ctx.Err() == nil
+	}
+
+	// 启动写入后钩子的工作池
+	run.startPostWriteHooks()
 
 	// 将生成 Scan.Threads 数量的 "工作线程" 作为 goroutines
 	for w := 0; w < run.options.Scan.Threads; w++ {
@@ -125,61 +611,369 @@ func (run *Runner) Run() {
 				select {
 				case <-run.ctx.Done():
 					return
-				case target, ok := <-run.Targets:
+				case target, ok := <-targets:
 					if !ok {
 						return
 					}
 
-					// 验证目标
-					if err := run.checkUrl(target); err != nil {
-						if run.options.Logging.LogScanErrors {
-							run.log.Error("invalid target to scan", "target", target, "err", err)
-						}
-						continue
+					if !process(target) {
+						return
 					}
 
-					result, err := run.Driver.Witness(target, run)
-					if err != nil {
-						// 这是 Chrome 未找到错误吗？
-						var chromeErr *ChromeNotFoundError
-						if errors.As(err, &chromeErr) {
-							run.log.Error("no valid chrome intallation found", "err", err)
-							run.cancel()
+					// 探测重定向链中发现的主机。目标只在探测时加入队列，
+					// 所以加入队列的工作线程总会在读取下一个目标之前清空它。
+					for redirect, ok := run.redirects.pop(); ok; redirect, ok = run.redirects.pop() {
+						if !process(redirect) {
 							return
 						}
-
-						if run.options.Logging.LogScanErrors {
-							run.log.Error("failed to witness target", "target", target, "err", err)
-						}
-						continue
 					}
+				}
+			}
 
-					// 假设状态码 0 表示没有信息，所以
-					// 不向写入器发送任何内容。
-					if result.ResponseCode == 0 {
-						if run.options.Logging.LogScanErrors {
-							run.log.Error("failed to witness target, status code was 0", "target", target)
-						}
-						continue
-					}
+		}()
+	}
 
-					if err := run.runWriters(result); err != nil {
-						run.log.Error("failed to write result for target", "target", target, "err", err)
-					}
+	wg.Wait()
+
+	// 写入批量写入器中剩余的结果，使它们在结果流关闭之前被发送
+	run.flushWriters()
+
+	// 等待排队的结果通过写入后的钩子
+	run.stopPostWriteHooks()
+
+	// 记录这次运行是如何产生的
+	if run.options.Scan.RunInfo {
+		if err := run.writeRunInfo(started, time.Now()); err != nil {
+			run.log.Error("could not write run info", "err", err)
+		}
+	}
+
+	// 所有结果都已发送，通知结果流的消费者
+	if run.Results != nil {
+		close(run.Results)
+	}
+}
+
+// Stats 返回已处理的目标数量和其中失败的数量
+func (run *Runner) Stats() (processed int64, failed int64) {
+	return run.processed.Load(), run.failed.Load()
+}
+
+// CheckErrorRate 在失败目标的百分比超过 Scan.MaxErrorRate 时返回错误。
+// 这通常表示环境有问题（例如没有网络或代理错误），而不只是目标不可达。
+func (run *Runner) CheckErrorRate() error {
+	processed, failed := run.Stats()
+	if run.options.Scan.MaxErrorRate == 0 || processed == 0 {
+		return nil
+	}
+
+	rate := float64(failed) / float64(processed) * 100
+	if rate > run.options.Scan.MaxErrorRate {
+		return fmt.Errorf("%d of %d targets failed (%.1f%%), more than the maximum error rate of %.1f%%",
+			failed, processed, rate, run.options.Scan.MaxErrorRate)
+	}
+
+	return nil
+}
+
+// skipCompletedTargets 跳过检查点中已在之前的运行中完成的目标
+func (run *Runner) skipCompletedTargets(targets <-chan string) <-chan string {
+	remaining := make(chan string)
 
-					run.log.Info("result 🤖", "target", target, "status-code", result.ResponseCode,
-						"title", result.Title, "have-screenshot", !result.Failed)
+	go func() {
+		defer close(remaining)
 
+		var skipped int
+		for target := range targets {
+			if run.checkpoint.done(target) {
+				skipped++
+				continue
+			}
+
+			select {
+			case <-run.ctx.Done():
+				return
+			case remaining <- target:
+			}
+		}
+
+		if skipped > 0 {
+			run.log.Info("skipped targets completed in a previous run", "skipped", skipped)
+		}
+	}()
+
+	return remaining
+}
+
+// shuffleTargets 读取所有目标，打乱顺序后通过新的通道分发
+func (run *Runner) shuffleTargets(targets <-chan string) <-chan string {
+	shuffled := make(chan string)
+
+	go func() {
+		defer close(shuffled)
+
+		var all []string
+		for target := range targets {
+			all = append(all, target)
+		}
+
+		seed := run.options.Scan.ShuffleSeed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		run.log.Debug("shuffling targets", "targets", len(all), "seed", seed)
+		islazy.ShuffleStrSeed(all, seed)
+
+		for _, target := range all {
+			select {
+			case <-run.ctx.Done():
+				return
+			case shuffled <- target:
+			}
+		}
+	}()
+
+	return shuffled
+}
+
+// limitTargets 只分发前 Scan.MaxTargets 个目标。达到上限后通道会被关闭，
+// 让工作线程处理完已分发的目标后退出，剩余的目标会被读取并丢弃，
+// 以免阻塞目标的生产者。
+func (run *Runner) limitTargets(targets <-chan string) <-chan string {
+	limited := make(chan string)
+
+	go func() {
+		for dispatched := 0; dispatched < run.options.Scan.MaxTargets; {
+			target, ok := <-targets
+			if !ok {
+				close(limited)
+				return
+			}
+
+			select {
+			case <-run.ctx.Done():
+				close(limited)
+				return
+			case limited <- target:
+				dispatched++
+			}
+		}
+		close(limited)
+
+		var skipped int
+		for range targets {
+			skipped++
+		}
+		if skipped > 0 {
+			run.log.Info("max targets reached, skipped the remaining targets",
+				"max-targets", run.options.Scan.MaxTargets, "skipped", skipped)
+		}
+	}()
+
+	return limited
+}
+
+// witness 探测单个目标并将结果传递给写入器。
+// 返回值表示该目标是否应被视为失败。
+func (run *Runner) witness(target string) bool {
+	ctx, span := run.Tracer.Start(run.ctx, "witness", tracing.String("target", target))
+	defer span.End()
+
+	// 验证目标
+	if err := run.checkUrl(target); err != nil {
+		span.SetError(err)
+		if errors.Is(err, ErrOutOfScope) {
+			run.log.Info("skipping out-of-scope target", "target", target)
+			return false
+		}
+		if run.options.Logging.LogScanErrors {
+			run.log.Error("invalid target to scan", "target", target, "err", err)
+		}
+		return false
+	}
+
+	// 预检目标，跳过无法到达的主机
+	if run.options.Scan.PreflightTimeout > 0 {
+		if err := run.preflightTarget(ctx, target); err != nil {
+			span.SetError(err)
+			if run.options.Logging.LogScanErrors {
+				run.log.Error("target failed preflight", "target", target, "err", err)
+			}
+			return true
+		}
+	}
+
+	result, err := run.driverWitness(ctx, target)
+
+	// 连接失败时使用另一个协议重试
+	var chromeErr *ChromeNotFoundError
+	if run.options.Scan.RetryAlternateScheme && (err != nil || result.ResponseCode == 0) && !errors.As(err, &chromeErr) {
+		if alternate, ok := targetline.AlternateScheme(target); ok {
+			run.log.Debug("retrying target with alternate scheme", "target", target, "alternate", alternate)
+			alternateResult, alternateErr := run.driverWitness(ctx, alternate)
+			if alternateErr == nil && alternateResult.ResponseCode != 0 {
+				if result != nil {
+					run.removeScreenshots(result)
 				}
+				alternateResult.SchemeSwitched = true
+				result, err = alternateResult, nil
 			}
+		}
+	}
 
-		}()
+	if err != nil {
+		span.SetError(err)
+
+		// 这是 Chrome 未找到错误吗？
+		if errors.As(err, &chromeErr) {
+			run.log.Error("no valid chrome intallation found", "err", err)
+			run.cancel()
+			return true
+		}
+
+		// 驱动 panic 时总是记录，并写入一个失败的结果
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			run.log.Error("driver panicked while witnessing target", "target", target,
+				"panic", panicErr.Value, "stack", string(panicErr.Stack))
+			run.writePanicResult(target, panicErr)
+			return true
+		}
+
+		if run.options.Logging.LogScanErrors {
+			run.log.Error("failed to witness target", "target", target, "err", err)
+		}
+		return true
 	}
 
-	wg.Wait()
+	if result.BrowserVersion != "" {
+		run.browserVersion.Store(result.BrowserVersion)
+	}
+	run.queueRedirectHosts(target, result)
+
+	span.SetAttributes(
+		tracing.String("final_url", result.FinalURL),
+		tracing.Int("response_code", result.ResponseCode),
+		tracing.Bool("failed", result.Failed),
+	)
+
+	// 假设状态码 0 表示没有信息，所以
+	// 不向写入器发送任何内容。
+	if result.ResponseCode == 0 {
+		if run.options.Logging.LogScanErrors {
+			run.log.Error("failed to witness target, status code was 0", "target", target)
+		}
+		return true
+	}
+
+	// 按配置的状态码将结果标记为失败。这只影响写入的结果，
+	// 不计为扫描失败。
+	failed := result.Failed
+	if !result.Failed {
+		if isFailure, reason := run.statusFailed(result.ResponseCode); isFailure {
+			result.Failed = true
+			result.FailedReason = reason
+		}
+	}
+
+	// 丢弃与黑名单哈希相近的结果
+	if run.isBlocklisted(result) {
+		run.log.Info("dropping result matching a blocklisted hash", "target", target,
+			"perception-hash", result.PerceptionHash)
+		run.removeScreenshots(result)
+		return false
+	}
+
+	// 丢弃标题和 HTML 都不匹配保存模式的结果
+	if run.saveMatching != nil && !run.saveMatching.MatchString(result.Title) && !run.saveMatching.MatchString(result.HTML) {
+		run.log.Info("dropping result not matching the save pattern", "target", target,
+			"final-url", result.FinalURL, "title", result.Title)
+		run.removeScreenshots(result)
+		return false
+	}
+
+	// 丢弃最终 URL 重复的结果
+	if run.options.Scan.DedupeFinalURL && result.FinalURL != "" && !run.finalURLs.add(result.FinalURL) {
+		run.log.Info("dropping duplicate result", "target", target, "final-url", result.FinalURL)
+		run.removeScreenshots(result)
+		return false
+	}
+
+	// 记录 HTTPS 页面加载的不安全子资源
+	detectMixedContent(result)
+
+	// 标记默认页面和占位页面
+	run.detectDefaultPage(result)
+
+	// 记录 HTTP 认证质询
+	detectAuthChallenges(result)
+
+	// 统计重定向并标记跨源重定向
+	detectCrossOriginRedirects(result)
+
+	// 标记第三方的脚本和链接来源
+	classifyExternalResources(result)
+
+	// 提取页面正文
+	if run.options.Scan.ExtractMainText {
+		extractMainText(result)
+	}
+
+	// 记录页面中的链接到站点地图
+	run.sitemap.add(target, result)
+
+	// 在任何内容被持久化之前屏蔽敏感的头部和 cookie
+	run.redactor.redact(result)
+
+	// 保存 HAR 文件
+	if run.options.Scan.SaveHar {
+		harFile, err := run.writeHar(target, result)
+		if err != nil {
+			run.log.Error("failed to write har file", "target", target, "err", err)
+		} else {
+			result.HarFile = harFile
+		}
+	}
+
+	// 运行结果钩子，钩子可以丢弃结果
+	if err := run.runHooks(result); err != nil {
+		run.log.Debug("result dropped by hook", "target", target)
+		return failed
+	}
+
+	run.runWriters(target, result)
+
+	run.log.Info("result 🤖", "target", target, "status-code", result.ResponseCode,
+		"title", result.Title, "have-screenshot", result.Filename != "" || result.Screenshot != "")
+
+	return failed
 }
 
 func (run *Runner) Close() {
 	// 关闭驱动
 	run.Driver.Close()
+
+	// 关闭检查点文件
+	if err := run.checkpoint.close(); err != nil {
+		run.log.Error("could not close checkpoint file", "err", err)
+	}
+
+	// 写入站点地图
+	if err := run.sitemap.write(run.options.Scan.SitemapFile); err != nil {
+		run.log.Error("could not write sitemap", "err", err)
+	}
+
+	// 刷新并关闭需要关闭的写入器
+	for _, writer := range run.writers {
+		if closer, ok := writer.(writers.Closer); ok {
+			if err := closer.Close(); err != nil {
+				run.log.Error("could not close writer", "err", err)
+			}
+		}
+	}
+
+	// 导出剩余的追踪数据
+	if err := run.Tracer.Shutdown(); err != nil {
+		run.log.Error("could not export traces", "err", err)
+	}
 }
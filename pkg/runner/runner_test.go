@@ -0,0 +1,209 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/writers"
+)
+
+// batchWriter queues results until flushed, like a batching database writer
+type batchWriter struct {
+	pending   []*models.Result
+	err       error
+	onWritten func(*models.Result, error)
+}
+
+func (w *batchWriter) Write(result *models.Result) error {
+	w.pending = append(w.pending, result)
+	return nil
+}
+
+func (w *batchWriter) OnWritten(fn func(*models.Result, error)) {
+	w.onWritten = fn
+}
+
+func (w *batchWriter) Flush() error {
+	for _, result := range w.pending {
+		w.onWritten(result, w.err)
+	}
+	w.pending = nil
+
+	return w.err
+}
+
+// syncWriter writes results immediately
+type syncWriter struct {
+	written int
+}
+
+func (w *syncWriter) Write(result *models.Result) error {
+	w.written++
+	return nil
+}
+
+// recordHook records the results it processed
+type recordHook struct {
+	results []*models.Result
+}
+
+func (h *recordHook) Process(result *models.Result) error {
+	h.results = append(h.results, result)
+	return nil
+}
+
+func newTestRunner(w ...writers.Writer) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &Runner{
+		writers:       w,
+		pendingWrites: make(map[*models.Result]*pendingWrite),
+		log:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	run.watchBatchers()
+
+	return run
+}
+
+func TestRunWritersWaitsForBatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		flushErr error
+	}{
+		{name: "Test with written batch"},
+		{name: "Test with failed batch", flushErr: errors.New("database is locked")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := &batchWriter{err: tt.flushErr}
+			direct := &syncWriter{}
+			hook := &recordHook{}
+
+			run := newTestRunner(direct, batch)
+			run.AddPostWriteHook(hook)
+			run.Results = make(chan *models.Result, 1)
+
+			checkpoint, err := openCheckpoint(filepath.Join(t.TempDir(), "checkpoint.jsonl"), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer checkpoint.close()
+			run.checkpoint = checkpoint
+
+			result := &models.Result{URL: "https://example.com"}
+			run.runWriters(result.URL, result)
+
+			if direct.written != 1 {
+				t.Errorf("direct writes =>\n\nhave: %v\nwant %v", direct.written, 1)
+			}
+			if len(hook.results) != 0 || len(run.Results) != 0 || checkpointSize(t, checkpoint) != 0 {
+				t.Fatalf("queued result was passed on before its batch was written")
+			}
+
+			run.flushWriters()
+
+			if len(hook.results) != 1 || hook.results[0] != result {
+				t.Errorf("post-write hook results =>\n\nhave: %v\nwant %v", hook.results, result)
+			}
+			if got := <-run.Results; got != result {
+				t.Errorf("Results =>\n\nhave: %v\nwant %v", got, result)
+			}
+			if len(run.pendingWrites) != 0 {
+				t.Errorf("pending writes =>\n\nhave: %v\nwant 0", len(run.pendingWrites))
+			}
+
+			// targets of results that could not be written are probed
+			// again on resume
+			want := 1
+			if tt.flushErr != nil {
+				want = 0
+			}
+			if got := checkpointSize(t, checkpoint); got != want {
+				t.Errorf("checkpoint records =>\n\nhave: %v\nwant %v", got, want)
+			}
+		})
+	}
+}
+
+// checkpointSize returns the number of records in a checkpoint file
+func checkpointSize(t *testing.T, c *checkpoint) int {
+	contents, err := os.ReadFile(c.file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.Count(string(contents), "\n")
+}
+
+// slowHook records how many results it processes at the same time
+type slowHook struct {
+	running   atomic.Int32
+	peak      atomic.Int32
+	processed atomic.Int32
+}
+
+func (h *slowHook) Process(result *models.Result) error {
+	running := h.running.Add(1)
+	defer h.running.Add(-1)
+
+	for peak := h.peak.Load(); running > peak && !h.peak.CompareAndSwap(peak, running); peak = h.peak.Load() {
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	h.processed.Add(1)
+
+	return nil
+}
+
+func TestPostWriteHookPool(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		results     int
+	}{
+		{name: "Test with a single worker", concurrency: 1, results: 4},
+		{name: "Test with more results than workers", concurrency: 3, results: 12},
+		{name: "Test with unset concurrency", concurrency: 0, results: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := &slowHook{}
+
+			run := newTestRunner()
+			run.options.Scan.HookConcurrency = tt.concurrency
+			run.AddPostWriteHook(hook)
+			run.startPostWriteHooks()
+
+			var wg sync.WaitGroup
+			for range tt.results {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					run.queuePostWriteHooks(&models.Result{URL: "https://example.com"})
+				}()
+			}
+			wg.Wait()
+			run.stopPostWriteHooks()
+
+			if got := int(hook.processed.Load()); got != tt.results {
+				t.Errorf("processed results =>\n\nhave: %v\nwant %v", got, tt.results)
+			}
+			if got, want := int(hook.peak.Load()), max(tt.concurrency, 1); got > want {
+				t.Errorf("concurrent hooks =>\n\nhave: %v\nwant at most %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrOutOfScope 表示目标不在允许列表中，或者在拒绝列表中
+var ErrOutOfScope = errors.New("target is out of scope")
+
+// scopeList 是一组用于匹配目标的模式
+type scopeList struct {
+	hosts    []string
+	wildcard []string
+	networks []*net.IPNet
+	patterns []*regexp.Regexp
+}
+
+// parseScopeList 解析范围模式。支持的模式有：
+//
+//   - re:<正则表达式>，匹配完整的目标 URL
+//   - CIDR 网络，例如 10.0.0.0/8，匹配 IP 地址主机
+//   - *.example.com，只匹配子域名
+//   - example.com 或 IP 地址，匹配该主机及其子域名
+func parseScopeList(entries []string) (*scopeList, error) {
+	list := &scopeList{}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if pattern, ok := strings.CutPrefix(entry, "re:"); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid scope regular expression %q: %w", pattern, err)
+			}
+			list.patterns = append(list.patterns, re)
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			list.networks = append(list.networks, network)
+			continue
+		}
+
+		entry = strings.ToLower(strings.TrimSuffix(entry, "."))
+		if domain, ok := strings.CutPrefix(entry, "*."); ok {
+			list.wildcard = append(list.wildcard, domain)
+			continue
+		}
+
+		list.hosts = append(list.hosts, entry)
+	}
+
+	return list, nil
+}
+
+// empty 返回 true 表示列表中没有模式
+func (l *scopeList) empty() bool {
+	return len(l.hosts) == 0 && len(l.wildcard) == 0 && len(l.networks) == 0 && len(l.patterns) == 0
+}
+
+// matches 检查目标 URL 是否匹配列表中的任一模式
+func (l *scopeList) matches(u *url.URL) bool {
+	for _, re := range l.patterns {
+		if re.MatchString(u.String()) {
+			return true
+		}
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range l.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+
+	for _, h := range l.hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+
+	for _, domain := range l.wildcard {
+		if strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inScope 检查目标是否在扫描范围内。拒绝列表优先：匹配拒绝列表的目标
+// 总是被跳过，即使它也匹配允许列表。设置了允许列表时，只有匹配它的
+// 目标才会被扫描。
+func (run *Runner) inScope(u *url.URL) bool {
+	if run.denylist.matches(u) {
+		return false
+	}
+
+	return run.allowlist.empty() || run.allowlist.matches(u)
+}
@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ScrollPosition 是截图前要滚动到的垂直位置
+type ScrollPosition struct {
+	// Raw 是用户指定的原始值，例如 "800" 或 "50%"
+	Raw string
+	// Value 是像素偏移量或百分比
+	Value float64
+	// Percent 表示 Value 是可滚动高度的百分比
+	Percent bool
+}
+
+// ParseScrollPosition 解析像素偏移量（例如 "800"）或
+// 可滚动高度的百分比（例如 "50%"）
+func ParseScrollPosition(raw string) (ScrollPosition, error) {
+	raw = strings.TrimSpace(raw)
+	position := ScrollPosition{Raw: raw}
+
+	value := raw
+	if strings.HasSuffix(raw, "%") {
+		position.Percent = true
+		value = strings.TrimSuffix(raw, "%")
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v < 0 {
+		return position, fmt.Errorf("invalid scroll position %q", raw)
+	}
+	if position.Percent && v > 100 {
+		return position, fmt.Errorf("invalid scroll position %q, percentages cannot exceed 100%%", raw)
+	}
+
+	position.Value = v
+	return position, nil
+}
+
+// ParseScrollPositions 解析一组滚动位置
+func ParseScrollPositions(raw []string) ([]ScrollPosition, error) {
+	var positions []ScrollPosition
+	for _, r := range raw {
+		position, err := ParseScrollPosition(r)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+// Offset 根据页面的滚动高度和视口高度计算像素偏移量。
+// 百分比是相对于最大可滚动距离计算的，所以 100% 表示页面底部。
+func (p ScrollPosition) Offset(scrollHeight, viewportHeight float64) float64 {
+	if !p.Percent {
+		return p.Value
+	}
+
+	scrollable := scrollHeight - viewportHeight
+	if scrollable < 0 {
+		scrollable = 0
+	}
+
+	return scrollable * p.Value / 100
+}
@@ -0,0 +1,262 @@
+package runner
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/models"
+	"golang.org/x/net/html"
+)
+
+// maxSitemapLinks 是每个页面最多记录的链接数量
+const maxSitemapLinks = 1000
+
+// maxSitemapLinkText 是记录的链接文本的最大长度
+const maxSitemapLinkText = 200
+
+// Sitemap 是扫描页面中发现的链接结构。Pages 是每个扫描页面及其链接，
+// URLs 是所有发现的 URL 及链接到它们的页面，无论它们是否被扫描过。
+type Sitemap struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Pages       []SitemapPage `json:"pages"`
+	URLs        []SitemapURL  `json:"urls"`
+}
+
+// SitemapPage 是一个扫描过的页面及其链接
+type SitemapPage struct {
+	Target string        `json:"target"`
+	URL    string        `json:"url"`
+	Title  string        `json:"title"`
+	Links  []SitemapLink `json:"links"`
+}
+
+// SitemapLink 是页面中的一个链接。Internal 表示链接指向页面自己的主机。
+type SitemapLink struct {
+	URL      string `json:"url"`
+	Text     string `json:"text"`
+	Internal bool   `json:"internal"`
+}
+
+// SitemapURL 是一个发现的 URL 及链接到它的页面。Scanned 表示该 URL
+// 本身也是一个扫描过的页面。
+type SitemapURL struct {
+	URL     string   `json:"url"`
+	Sources []string `json:"sources"`
+	Scanned bool     `json:"scanned"`
+}
+
+// sitemap 在扫描过程中收集页面的链接。nil 的 sitemap 是禁用的。
+type sitemap struct {
+	mutex sync.Mutex
+	pages []SitemapPage
+}
+
+// newSitemap 在设置了站点地图文件时返回一个 sitemap，否则返回 nil
+func newSitemap(path string) *sitemap {
+	if path == "" {
+		return nil
+	}
+
+	return &sitemap{}
+}
+
+// add 记录结果页面 HTML 中的链接
+func (s *sitemap) add(target string, result *models.Result) {
+	if s == nil || result.Failed || result.HTML == "" {
+		return
+	}
+
+	page := SitemapPage{
+		Target: target,
+		URL:    result.FinalURL,
+		Title:  result.Title,
+		Links:  pageLinks(result.FinalURL, result.HTML),
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pages = append(s.pages, page)
+}
+
+// sitemap 返回按 URL 排序的页面和发现的 URL
+func (s *sitemap) sitemap() Sitemap {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pages := append([]SitemapPage{}, s.pages...)
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].URL < pages[j].URL })
+
+	scanned := make(map[string]bool)
+	for _, page := range pages {
+		scanned[page.URL] = true
+	}
+
+	discovered := make(map[string]*SitemapURL)
+	for _, page := range pages {
+		for _, link := range page.Links {
+			entry, ok := discovered[link.URL]
+			if !ok {
+				entry = &SitemapURL{URL: link.URL, Scanned: scanned[link.URL]}
+				discovered[link.URL] = entry
+			}
+			if !islazy.SliceHasStr(entry.Sources, page.URL) {
+				entry.Sources = append(entry.Sources, page.URL)
+			}
+		}
+	}
+
+	urls := make([]SitemapURL, 0, len(discovered))
+	for _, entry := range discovered {
+		urls = append(urls, *entry)
+	}
+	sort.Slice(urls, func(i, j int) bool { return urls[i].URL < urls[j].URL })
+
+	return Sitemap{
+		GeneratedAt: time.Now(),
+		Pages:       pages,
+		URLs:        urls,
+	}
+}
+
+// write 将站点地图以 JSON 写入文件
+func (s *sitemap) write(path string) error {
+	if s == nil {
+		return nil
+	}
+
+	path, err := islazy.CreateFileWithDir(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s.sitemap()); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// pageLinks 返回 HTML 中 <a> 和 <area> 元素的 http(s) 链接，相对链接
+// 根据 <base> 元素或页面 URL 解析，主机名转为小写并去掉片段，重复的
+// 链接只保留一次
+func pageLinks(pageURL string, document string) []SitemapLink {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	host := base.Hostname()
+
+	root, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		return nil
+	}
+
+	var links []SitemapLink
+	seen := make(map[string]bool)
+	baseSet := false
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if len(links) >= maxSitemapLinks {
+			return
+		}
+
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "base":
+				// 只有第一个 <base> 元素生效
+				if href, ok := htmlAttr(n, "href"); ok && !baseSet {
+					if u, err := base.Parse(href); err == nil {
+						base = u
+					}
+					baseSet = true
+				}
+			case "a", "area":
+				if href, ok := htmlAttr(n, "href"); ok {
+					if u, err := base.Parse(strings.TrimSpace(href)); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+						u.Host = strings.ToLower(u.Host)
+						u.Fragment = ""
+						u.RawFragment = ""
+						if link := u.String(); !seen[link] {
+							seen[link] = true
+							links = append(links, SitemapLink{
+								URL:      link,
+								Text:     linkText(n),
+								Internal: strings.EqualFold(u.Hostname(), host),
+							})
+						}
+					}
+				}
+			}
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return links
+}
+
+// htmlAttr 返回元素的属性值
+func htmlAttr(n *html.Node, name string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			return attr.Val, true
+		}
+	}
+
+	return "", false
+}
+
+// linkText 返回链接的文本，没有文本时返回 title 或图像的 alt 属性
+func linkText(n *html.Node) string {
+	var text strings.Builder
+	var alt string
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			text.WriteString(n.Data)
+			text.WriteString(" ")
+		case html.ElementNode:
+			if value, ok := htmlAttr(n, "alt"); ok && n.Data == "img" && alt == "" {
+				alt = value
+			}
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+
+	if value := strings.Join(strings.Fields(text.String()), " "); value != "" {
+		if runes := []rune(value); len(runes) > maxSitemapLinkText {
+			return string(runes[:maxSitemapLinkText])
+		}
+		return value
+	}
+	if title, ok := htmlAttr(n, "title"); ok && strings.TrimSpace(title) != "" {
+		return strings.TrimSpace(title)
+	}
+
+	return strings.TrimSpace(alt)
+}
@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// statusCodeRange 是一个闭区间的 HTTP 状态码范围
+type statusCodeRange struct {
+	min int
+	max int
+}
+
+// statusCodes 匹配由单个状态码（403）、类别（5xx）或
+// 范围（400-499）组成的状态码列表
+type statusCodes []statusCodeRange
+
+// parseStatusCodes 解析状态码列表
+func parseStatusCodes(values []string) (statusCodes, error) {
+	var codes statusCodes
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		var r statusCodeRange
+		switch {
+		case len(value) == 3 && strings.HasSuffix(value, "xx"):
+			class, err := strconv.Atoi(value[:1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid status code class %s", value)
+			}
+			r = statusCodeRange{min: class * 100, max: class*100 + 99}
+		case strings.Contains(value, "-"):
+			low, high, _ := strings.Cut(value, "-")
+			min, err := strconv.Atoi(low)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status code range %s", value)
+			}
+			max, err := strconv.Atoi(high)
+			if err != nil || max < min {
+				return nil, fmt.Errorf("invalid status code range %s", value)
+			}
+			r = statusCodeRange{min: min, max: max}
+		default:
+			code, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status code %s", value)
+			}
+			r = statusCodeRange{min: code, max: code}
+		}
+
+		if r.min < 100 || r.max > 599 {
+			return nil, fmt.Errorf("status code %s is out of range", value)
+		}
+		codes = append(codes, r)
+	}
+
+	return codes, nil
+}
+
+// matches 返回 true 表示状态码在列表中
+func (codes statusCodes) matches(code int) bool {
+	for _, r := range codes {
+		if code >= r.min && code <= r.max {
+			return true
+		}
+	}
+
+	return false
+}
+
+// statusFailed 根据配置的成功和失败状态码判断响应是否应视为失败，
+// 并返回失败原因
+func (run *Runner) statusFailed(code int) (bool, string) {
+	if run.failStatusCodes.matches(code) {
+		return true, fmt.Sprintf("status code %d is configured as a failure", code)
+	}
+
+	if len(run.successStatusCodes) > 0 && !run.successStatusCodes.matches(code) {
+		return true, fmt.Sprintf("status code %d is not configured as a success", code)
+	}
+
+	return false, ""
+}
@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStatusCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    statusCodes
+		wantErr bool
+	}{
+		{
+			name:   "Test with single codes",
+			values: []string{"200", " 403 "},
+			want:   statusCodes{{min: 200, max: 200}, {min: 403, max: 403}},
+		},
+		{
+			name:   "Test with class",
+			values: []string{"5XX"},
+			want:   statusCodes{{min: 500, max: 599}},
+		},
+		{
+			name:   "Test with range",
+			values: []string{"400-499"},
+			want:   statusCodes{{min: 400, max: 499}},
+		},
+		{
+			name:   "Test with empty values",
+			values: []string{"", " "},
+			want:   nil,
+		},
+		{
+			name:    "Test with invalid code",
+			values:  []string{"ok"},
+			wantErr: true,
+		},
+		{
+			name:    "Test with invalid class",
+			values:  []string{"axx"},
+			wantErr: true,
+		},
+		{
+			name:    "Test with reversed range",
+			values:  []string{"499-400"},
+			wantErr: true,
+		},
+		{
+			name:    "Test with out of range code",
+			values:  []string{"600"},
+			wantErr: true,
+		},
+		{
+			name:    "Test with out of range class",
+			values:  []string{"0xx"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatusCodes(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStatusCodes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStatusCodes() =>\n\nhave: %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusFailed(t *testing.T) {
+	fail, _ := parseStatusCodes([]string{"404"})
+	success, _ := parseStatusCodes([]string{"2xx", "404"})
+
+	tests := []struct {
+		name    string
+		fail    statusCodes
+		success statusCodes
+		code    int
+		want    bool
+	}{
+		{name: "Test without configuration", code: 500, want: false},
+		{name: "Test with failure code", fail: fail, code: 404, want: true},
+		{name: "Test with other code", fail: fail, code: 500, want: false},
+		{name: "Test with success code", success: success, code: 204, want: false},
+		{name: "Test with non-success code", success: success, code: 301, want: true},
+		{name: "Test with failure overriding success", fail: fail, success: success, code: 404, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &Runner{failStatusCodes: tt.fail, successStatusCodes: tt.success}
+			got, reason := run.statusFailed(tt.code)
+			if got != tt.want {
+				t.Errorf("statusFailed() =>\n\nhave: %v (%s)\nwant %v", got, reason, tt.want)
+			}
+			if got && reason == "" {
+				t.Errorf("statusFailed() has no reason")
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NetworkThrottle 是要模拟的网络条件。吞吐量的单位是字节/秒，
+// -1 表示不限制。
+type NetworkThrottle struct {
+	Offline            bool
+	Latency            float64
+	DownloadThroughput float64
+	UploadThroughput   float64
+}
+
+// networkThrottlePresets 是与 Chrome DevTools 相同的网络条件预设
+var networkThrottlePresets = map[string]NetworkThrottle{
+	"slow-3g": {Latency: 2000, DownloadThroughput: 50000, UploadThroughput: 50000},
+	"fast-3g": {Latency: 562.5, DownloadThroughput: 180000, UploadThroughput: 84375},
+	"offline": {Offline: true, DownloadThroughput: -1, UploadThroughput: -1},
+}
+
+// ParseNetworkThrottle 解析网络条件。可以是预设（slow-3g、fast-3g、offline），
+// 或格式为 "latency,download,upload" 的自定义条件，其中延迟的单位是毫秒，
+// 下载和上传吞吐量的单位是 kbit/s（0 表示不限制）。
+func ParseNetworkThrottle(raw string) (NetworkThrottle, error) {
+	if preset, ok := networkThrottlePresets[strings.ToLower(raw)]; ok {
+		return preset, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	if len(parts) != 3 {
+		return NetworkThrottle{}, fmt.Errorf("invalid network throttle %q, expected a preset (slow-3g, fast-3g, offline) or latency,download,upload", raw)
+	}
+
+	var values []float64
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return NetworkThrottle{}, fmt.Errorf("invalid network throttle %q: %w", raw, err)
+		}
+		if v < 0 {
+			return NetworkThrottle{}, fmt.Errorf("invalid network throttle %q, values cannot be negative", raw)
+		}
+		values = append(values, v)
+	}
+
+	return NetworkThrottle{
+		Latency:            values[0],
+		DownloadThroughput: kbitThroughput(values[1]),
+		UploadThroughput:   kbitThroughput(values[2]),
+	}, nil
+}
+
+// kbitThroughput 将 kbit/s 转换为 CDP 使用的字节/秒，0 表示不限制
+func kbitThroughput(kbit float64) float64 {
+	if kbit == 0 {
+		return -1
+	}
+
+	return kbit * 1000 / 8
+}
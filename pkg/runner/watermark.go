@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// WatermarkTokens 是截图水印文本中支持的占位符
+var WatermarkTokens = []string{"{target}", "{host}", "{status}", "{timestamp}", "{date}"}
+
+// WatermarkPositions 是截图水印支持的位置
+var WatermarkPositions = []string{"top-left", "top-right", "bottom-left", "bottom-right"}
+
+// ExpandWatermark 根据目标、响应状态码和探测时间展开截图水印文本。
+// 时间以 UTC 表示，使不同时区的操作者生成的截图可以直接对比。
+func ExpandWatermark(template string, target string, statusCode int, at time.Time) (string, error) {
+	var host string
+	if u, err := url.Parse(target); err == nil {
+		host = u.Hostname()
+	}
+
+	values := map[string]string{
+		"{target}":    target,
+		"{host}":      host,
+		"{status}":    strconv.Itoa(statusCode),
+		"{timestamp}": at.UTC().Format(time.RFC3339),
+		"{date}":      at.UTC().Format("2006-01-02"),
+	}
+
+	var unknown error
+	text := filenameToken.ReplaceAllStringFunc(template, func(token string) string {
+		value, ok := values[token]
+		if !ok {
+			unknown = fmt.Errorf("unknown watermark token %s", token)
+			return token
+		}
+		return value
+	})
+	if unknown != nil {
+		return "", unknown
+	}
+
+	return text, nil
+}
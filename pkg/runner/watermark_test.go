@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpandWatermark(t *testing.T) {
+	at := time.Date(2024, 6, 1, 14, 30, 0, 0, time.FixedZone("SAST", 2*60*60))
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Test with target and status",
+			template: "{target} ({status})",
+			want:     "https://example.com:8443/login (200)",
+		},
+		{
+			name:     "Test with host",
+			template: "host: {host}",
+			want:     "host: example.com",
+		},
+		{
+			name:     "Test with time in utc",
+			template: "{date} {timestamp}",
+			want:     "2024-06-01 2024-06-01T12:30:00Z",
+		},
+		{
+			name:     "Test without tokens",
+			template: "confidential",
+			want:     "confidential",
+		},
+		{
+			name:     "Test with unknown token",
+			template: "{target} {port}",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandWatermark(tt.template, "https://example.com:8443/login", 200, at)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandWatermark() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandWatermark() =>\n\nhave: %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Package targetline 解析目标行，即带有可选代理、HTTP 方法和请求体的目标 URL。
+package targetline
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sensepost/gowitness/internal/islazy"
+)
+
+// targetMethods 是目标行中可以使用的 HTTP 方法
+var targetMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+// Target 是从目标行中解析出来的探测请求
+type Target struct {
+	// Method 是导航使用的 HTTP 方法
+	Method string
+	// URL 是要探测的 URL
+	URL string
+	// Body 是随请求发送的请求体（如果有）
+	Body string
+	// Proxy 是探测这个目标使用的代理，覆盖 Chrome.Proxy（如果有）
+	Proxy string
+}
+
+// targetProxyPrefix 是目标行中代理前缀的标记
+const targetProxyPrefix = "proxy="
+
+// Parse 解析格式为 "[proxy=PROXY ][METHOD ]URL[ BODY]" 的目标行。
+//
+// 例如：
+//
+//	https://example.com
+//	POST https://example.com/api {"key":"value"}
+//	proxy=socks5://10.0.0.1:1080 https://example.com
+//
+// 没有方法前缀的目标默认使用 GET。
+func Parse(raw string) Target {
+	raw = strings.TrimSpace(raw)
+	target := Target{Method: http.MethodGet, URL: raw}
+
+	if strings.HasPrefix(raw, targetProxyPrefix) {
+		proxy, rest, _ := strings.Cut(raw, " ")
+		target = Parse(rest)
+		target.Proxy = strings.TrimPrefix(proxy, targetProxyPrefix)
+		return target
+	}
+
+	parts := strings.SplitN(raw, " ", 2)
+	// 方法不区分大小写，例如 "post https://example.com"
+	method := strings.ToUpper(parts[0])
+	if len(parts) != 2 || !islazy.SliceHasStr(targetMethods, method) {
+		return target
+	}
+
+	target.Method = method
+	urlAndBody := strings.SplitN(strings.TrimSpace(parts[1]), " ", 2)
+	target.URL = urlAndBody[0]
+	if len(urlAndBody) == 2 {
+		target.Body = strings.TrimSpace(urlAndBody[1])
+	}
+
+	return target
+}
+
+// IsPlain 返回 true 表示这是一个普通的 GET 导航
+func (t Target) IsPlain() bool {
+	return t.Method == http.MethodGet && t.Body == ""
+}
+
+// ContentType 根据请求体猜测 Content-Type 头部
+func (t Target) ContentType() string {
+	if strings.HasPrefix(t.Body, "{") || strings.HasPrefix(t.Body, "[") {
+		return "application/json"
+	}
+
+	return "application/x-www-form-urlencoded"
+}
+
+// String 将目标渲染回目标行格式
+func (t Target) String() string {
+	line := t.URL
+	if !t.IsPlain() {
+		line = t.Method + " " + t.URL
+		if t.Body != "" {
+			line += " " + t.Body
+		}
+	}
+
+	if t.Proxy != "" {
+		line = targetProxyPrefix + t.Proxy + " " + line
+	}
+
+	return line
+}
+
+// AlternateScheme 返回使用另一个协议（http 与 https 互换）的目标行。
+// 使用原协议默认端口的目标会改用新协议的默认端口，其他端口保持不变。
+func AlternateScheme(raw string) (string, bool) {
+	target := Parse(raw)
+
+	u, err := url.Parse(target.URL)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+
+	var defaultPort string
+	switch u.Scheme {
+	case "http":
+		u.Scheme, defaultPort = "https", "80"
+	case "https":
+		u.Scheme, defaultPort = "http", "443"
+	default:
+		return "", false
+	}
+
+	if u.Port() == defaultPort {
+		u.Host = u.Hostname()
+		if strings.Contains(u.Host, ":") {
+			u.Host = "[" + u.Host + "]"
+		}
+	}
+
+	target.URL = u.String()
+	return target.String(), true
+}
@@ -0,0 +1,71 @@
+package targetline
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want Target
+	}{
+		{
+			name: "Test with URL",
+			raw:  "https://example.com",
+			want: Target{Method: "GET", URL: "https://example.com"},
+		},
+		{
+			name: "Test with method and body",
+			raw:  `POST https://example.com/api {"key": "value"}`,
+			want: Target{Method: "POST", URL: "https://example.com/api", Body: `{"key": "value"}`},
+		},
+		{
+			name: "Test with lowercase method",
+			raw:  "post https://example.com/api a=b",
+			want: Target{Method: "POST", URL: "https://example.com/api", Body: "a=b"},
+		},
+		{
+			name: "Test with proxy",
+			raw:  "proxy=socks5://10.0.0.1:1080 PUT https://example.com",
+			want: Target{Method: "PUT", URL: "https://example.com", Proxy: "socks5://10.0.0.1:1080"},
+		},
+		{
+			name: "Test with unknown method",
+			raw:  "FETCH https://example.com",
+			want: Target{Method: "GET", URL: "FETCH https://example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.raw)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =>\n\nhave: %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlternateScheme(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "http://example.com", want: "https://example.com"},
+		{raw: "https://example.com:443/path", want: "http://example.com/path"},
+		{raw: "http://example.com:80", want: "https://example.com"},
+		{raw: "https://example.com:8443", want: "http://example.com:8443"},
+		{raw: "post https://example.com a=b", want: "POST http://example.com a=b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, ok := AlternateScheme(tt.raw)
+			if !ok || got != tt.want {
+				t.Errorf("AlternateScheme() =>\n\nhave: %v (%v)\nwant %v", got, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// shutdownTimeout is how long Shutdown waits for remaining spans to export
+const shutdownTimeout = 10 * time.Second
+
+// scopeName is the instrumentation scope of gowitness spans
+const scopeName = "github.com/sensepost/gowitness"
+
+// Attribute is a span attribute
+type Attribute = attribute.KeyValue
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return attribute.String(key, value)
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute {
+	return attribute.Int(key, value)
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return attribute.Bool(key, value)
+}
+
+// Tracer records spans and exports them in batches to an OTLP/HTTP
+// collector.
+//
+// A nil *Tracer is valid and records nothing, so callers do not need to
+// check if tracing is enabled.
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// NewTracer returns a tracer exporting to an OTLP/HTTP endpoint, such
+// as http://localhost:4318. Export errors are logged.
+func NewTracer(endpoint, service string) (*Tracer, error) {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	// the sdk reports export errors to the global error handler
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Error("could not export traces", "endpoint", endpoint, "err", err)
+	}))
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", service))),
+	)
+
+	return &Tracer{
+		provider: provider,
+		tracer:   provider.Tracer(scopeName),
+	}, nil
+}
+
+// Span is a single timed operation in a trace
+type Span struct {
+	span trace.Span
+}
+
+// Start starts a new span. If ctx carries a span, the new span is its child.
+func (t *Tracer) Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+	return ctx, &Span{span: span}
+}
+
+// SetAttributes adds attributes to a span
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+
+	s.span.SetAttributes(attributes...)
+}
+
+// SetError marks a span as failed
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End ends a span and queues it for export
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.span.End()
+}
+
+// Shutdown flushes any remaining spans and stops the exporter
+func (t *Tracer) Shutdown() error {
+	if t == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	return t.provider.Shutdown(ctx)
+}
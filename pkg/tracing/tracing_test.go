@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestTracerExportsSpans(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var bodies int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		if len(body) > 0 {
+			bodies++
+		}
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tracer, err := NewTracer(server.URL+"/", "gowitness-test")
+	if err != nil {
+		t.Fatalf("NewTracer() error = %v", err)
+	}
+
+	ctx, parent := tracer.Start(context.Background(), "witness", String("target", "https://example.com"))
+	_, child := tracer.Start(ctx, "navigate")
+	child.SetError(errors.New("navigation failed"))
+	child.End()
+	parent.SetAttributes(Int("response_code", 200), Bool("failed", false))
+	parent.End()
+
+	if err := tracer.Shutdown(); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(paths) == 0 || bodies == 0 {
+		t.Fatalf("no spans were exported")
+	}
+	for _, path := range paths {
+		if path != "/v1/traces" {
+			t.Errorf("export path =>\n\nhave: %v\nwant %v", path, "/v1/traces")
+		}
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+
+	ctx, span := tracer.Start(context.Background(), "witness")
+	if ctx == nil || span != nil {
+		t.Fatalf("Start() on a nil tracer => ctx %v, span %v", ctx, span)
+	}
+
+	// none of these may panic
+	span.SetAttributes(String("key", "value"))
+	span.SetError(errors.New("error"))
+	span.End()
+
+	if err := tracer.Shutdown(); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
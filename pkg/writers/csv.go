@@ -5,22 +5,51 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
+	"sync/atomic"
 
 	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/log"
 	"github.com/sensepost/gowitness/pkg/models"
 )
 
 // fields in the main model to ignore
 var csvExludedFields = []string{"HTML"}
 
+// csvMaxCellLength is the maximum number of characters in a spreadsheet
+// cell (e.g. in Excel). Longer values, like inline screenshots, are written
+// but get truncated when the file is opened in a spreadsheet.
+const csvMaxCellLength = 32767
+
+// csvColumn is a, possibly nested, field of a result written as a column
+type csvColumn struct {
+	name  string
+	index []int
+}
+
 // CsvWriter writes CSV files
 type CsvWriter struct {
-	FilePath  string
+	FilePath string
+	// Separator joins the elements of slice fields (e.g. technologies)
+	Separator string
+
 	finalPath string
+	columns   []csvColumn
+	// warnedCellLength is set once a value longer than csvMaxCellLength
+	// was written, to warn only once
+	warnedCellLength atomic.Bool
 }
 
-// NewCsvWriter gets a new CsvWriter
-func NewCsvWriter(destination string) (*CsvWriter, error) {
+// NewCsvWriter gets a new CsvWriter. columns are the result fields to write,
+// by field or json name, with dots for nested fields (e.g. tls.issuer). All
+// non-slice fields are written if no columns are given. Slice fields are
+// flattened into a single value joined with separator.
+func NewCsvWriter(destination string, columns []string, separator string) (*CsvWriter, error) {
+	cols, err := csvColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+
 	p, err := islazy.CreateFileWithDir(destination)
 	if err != nil {
 		return nil, err
@@ -31,17 +60,25 @@ func NewCsvWriter(destination string) (*CsvWriter, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	if err := writer.Write(csvHeaders()); err != nil {
+	var headers []string
+	for _, col := range cols {
+		headers = append(headers, col.name)
+	}
+
+	if err := writer.Write(headers); err != nil {
 		return nil, err
 	}
 
 	return &CsvWriter{
 		FilePath:  destination,
+		Separator: separator,
 		finalPath: p,
+		columns:   cols,
 	}, nil
 }
 
@@ -58,45 +95,119 @@ func (cw *CsvWriter) Write(result *models.Result) error {
 
 	// get values from the result
 	val := reflect.ValueOf(*result)
-	numField := val.NumField()
 
 	var values []string
-	for i := 0; i < numField; i++ {
-		// skip excluded fields
-		if islazy.SliceHasStr(csvExludedFields, val.Type().Field(i).Name) {
-			continue
+	for _, col := range cw.columns {
+		value := cw.value(val.FieldByIndex(col.index))
+		if len(value) > csvMaxCellLength && cw.warnedCellLength.CompareAndSwap(false, true) {
+			log.Warn("a csv value is longer than spreadsheets allow in a cell and will be truncated when opened in one",
+				"column", col.name, "length", len(value))
 		}
+		values = append(values, value)
+	}
 
-		// skip slices
-		if val.Field(i).Kind() == reflect.Slice {
-			continue // Optionally skip slice fields, or handle them differently
-		}
+	return writer.Write(values)
+}
 
-		values = append(values, fmt.Sprintf("%v", val.Field(i).Interface()))
+// value formats a field, flattening slices
+func (cw *CsvWriter) value(field reflect.Value) string {
+	if field.Kind() != reflect.Slice || field.Type().Elem().Kind() == reflect.Uint8 {
+		return fmt.Sprintf("%v", field.Interface())
 	}
 
-	return writer.Write(values)
+	var elements []string
+	for i := 0; i < field.Len(); i++ {
+		elements = append(elements, csvElement(field.Index(i)))
+	}
+
+	return strings.Join(elements, cw.Separator)
+}
+
+// csvElement formats an element of a slice field. Model structs are
+// reduced to their key/value, value, name or url field.
+func csvElement(element reflect.Value) string {
+	if element.Kind() != reflect.Struct {
+		return fmt.Sprintf("%v", element.Interface())
+	}
+
+	key := element.FieldByName("Key")
+	value := element.FieldByName("Value")
+	if key.IsValid() && value.IsValid() {
+		return fmt.Sprintf("%v: %v", key.Interface(), value.Interface())
+	}
+
+	for _, name := range []string{"Value", "Name", "URL", "Filename"} {
+		if f := element.FieldByName(name); f.IsValid() {
+			return fmt.Sprintf("%v", f.Interface())
+		}
+	}
+
+	return fmt.Sprintf("%v", element.Interface())
 }
 
-// headers returns the headers a CSV file should have.
-func csvHeaders() []string {
-	val := reflect.ValueOf(models.Result{})
-	numField := val.NumField()
+// csvColumns resolves column names to result fields, or returns the
+// default columns if no names are given.
+func csvColumns(names []string) ([]csvColumn, error) {
+	resultType := reflect.TypeOf(models.Result{})
+
+	if len(names) == 0 {
+		var columns []csvColumn
+		for i := 0; i < resultType.NumField(); i++ {
+			field := resultType.Field(i)
+
+			// skip excluded fields
+			if islazy.SliceHasStr(csvExludedFields, field.Name) {
+				continue
+			}
 
-	var fieldNames []string
-	for i := 0; i < numField; i++ {
-		// skip excluded fields
-		if islazy.SliceHasStr(csvExludedFields, val.Type().Field(i).Name) {
-			continue
+			// skip slices
+			if field.Type.Kind() == reflect.Slice {
+				continue
+			}
+
+			columns = append(columns, csvColumn{name: field.Name, index: field.Index})
 		}
 
-		// skip slices
-		if val.Field(i).Kind() == reflect.Slice {
-			continue // Optionally skip slice fields, or handle them differently
+		return columns, nil
+	}
+
+	var columns []csvColumn
+	for _, name := range names {
+		var index []int
+		var path []string
+
+		t := resultType
+		for _, part := range strings.Split(strings.TrimSpace(name), ".") {
+			if t.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("unknown csv column %q", name)
+			}
+
+			field, ok := csvField(t, part)
+			if !ok {
+				return nil, fmt.Errorf("unknown csv column %q", name)
+			}
+
+			index = append(index, field.Index...)
+			path = append(path, field.Name)
+			t = field.Type
 		}
 
-		fieldNames = append(fieldNames, val.Type().Field(i).Name)
+		columns = append(columns, csvColumn{name: strings.Join(path, "."), index: index})
+	}
+
+	return columns, nil
+}
+
+// csvField finds a struct field by its field or json name, ignoring case
+func csvField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if strings.EqualFold(field.Name, name) || strings.EqualFold(jsonName, name) {
+			return field, true
+		}
 	}
 
-	return fieldNames
+	return reflect.StructField{}, false
 }
@@ -1,27 +1,69 @@
 package writers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/sensepost/gowitness/internal/islazy"
 	"github.com/sensepost/gowitness/pkg/database"
 	"github.com/sensepost/gowitness/pkg/log"
 	"github.com/sensepost/gowitness/pkg/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var hammingThreshold = 10
 
-// DbWriter is a Database writer
+// upsertKeys are the result columns rescans can be matched on
+var upsertKeys = []string{"url", "final_url"}
+
+// writeAttempts is how often a write transaction is tried before giving
+// up, as concurrent writers can make a transaction fail with a busy
+// database, deadlock or serialization error
+const writeAttempts = 3
+
+// dbMaxPending is the maximum number of results kept for a retry when
+// writing fails. Results written while the queue is full are refused with
+// an error.
+const dbMaxPending = 1000
+
+// DbWriter is a Database writer.
+//
+// Results are written in batches of batchSize results per transaction.
+// Every transaction commit waits for the database to sync to disk, so on
+// SQLite batching many results in one transaction makes writes much faster
+// with a high number of threads. Results that could not be written stay
+// queued and are retried with the next write. Queued results are written
+// when the writer is flushed or closed.
 type DbWriter struct {
 	URI           string
 	conn          *gorm.DB
 	mutex         sync.Mutex
 	hammingGroups []islazy.HammingGroup
+	// upsertKey is the column existing results are matched on, or empty
+	// to always insert
+	upsertKey string
+	batchSize int
+	pending   []*models.Result
+	onWritten func(*models.Result, error)
 }
 
-// NewDbWriter initialises a database writer
-func NewDbWriter(uri string, debug bool) (*DbWriter, error) {
+// NewDbWriter initialises a database writer. If upsertKey is set (url or
+// final_url), a result replaces the existing result with the same value in
+// that column instead of adding a new row. batchSize is the number of
+// results written per transaction.
+func NewDbWriter(uri string, debug bool, upsertKey string, batchSize int) (*DbWriter, error) {
+	if upsertKey != "" && !islazy.SliceHasStr(upsertKeys, upsertKey) {
+		return nil, fmt.Errorf("invalid upsert key %q, must be one of %v", upsertKey, upsertKeys)
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
 	c, err := database.Connection(uri, false, debug)
 	if err != nil {
 		return nil, err
@@ -32,14 +74,52 @@ func NewDbWriter(uri string, debug bool) (*DbWriter, error) {
 		conn:          c,
 		mutex:         sync.Mutex{},
 		hammingGroups: []islazy.HammingGroup{},
+		upsertKey:     upsertKey,
+		batchSize:     batchSize,
 	}, nil
 }
 
-// Write results to the database
-func (dw *DbWriter) Write(result *models.Result) error {
+// OnWritten sets the function called for queued results once they are
+// written
+func (dw *DbWriter) OnWritten(fn func(result *models.Result, err error)) {
 	dw.mutex.Lock()
 	defer dw.mutex.Unlock()
 
+	dw.onWritten = fn
+}
+
+// Write queues a result and writes the queue once a batch is full
+func (dw *DbWriter) Write(result *models.Result) error {
+	dw.mutex.Lock()
+	written, err := dw.queue(result)
+	fn := dw.onWritten
+	dw.mutex.Unlock()
+
+	notifyWritten(fn, written)
+	return err
+}
+
+// Flush writes the queued results, giving up on results that can't be
+// written
+func (dw *DbWriter) Flush() error {
+	dw.mutex.Lock()
+	written, err := dw.flush(true)
+	fn := dw.onWritten
+	dw.mutex.Unlock()
+
+	notifyWritten(fn, written)
+	return err
+}
+
+// Close writes any queued results
+func (dw *DbWriter) Close() error {
+	return dw.Flush()
+}
+
+// queue adds a result to the queue and writes the queue once a batch is
+// full. Failed results stay queued for a retry. The caller must hold the
+// mutex.
+func (dw *DbWriter) queue(result *models.Result) ([]writtenResult, error) {
 	// Assign Group ID based on PerceptionHash
 	groupID, err := dw.AssignGroupID(result.PerceptionHash)
 	if err == nil {
@@ -50,7 +130,173 @@ func (dw *DbWriter) Write(result *models.Result) error {
 		log.Debug("could not get group id for perception hash", "hash", result.PerceptionHash)
 	}
 
-	return dw.conn.Create(result).Error
+	var written []writtenResult
+	if len(dw.pending) >= dbMaxPending {
+		// try to make room before refusing the result
+		written, err = dw.flush(false)
+		if err != nil {
+			return written, fmt.Errorf("database queue is full with %d results, dropping result for %s: %w", len(dw.pending), result.URL, err)
+		}
+	}
+
+	dw.pending = append(dw.pending, result)
+	if len(dw.pending) < dw.batchSize {
+		return written, nil
+	}
+
+	flushed, err := dw.flush(false)
+	if err != nil {
+		log.Error("could not write results, retrying them with the next write", "queued", len(dw.pending), "err", err)
+	}
+
+	return append(written, flushed...), nil
+}
+
+// flush writes the queued results in one transaction. If the transaction
+// keeps failing, the results are written one by one, so that a result that
+// can't be written does not hold back the others and its error is reported
+// for it. Results that were not written stay queued, unless giveUp is set.
+// The caller must hold the mutex.
+func (dw *DbWriter) flush(giveUp bool) ([]writtenResult, error) {
+	if len(dw.pending) == 0 {
+		return nil, nil
+	}
+
+	var written []writtenResult
+	if err := dw.transaction(dw.pending); err == nil {
+		for _, result := range dw.pending {
+			written = append(written, writtenResult{result: result})
+		}
+		dw.pending = nil
+
+		return written, nil
+	}
+
+	var failed []*models.Result
+	var errs []error
+	for _, result := range dw.pending {
+		err := dw.conn.Transaction(func(tx *gorm.DB) error {
+			return dw.write(tx, result)
+		})
+		if err == nil {
+			written = append(written, writtenResult{result: result})
+			continue
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", result.URL, err))
+		if giveUp {
+			written = append(written, writtenResult{result: result, err: err})
+		} else {
+			failed = append(failed, result)
+		}
+	}
+	dw.pending = failed
+
+	if len(errs) > 0 {
+		return written, fmt.Errorf("could not write %d results: %w", len(errs), errors.Join(errs...))
+	}
+
+	return written, nil
+}
+
+// transaction writes results in one transaction, retrying it a few times
+func (dw *DbWriter) transaction(results []*models.Result) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = dw.conn.Transaction(func(tx *gorm.DB) error {
+			for _, result := range results {
+				if err := dw.write(tx, result); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err == nil || attempt == writeAttempts {
+			return err
+		}
+
+		log.Debug("retrying result write", "results", len(results), "attempt", attempt, "err", err)
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+	}
+}
+
+// write adds a result, or replaces the existing result if upserts are
+// enabled
+func (dw *DbWriter) write(tx *gorm.DB, result *models.Result) error {
+	if dw.upsertKey == "" {
+		return tx.Create(result).Error
+	}
+
+	return dw.upsert(tx, result)
+}
+
+// upsert replaces the latest result with the same upsert key value, keeping
+// its id, scan count and first probe time. The existing row is deleted and
+// its associations with it (they cascade), so that the new capture fully
+// replaces the old one. Results without a key value, such as failed results
+// without a final url, are always inserted.
+func (dw *DbWriter) upsert(tx *gorm.DB, result *models.Result) error {
+	key := result.URL
+	if dw.upsertKey == "final_url" {
+		key = result.FinalURL
+	}
+
+	if key == "" {
+		result.ID = 0
+		result.ScanCount = 1
+		result.FirstProbedAt = result.ProbedAt
+
+		return tx.Create(result).Error
+	}
+
+	// make sure a row for the key exists and lock it, so that concurrent
+	// writers to the same database wait for each other even if there is
+	// no result to replace yet. gorm renders the conflict clause per
+	// backend (ON CONFLICT DO NOTHING, or ON DUPLICATE KEY UPDATE on
+	// MySQL). SQLite locks the whole database for writes instead and does
+	// not support row locks.
+	lock := models.UpsertKey{Hash: upsertHash(dw.upsertKey, key)}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lock).Error; err != nil {
+		return err
+	}
+	if tx.Dialector.Name() != "sqlite" {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&lock).Error; err != nil {
+			return err
+		}
+	}
+
+	var existing models.Result
+	found := tx.Where(dw.upsertKey+" = ?", key).Order("id DESC").
+		Select("id", "scan_count", "first_probed_at", "probed_at").Limit(1).Find(&existing)
+	switch {
+	case found.Error != nil:
+		return found.Error
+	case found.RowsAffected == 0:
+		result.ID = 0
+		result.ScanCount = 1
+		result.FirstProbedAt = result.ProbedAt
+	default:
+		if err := tx.Delete(&models.Result{}, existing.ID).Error; err != nil {
+			return err
+		}
+
+		result.ID = existing.ID
+		result.ScanCount = max(existing.ScanCount, 1) + 1
+		result.FirstProbedAt = existing.FirstProbedAt
+		if result.FirstProbedAt.IsZero() {
+			// results written before upserts were enabled
+			result.FirstProbedAt = existing.ProbedAt
+		}
+	}
+
+	return tx.Create(result).Error
+}
+
+// upsertHash returns the UpsertKey hash of an upsert column value
+func upsertHash(column string, value string) string {
+	sum := sha256.Sum256([]byte(column + "\x00" + value))
+	return hex.EncodeToString(sum[:])
 }
 
 // AssignGroupID assigns a PerceptionHashGroupId based on Hamming distance
@@ -83,6 +329,11 @@ func (dw *DbWriter) AssignGroupID(perceptionHashStr string) (uint, error) {
 	}
 	nextGroupID := maxGroupID + 1
 
+	// results queued in a batch are not in the database yet
+	for _, group := range dw.hammingGroups {
+		nextGroupID = max(nextGroupID, group.GroupID+1)
+	}
+
 	// Add the new group to in-memory cache
 	newGroup := islazy.HammingGroup{
 		GroupID: nextGroupID,
@@ -0,0 +1,130 @@
+package writers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+func TestDbWriterUpsert(t *testing.T) {
+	w, err := NewDbWriter("sqlite://upsert?mode=memory", false, "final_url", 1)
+	if err != nil {
+		t.Fatalf("NewDbWriter() error = %v", err)
+	}
+	defer w.Close()
+
+	first := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	results := []*models.Result{
+		{URL: "https://example.com", FinalURL: "https://example.com/", ProbedAt: first},
+		{URL: "http://example.com", FinalURL: "https://example.com/", ProbedAt: first.Add(time.Hour)},
+		// failed results without a final url must not replace each other
+		{URL: "https://one.example.com", Failed: true, ProbedAt: first},
+		{URL: "https://two.example.com", Failed: true, ProbedAt: first},
+	}
+	for _, result := range results {
+		if err := w.Write(result); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	var rows []models.Result
+	if err := w.conn.Order("id").Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		url           string
+		scanCount     int
+		firstProbedAt time.Time
+	}{
+		{url: "http://example.com", scanCount: 2, firstProbedAt: first},
+		{url: "https://one.example.com", scanCount: 1, firstProbedAt: first},
+		{url: "https://two.example.com", scanCount: 1, firstProbedAt: first},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows =>\n\nhave: %v\nwant %v", len(rows), len(want))
+	}
+	for i, w := range want {
+		if rows[i].URL != w.url || rows[i].ScanCount != w.scanCount || !rows[i].FirstProbedAt.Equal(w.firstProbedAt) {
+			t.Errorf("row %d =>\n\nhave: %s %d %v\nwant %s %d %v", i,
+				rows[i].URL, rows[i].ScanCount, rows[i].FirstProbedAt, w.url, w.scanCount, w.firstProbedAt)
+		}
+	}
+}
+
+func TestDbWriterBatches(t *testing.T) {
+	w, err := NewDbWriter("sqlite://batches?mode=memory", false, "", 2)
+	if err != nil {
+		t.Fatalf("NewDbWriter() error = %v", err)
+	}
+
+	written := make(map[string]error)
+	w.OnWritten(func(result *models.Result, err error) {
+		written[result.URL] = err
+	})
+
+	tests := []struct {
+		name   string
+		result *models.Result
+		want   []string
+	}{
+		{
+			name:   "Test with queued result",
+			result: &models.Result{URL: "https://one.example.com"},
+			want:   nil,
+		},
+		{
+			name:   "Test with full batch",
+			result: &models.Result{URL: "https://two.example.com"},
+			want:   []string{"https://one.example.com", "https://two.example.com"},
+		},
+		{
+			// the id is taken, so this result fails the batch
+			name:   "Test with failing result",
+			result: &models.Result{ID: 1, URL: "https://three.example.com"},
+			want:   []string{"https://one.example.com", "https://two.example.com"},
+		},
+		{
+			name:   "Test with batch including a failing result",
+			result: &models.Result{URL: "https://four.example.com"},
+			want:   []string{"https://one.example.com", "https://two.example.com", "https://four.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := w.Write(tt.result); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			if len(written) != len(tt.want) {
+				t.Fatalf("written =>\n\nhave: %v\nwant %v", written, tt.want)
+			}
+			for _, url := range tt.want {
+				if err, ok := written[url]; !ok || err != nil {
+					t.Errorf("%s =>\n\nhave: written %v with error %v\nwant written", url, ok, err)
+				}
+			}
+		})
+	}
+
+	// the failing result stays queued until the writer gives up on it
+	if len(w.pending) != 1 || w.pending[0].URL != "https://three.example.com" {
+		t.Fatalf("pending =>\n\nhave: %v\nwant the failing result", w.pending)
+	}
+	if err := w.Close(); err == nil {
+		t.Errorf("Close() with a failing result did not fail")
+	}
+	if err, ok := written["https://three.example.com"]; !ok || err == nil {
+		t.Errorf("failing result =>\n\nhave: written %v with error %v\nwant an error", ok, err)
+	}
+
+	var count int64
+	if err := w.conn.Model(&models.Result{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("rows =>\n\nhave: %v\nwant %v", count, 3)
+	}
+}
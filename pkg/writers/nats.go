@@ -0,0 +1,254 @@
+package writers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// natsTimeout is how long to wait for the NATS server while connecting and
+// flushing a batch
+const natsTimeout = 30 * time.Second
+
+// natsMaxPending is the maximum number of results kept for a retry when
+// publishing fails. Results written while the queue is full are refused
+// with an error.
+const natsMaxPending = 10000
+
+// NatsWriter publishes results as JSON to a NATS subject.
+//
+// Results are published in batches. A batch is only removed once the server
+// confirmed it (with a flush round trip, or a JetStream ack for every
+// message when JetStream is enabled). Failed batches are retried with the
+// next write, so results are delivered at least once.
+type NatsWriter struct {
+	URL       string
+	Subject   string
+	BatchSize int
+	JetStream bool
+
+	mutex     sync.Mutex
+	conn      *nats.Conn
+	js        jetstream.JetStream
+	pending   []natsPending
+	onWritten func(*models.Result, error)
+}
+
+// natsPending is a queued result
+type natsPending struct {
+	result  *models.Result
+	payload []byte
+}
+
+// NewNatsWriter initialises a NATS writer. The uri is a nats:// (or tls://)
+// url, optionally with user:pass credentials.
+func NewNatsWriter(uri string, subject string, batchSize int, jetStream bool) (*NatsWriter, error) {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return nil, errors.New("invalid nats subject")
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	// reconnect forever, but don't buffer publishes while disconnected.
+	// queued results are retried by the writer instead, so that a failed
+	// batch is reported rather than silently buffered.
+	conn, err := nats.Connect(uri,
+		nats.Name("gowitness"),
+		nats.Timeout(natsTimeout),
+		nats.MaxReconnects(-1),
+		nats.ReconnectBufSize(-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &NatsWriter{
+		URL:       uri,
+		Subject:   subject,
+		BatchSize: batchSize,
+		JetStream: jetStream,
+		conn:      conn,
+	}
+
+	if jetStream {
+		w.js, err = jetstream.New(conn, jetstream.WithPublishAsyncTimeout(natsTimeout))
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+// OnWritten sets the function called for queued results once they are
+// published
+func (w *NatsWriter) OnWritten(fn func(result *models.Result, err error)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.onWritten = fn
+}
+
+// Write queues a result and publishes the queue once a batch is full
+func (w *NatsWriter) Write(result *models.Result) error {
+	j, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	w.mutex.Lock()
+	written, err := w.queue(result, j)
+	fn := w.onWritten
+	w.mutex.Unlock()
+
+	notifyWritten(fn, written)
+	return err
+}
+
+// Flush publishes the queued results, giving up on results that can't be
+// published
+func (w *NatsWriter) Flush() error {
+	w.mutex.Lock()
+	written, err := w.flush(true)
+	fn := w.onWritten
+	w.mutex.Unlock()
+
+	notifyWritten(fn, written)
+	return err
+}
+
+// Close publishes any queued results and closes the connection
+func (w *NatsWriter) Close() error {
+	err := w.Flush()
+	w.conn.Close()
+
+	return err
+}
+
+// queue adds a result to the queue and publishes the queue once a batch is
+// full. Failed results stay queued for a retry. The caller must hold the
+// mutex.
+func (w *NatsWriter) queue(result *models.Result, payload []byte) ([]writtenResult, error) {
+	// the server closes the connection on messages larger than its
+	// maximum payload, which would fail the whole batch on every retry
+	if max := w.conn.MaxPayload(); max > 0 && int64(len(payload)) > max {
+		return nil, fmt.Errorf("result for %s is %d bytes, larger than the nats maximum payload of %d bytes", result.URL, len(payload), max)
+	}
+
+	var written []writtenResult
+	if len(w.pending) >= natsMaxPending {
+		// try to make room before refusing the result
+		var err error
+		written, err = w.flush(false)
+		if err != nil {
+			return written, fmt.Errorf("nats queue is full with %d results, dropping result for %s: %w", len(w.pending), result.URL, err)
+		}
+	}
+
+	w.pending = append(w.pending, natsPending{result: result, payload: payload})
+	if len(w.pending) < w.BatchSize {
+		return written, nil
+	}
+
+	flushed, err := w.flush(false)
+	if err != nil {
+		log.Error("could not publish results to nats, retrying them with the next write", "queued", len(w.pending), "err", err)
+	}
+
+	return append(written, flushed...), nil
+}
+
+// flush publishes queued results and waits for the server to confirm them.
+// Results that were not confirmed stay queued, unless giveUp is set. The
+// caller must hold the mutex.
+func (w *NatsWriter) flush(giveUp bool) ([]writtenResult, error) {
+	if len(w.pending) == 0 {
+		return nil, nil
+	}
+
+	var written []writtenResult
+	var err error
+	if w.JetStream {
+		written, err = w.publishJetStream()
+	} else {
+		written, err = w.publish()
+	}
+	if err == nil {
+		return written, nil
+	}
+
+	err = fmt.Errorf("could not publish %d results to nats: %w", len(w.pending), err)
+	if giveUp {
+		for _, p := range w.pending {
+			written = append(written, writtenResult{result: p.result, err: err})
+		}
+		w.pending = nil
+	}
+
+	return written, err
+}
+
+// publish writes the queued results and waits for the server to process
+// them with a flush round trip
+func (w *NatsWriter) publish() ([]writtenResult, error) {
+	for _, p := range w.pending {
+		if err := w.conn.Publish(w.Subject, p.payload); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.conn.FlushTimeout(natsTimeout); err != nil {
+		return nil, err
+	}
+
+	written := make([]writtenResult, 0, len(w.pending))
+	for _, p := range w.pending {
+		written = append(written, writtenResult{result: p.result})
+	}
+	w.pending = nil
+
+	return written, nil
+}
+
+// publishJetStream publishes the queued results and waits for a JetStream
+// ack for each. Only results that were not acknowledged stay queued.
+func (w *NatsWriter) publishJetStream() ([]writtenResult, error) {
+	var errs []error
+
+	futures := make([]jetstream.PubAckFuture, 0, len(w.pending))
+	for _, p := range w.pending {
+		future, err := w.js.PublishAsync(w.Subject, p.payload)
+		if err != nil {
+			// wait for the acks of what was published before failing
+			errs = append(errs, err)
+			break
+		}
+		futures = append(futures, future)
+	}
+
+	// futures resolve with an error once the ack timeout passes
+	var written []writtenResult
+	var failed []natsPending
+	for i, future := range futures {
+		select {
+		case <-future.Ok():
+			written = append(written, writtenResult{result: w.pending[i].result})
+		case err := <-future.Err():
+			failed = append(failed, w.pending[i])
+			errs = append(errs, fmt.Errorf("%s: %w", w.pending[i].result.URL, err))
+		}
+	}
+
+	w.pending = append(failed, w.pending[len(futures):]...)
+	return written, errors.Join(errs...)
+}
@@ -0,0 +1,232 @@
+package writers
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// fakeNatsServer is a minimal core NATS server that records published
+// messages
+type fakeNatsServer struct {
+	listener net.Listener
+
+	mutex    sync.Mutex
+	conns    []net.Conn
+	messages []string
+}
+
+func newFakeNatsServer(t *testing.T) *fakeNatsServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeNatsServer{listener: listener}
+	go s.serve()
+	t.Cleanup(s.close)
+
+	return s
+}
+
+func (s *fakeNatsServer) url() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+func (s *fakeNatsServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.conns = append(s.conns, conn)
+		s.mutex.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeNatsServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	io.WriteString(conn, `INFO {"server_id":"fake","version":"2.10.0","proto":1,"max_payload":1048576}`+"\r\n")
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+		case "PUB":
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+
+			s.mutex.Lock()
+			s.messages = append(s.messages, fields[1]+" "+string(payload[:size]))
+			s.mutex.Unlock()
+		}
+	}
+}
+
+func (s *fakeNatsServer) published() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string(nil), s.messages...)
+}
+
+func (s *fakeNatsServer) close() {
+	s.listener.Close()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func TestNatsWriterBatches(t *testing.T) {
+	server := newFakeNatsServer(t)
+
+	w, err := NewNatsWriter(server.url(), "gowitness.results", 2, false)
+	if err != nil {
+		t.Fatalf("NewNatsWriter() error = %v", err)
+	}
+
+	// results are only reported as written once their batch was published
+	var written []string
+	w.OnWritten(func(result *models.Result, err error) {
+		if err != nil {
+			t.Errorf("OnWritten() error = %v", err)
+		}
+		written = append(written, result.URL)
+	})
+
+	tests := []struct {
+		url  string
+		want int
+	}{
+		{url: "https://one.example.com", want: 0},
+		{url: "https://two.example.com", want: 2},
+		{url: "https://three.example.com", want: 2},
+	}
+
+	for _, tt := range tests {
+		if err := w.Write(&models.Result{URL: tt.url}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if got := len(server.published()); got != tt.want {
+			t.Errorf("published after %s =>\n\nhave: %v\nwant %v", tt.url, got, tt.want)
+		}
+		if len(written) != tt.want {
+			t.Errorf("written after %s =>\n\nhave: %v\nwant %v", tt.url, len(written), tt.want)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(written) != 3 {
+		t.Errorf("written after Close() =>\n\nhave: %v\nwant %v", len(written), 3)
+	}
+
+	messages := server.published()
+	if len(messages) != 3 {
+		t.Fatalf("published after Close() =>\n\nhave: %v\nwant %v", len(messages), 3)
+	}
+	for i, message := range messages {
+		subject, payload, _ := strings.Cut(message, " ")
+		if subject != "gowitness.results" {
+			t.Errorf("subject =>\n\nhave: %v\nwant %v", subject, "gowitness.results")
+		}
+
+		var result models.Result
+		if err := json.Unmarshal([]byte(payload), &result); err != nil {
+			t.Fatalf("payload is not a result: %v", err)
+		}
+		if result.URL != tests[i].url {
+			t.Errorf("result url =>\n\nhave: %v\nwant %v", result.URL, tests[i].url)
+		}
+	}
+}
+
+func TestNatsWriterQueueFull(t *testing.T) {
+	server := newFakeNatsServer(t)
+
+	w, err := NewNatsWriter(server.url(), "gowitness.results", 1, false)
+	if err != nil {
+		t.Fatalf("NewNatsWriter() error = %v", err)
+	}
+	defer w.conn.Close()
+
+	server.close()
+	for deadline := time.Now().Add(5 * time.Second); w.conn.IsConnected(); {
+		if time.Now().After(deadline) {
+			t.Fatal("the writer did not notice the server going away")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var written, failed int
+	w.OnWritten(func(result *models.Result, err error) {
+		if err != nil {
+			failed++
+		} else {
+			written++
+		}
+	})
+
+	// failed results are queued for a retry
+	for i := range natsMaxPending {
+		if err := w.Write(&models.Result{URL: "https://example.com/" + strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if len(w.pending) != natsMaxPending {
+		t.Fatalf("pending =>\n\nhave: %v\nwant %v", len(w.pending), natsMaxPending)
+	}
+
+	// once the queue is full new results are refused, not older ones dropped
+	err = w.Write(&models.Result{URL: "https://example.com/refused"})
+	if err == nil || !strings.Contains(err.Error(), "https://example.com/refused") {
+		t.Errorf("Write() on a full queue =>\n\nhave: %v\nwant an error naming the refused result", err)
+	}
+	if w.pending[0].result.URL != "https://example.com/0" || len(w.pending) != natsMaxPending {
+		t.Errorf("the queue changed after refusing a result")
+	}
+
+	// flushing gives up on the queued results
+	if err := w.Flush(); err == nil {
+		t.Errorf("Flush() without a server did not fail")
+	}
+	if written != 0 || failed != natsMaxPending || len(w.pending) != 0 {
+		t.Errorf("after Flush() =>\n\nhave: %d written, %d failed, %d pending\nwant 0 written, %d failed, 0 pending",
+			written, failed, len(w.pending), natsMaxPending)
+	}
+}
@@ -0,0 +1,54 @@
+package writers
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/sensepost/gowitness/internal/islazy"
+	"github.com/sensepost/gowitness/pkg/models"
+)
+
+// PemWriter writes the TLS certificate chain of results to PEM files
+type PemWriter struct {
+	Path string
+}
+
+// NewPemWriter returns a new PEM writer that writes to a directory
+func NewPemWriter(destination string) (*PemWriter, error) {
+	dst, err := islazy.CreateDir(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PemWriter{
+		Path: dst,
+	}, nil
+}
+
+// Write the certificate chain of a result, leaf first, to a file named by
+// the host (and port, if it is not 443). Results without a certificate are
+// skipped.
+func (pw *PemWriter) Write(result *models.Result) error {
+	if result.TLS.PEM == "" {
+		return nil
+	}
+
+	target := result.FinalURL
+	if target == "" {
+		target = result.URL
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+
+	name := u.Hostname()
+	if port := u.Port(); port != "" && port != "443" {
+		name += "_" + port
+	}
+
+	path := filepath.Join(pw.Path, islazy.SafeFileName(name)+".pem")
+	return os.WriteFile(path, []byte(result.TLS.PEM), 0644)
+}
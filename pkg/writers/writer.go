@@ -6,3 +6,41 @@ import "github.com/sensepost/gowitness/pkg/models"
 type Writer interface {
 	Write(*models.Result) error
 }
+
+// Closer is implemented by writers that buffer results, and need to flush
+// them and release resources when a run is done
+type Closer interface {
+	Close() error
+}
+
+// Batcher is implemented by writers that queue results, to write them in
+// batches or to retry them after a failure. For these writers a nil error
+// from Write only means that the result was queued. The function set with
+// OnWritten is called for every queued result once it was written, or with
+// an error once the writer gave up on it.
+type Batcher interface {
+	OnWritten(fn func(result *models.Result, err error))
+	// Flush writes the queued results, giving up on results that can't
+	// be written
+	Flush() error
+}
+
+// writtenResult is a queued result that was written, or given up on with
+// err
+type writtenResult struct {
+	result *models.Result
+	err    error
+}
+
+// notifyWritten passes written results to the OnWritten function of a
+// Batcher. It must not be called while holding the writer's mutex, as the
+// function may take a while.
+func notifyWritten(fn func(*models.Result, error), written []writtenResult) {
+	if fn == nil {
+		return
+	}
+
+	for _, w := range written {
+		fn(w.result, w.err)
+	}
+}
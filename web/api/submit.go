@@ -87,7 +87,7 @@ func (h *ApiHandler) SubmitHandler(w http.ResponseWriter, r *http.Request) {
 		options.Scan.ScreenshotFullPage = request.Options.FullPage
 	}
 
-	writer, err := writers.NewDbWriter(h.DbURI, false)
+	writer, err := writers.NewDbWriter(h.DbURI, false, "", 1)
 	if err != nil {
 		http.Error(w, "Error connecting to DB for writer", http.StatusInternalServerError)
 		return
@@ -0,0 +1,65 @@
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	"github.com/sensepost/gowitness/pkg/log"
+	"github.com/sensepost/gowitness/pkg/models"
+	"github.com/sensepost/gowitness/pkg/writers"
+)
+
+// jsonlDbUri is the in-memory database results from a JSON Lines file are
+// served from
+const jsonlDbUri = "sqlite://gowitness-jsonl?mode=memory"
+
+// maxJsonlLine is the longest JSON line that will be read. Results can be
+// large when HTML and network content is saved.
+const maxJsonlLine = 256 * 1024 * 1024
+
+// loadJsonl reads results from a JSON Lines file into a database
+func loadJsonl(path string, uri string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := writers.NewDbWriter(uri, false, "", 1)
+	if err != nil {
+		return err
+	}
+
+	var c = 0
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJsonlLine)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var result models.Result
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			log.Error("could not unmarshal JSON line", "err", err)
+			continue
+		}
+
+		// ids are assigned by the database
+		result.ID = 0
+		if err := writer.Write(&result); err != nil {
+			return err
+		}
+		c++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	log.Info("loaded results from a JSON Lines file", "file", path, "rows", c)
+	return nil
+}
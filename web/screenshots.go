@@ -0,0 +1,48 @@
+package web
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/sensepost/gowitness/pkg/log"
+)
+
+// screenshotHandler serves screenshot files, transparently decompressing
+// gzip compressed screenshots
+func screenshotHandler(root string) http.Handler {
+	fileServer := http.FileServer(http.Dir(root))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, ".gz") {
+			fileServer.ServeHTTP(w, r)
+			return
+		}
+
+		file, err := http.Dir(root).Open(r.URL.Path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer file.Close()
+
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			http.Error(w, "could not decompress screenshot", http.StatusInternalServerError)
+			return
+		}
+		defer reader.Close()
+
+		name := strings.TrimSuffix(path.Base(r.URL.Path), ".gz")
+		if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+
+		if _, err := io.Copy(w, reader); err != nil {
+			log.Error("could not serve compressed screenshot", "file", r.URL.Path, "err", err)
+		}
+	})
+}
@@ -20,6 +20,9 @@ type Server struct {
 	Port           int
 	DbUri          string
 	ScreenshotPath string
+	// FromJsonl is a JSON Lines file to serve results from instead of a
+	// database. Results are loaded into an in-memory database.
+	FromJsonl string
 }
 
 // NewServer returns a new server intance
@@ -57,12 +60,26 @@ func (s *Server) Run() {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
 
-	apih, err := api.NewApiHandler(s.DbUri, s.ScreenshotPath)
+	dbUri := s.DbUri
+	if s.FromJsonl != "" {
+		dbUri = jsonlDbUri
+	}
+
+	apih, err := api.NewApiHandler(dbUri, s.ScreenshotPath)
 	if err != nil {
 		log.Error("could not get api handler up", "err", err)
 		return
 	}
 
+	// the api handler keeps the in-memory database open while results
+	// are loaded into it
+	if s.FromJsonl != "" {
+		if err := loadJsonl(s.FromJsonl, jsonlDbUri); err != nil {
+			log.Error("could not load results from jsonl file", "file", s.FromJsonl, "err", err)
+			return
+		}
+	}
+
 	r.Route("/api", func(r chi.Router) {
 		r.Use(isJSON)
 		r.Use(cors.Handler(cors.Options{
@@ -84,7 +101,7 @@ func (s *Server) Run() {
 	})
 
 	// screenshot files
-	r.Mount("/screenshots", http.StripPrefix("/screenshots/", http.FileServer(http.Dir(s.ScreenshotPath))))
+	r.Mount("/screenshots", http.StripPrefix("/screenshots/", screenshotHandler(s.ScreenshotPath)))
 
 	// swagger documentation
 	r.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL("/swagger/doc.json")))